@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// milliunitDigits is the number of decimal places of YNAB milliunits
+const milliunitDigits = 3
+
+// currencyMinorUnits maps ISO 4217 currencies that don't have 2 decimal places to the number they have
+var currencyMinorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// minorUnits returns the number of decimal places of an ISO 4217 currency code
+func minorUnits(currency string) (int, error) {
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return 0, errors.Errorf("invalid currency code: %q", currency)
+	}
+
+	if digits, ok := currencyMinorUnits[currency]; ok {
+		return digits, nil
+	}
+	return 2, nil
+}
+
+// parseMilliunits converts a decimal amount of currency, e.g. "-19.99", to YNAB milliunits without rounding.
+// Amounts with more decimal places than the currency has are rejected unless the extra places are zeros.
+func parseMilliunits(amount, currency string) (int64, error) {
+	digits, err := minorUnits(currency)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseDecimalMilliunits(amount, digits, currency)
+}
+
+// parseDecimalMilliunits converts a decimal amount to milliunits, rejecting it when it has more than digits
+// significant decimal places of unit
+func parseDecimalMilliunits(amount string, digits int, unit string) (int64, error) {
+	negative := false
+	number := amount
+	switch {
+	case strings.HasPrefix(number, "-"):
+		negative = true
+		number = number[1:]
+	case strings.HasPrefix(number, "+"):
+		number = number[1:]
+	}
+
+	whole, fraction, hasFraction := strings.Cut(number, ".")
+	if whole == "" || !isDigits(whole) || (hasFraction && (fraction == "" || !isDigits(fraction))) {
+		return 0, errors.Errorf("invalid amount: %q", amount)
+	}
+
+	significant := strings.TrimRight(fraction, "0")
+	if len(significant) > digits {
+		return 0, errors.Errorf("invalid amount: %q has more than %d decimal places of %s", amount, digits, unit)
+	}
+
+	var milliunits int64
+	for _, d := range whole + significant + strings.Repeat("0", milliunitDigits-len(significant)) {
+		if milliunits > (math.MaxInt64-9)/10 {
+			return 0, errors.Errorf("invalid amount: %q is too large", amount)
+		}
+		milliunits = milliunits*10 + int64(d-'0')
+	}
+
+	if negative {
+		milliunits = -milliunits
+	}
+	return milliunits, nil
+}
+
+// isDigits reports whether s consists of ASCII digits only
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// formatAmount formats YNAB milliunits as a decimal amount with the decimal places of currency, e.g. "-19.99"
+func formatAmount(milliunits int64, currency string) string {
+	digits, err := minorUnits(currency)
+	if err != nil {
+		digits = 2
+	}
+
+	sign := ""
+	abs := uint64(milliunits)
+	if milliunits < 0 {
+		sign = "-"
+		abs = uint64(-milliunits)
+	}
+
+	whole := strconv.FormatUint(abs/1000, 10)
+	if digits == 0 {
+		return sign + whole
+	}
+	fraction := fmt.Sprintf("%03d", abs%1000)
+	return sign + whole + "." + fraction[:digits]
+}
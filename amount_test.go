@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMilliunits(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     int64
+		wantErr  string
+	}{
+		// Amounts float64 can't represent exactly, int64(amount * 1000) turned them into one milliunit less
+		{name: "float rounding", amount: "2.01", currency: "EUR", want: 2010},
+		{name: "negative float rounding", amount: "-8.12", currency: "EUR", want: -8120},
+		{name: "larger float rounding", amount: "16.24", currency: "EUR", want: 16240},
+		{name: "float rounding of three decimals", amount: "1.005", currency: "BHD", want: 1005},
+		{name: "negative cents", amount: "-19.99", currency: "EUR", want: -19990},
+		{name: "large cents", amount: "1234567.89", currency: "EUR", want: 1234567890},
+		{name: "one decimal place", amount: "-12.5", currency: "EUR", want: -12500},
+		{name: "whole amount", amount: "100", currency: "EUR", want: 100000},
+		{name: "plus sign", amount: "+7.00", currency: "GBP", want: 7000},
+		{name: "negative zero", amount: "-0.00", currency: "EUR", want: 0},
+		{name: "trailing zeros", amount: "1.2300", currency: "EUR", want: 1230},
+		{name: "currency without decimals", amount: "-1500", currency: "JPY", want: -1500000},
+		{name: "currency without decimals with zero cents", amount: "1500.00", currency: "JPY", want: 1500000},
+		{name: "currency with three decimals", amount: "-12.345", currency: "KWD", want: -12345},
+		{name: "cents of a currency without decimals", amount: "1500.5", currency: "JPY", wantErr: `invalid amount: "1500.5" has more than 0 decimal places of JPY`},
+		{name: "fraction of a cent", amount: "1.234", currency: "EUR", wantErr: `invalid amount: "1.234" has more than 2 decimal places of EUR`},
+		{name: "empty", amount: "", currency: "EUR", wantErr: `invalid amount: ""`},
+		{name: "sign only", amount: "-", currency: "EUR", wantErr: `invalid amount: "-"`},
+		{name: "missing whole part", amount: ".50", currency: "EUR", wantErr: `invalid amount: ".50"`},
+		{name: "missing fraction", amount: "1.", currency: "EUR", wantErr: `invalid amount: "1."`},
+		{name: "decimal comma", amount: "1,50", currency: "EUR", wantErr: `invalid amount: "1,50"`},
+		{name: "exponent", amount: "1e3", currency: "EUR", wantErr: `invalid amount: "1e3"`},
+		{name: "thousands separator", amount: "1,000.00", currency: "EUR", wantErr: `invalid amount: "1,000.00"`},
+		{name: "too large", amount: "99999999999999999.99", currency: "EUR", wantErr: `invalid amount: "99999999999999999.99" is too large`},
+		{name: "lowercase currency", amount: "1.00", currency: "eur", wantErr: `invalid currency code: "eur"`},
+		{name: "missing currency", amount: "1.00", currency: "", wantErr: `invalid currency code: ""`},
+		{name: "long currency", amount: "1.00", currency: "EURO", wantErr: `invalid currency code: "EURO"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMilliunits(tt.amount, tt.currency)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	assert.Equal(t, "-19.99", formatAmount(-19990, "EUR"))
+	assert.Equal(t, "0.50", formatAmount(500, "GBP"))
+	assert.Equal(t, "-1500", formatAmount(-1500000, "JPY"))
+	assert.Equal(t, "12.345", formatAmount(12345, "KWD"))
+}
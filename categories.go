@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/brunomvsouza/ynab.go/api/category"
+	"github.com/pkg/errors"
+)
+
+// categoryIDs maps the names of the categories of a budget, and their "Group: Category" names, to their IDs.
+// Names are lowercased, names several categories share map to an empty ID and deleted categories are left out.
+func categoryIDs(groups []*category.GroupWithCategories) map[string]string {
+	ids := make(map[string]string)
+	add := func(name, id string) {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if existing, ok := ids[key]; ok && existing != id {
+			ids[key] = ""
+			return
+		}
+		ids[key] = id
+	}
+
+	for _, group := range groups {
+		if group == nil || group.Deleted {
+			continue
+		}
+		for _, c := range group.Categories {
+			if c == nil || c.Deleted {
+				continue
+			}
+			add(c.Name, c.ID)
+			add(group.Name+": "+c.Name, c.ID)
+		}
+	}
+	return ids
+}
+
+// resolveCategories sets the ID of the category rules assigned to transactions, from the categories of the job's
+// budget. Transactions whose category isn't found, or is ambiguous, are left uncategorized and unapproved.
+func (s *SyncService) resolveCategories(ctx context.Context, j job, transactions []Transaction) error {
+	if !slices.ContainsFunc(transactions, func(t Transaction) bool { return t.Category != "" }) {
+		return nil
+	}
+
+	groups, err := s.ynabService.GetCategories(j.YNABBudgetID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get categories")
+	}
+	ids := categoryIDs(groups)
+
+	for i := range transactions {
+		t := &transactions[i]
+		if t.Category == "" {
+			continue
+		}
+
+		id, ok := ids[strings.ToLower(strings.TrimSpace(t.Category))]
+		if id == "" {
+			reason := "category not found in budget"
+			if ok {
+				reason = "category name is ambiguous, use \"Group: Category\""
+			}
+			slog.Default().WarnContext(ctx, "left transaction uncategorized", "ynab_budget_id", j.YNABBudgetID, "id", t.ID, "category", t.Category, "reason", reason)
+			t.Approved = false
+			continue
+		}
+		t.CategoryID = id
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api/category"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryIDs(t *testing.T) {
+	ids := categoryIDs([]*category.GroupWithCategories{
+		{Name: "Bills", Categories: []*category.Category{
+			{ID: "rent", Name: "Rent"},
+			{ID: "fees", Name: "Fees"},
+			{ID: "old", Name: "Phone", Deleted: true},
+		}},
+		{Name: "Car", Categories: []*category.Category{
+			{ID: "car-fees", Name: "Fees"},
+			{ID: "fuel", Name: "Fuel", Hidden: true},
+		}},
+		{Name: "Removed", Deleted: true, Categories: []*category.Category{
+			{ID: "gone", Name: "Gone"},
+		}},
+	})
+
+	assert.Equal(t, map[string]string{
+		"rent":        "rent",
+		"bills: rent": "rent",
+		"fees":        "",
+		"bills: fees": "fees",
+		"car: fees":   "car-fees",
+		"fuel":        "fuel",
+		"car: fuel":   "fuel",
+	}, ids)
+}
+
+func TestSyncServiceListTransactionsCategories(t *testing.T) {
+	testJob := job{GCAccountID: "aaa", YNABAccountID: "bbb", YNABBudgetID: "ccc"}
+	now := time.Now().UTC()
+	path := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"categories": [
+		{"match": {"payee": "Landlord"}, "category": "bills: rent", "approve": true},
+		{"match": {"payee": "Garage"}, "category": "Fees", "approve": true},
+		{"match": {"payee": "Cinema"}, "category": "Fun"}
+	]}`), 0o600))
+	categorized, err := newRulesFile(path)
+	require.NoError(t, err)
+
+	t.Run("resolves categories in the budget", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService := &SyncService{gcService: goCardlessMock, ynabService: ynabMock, rules: categorized}
+
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", mock.Anything, mock.Anything).Return([]Transaction{
+			{ID: "rent", Name: "Landlord", AmountMili: -2000000},
+			{ID: "garage", Name: "Garage", AmountMili: -50000},
+			{ID: "cinema", Name: "Cinema", AmountMili: -30000},
+			{ID: "shop", Name: "Shop", AmountMili: -1000},
+		}, nil)
+		ynabMock.EXPECT().GetCategories("ccc").Return([]*category.GroupWithCategories{
+			{Name: "Bills", Categories: []*category.Category{{ID: "rent-id", Name: "Rent"}, {ID: "fees-id", Name: "Fees"}}},
+			{Name: "Car", Categories: []*category.Category{{ID: "car-fees-id", Name: "Fees"}}},
+		}, nil).Once()
+
+		transactions, _, err := syncService.listTransactions(context.Background(), testJob, now, now)
+		assert.NoError(t, err)
+		if assert.Len(t, transactions, 4) {
+			assert.Equal(t, "rent-id", transactions[0].CategoryID)
+			assert.True(t, transactions[0].Approved)
+			// Ambiguous and unknown categories are left out, along with the approval
+			assert.Empty(t, transactions[1].CategoryID)
+			assert.False(t, transactions[1].Approved)
+			assert.Empty(t, transactions[2].CategoryID)
+			assert.Empty(t, transactions[3].CategoryID)
+		}
+	})
+
+	t.Run("doesn't fetch categories without a match", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService := &SyncService{gcService: goCardlessMock, ynabService: ynabMock, rules: categorized}
+
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", mock.Anything, mock.Anything).Return([]Transaction{{ID: "shop", Name: "Shop"}}, nil)
+
+		_, _, err := syncService.listTransactions(context.Background(), testJob, now, now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("fails without the categories", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService := &SyncService{gcService: goCardlessMock, ynabService: ynabMock, rules: categorized}
+
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", mock.Anything, mock.Anything).Return([]Transaction{{ID: "rent", Name: "Landlord"}}, nil)
+		ynabMock.EXPECT().GetCategories("ccc").Return(nil, assert.AnError)
+
+		_, _, err := syncService.listTransactions(context.Background(), testJob, now, now)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
@@ -0,0 +1,34 @@
+// Command fakegocardless serves a fake GoCardless Bank Account Data API with sandbox accounts, so the sync and
+// link commands can be tried locally by pointing GC_BASE_URL at it
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"psmarcin.github.com/open-ynab-sync/internal/fakegocardless"
+)
+
+func main() {
+	logger := slog.Default()
+
+	addr := flag.String("addr", "localhost:8090", "Address to listen on")
+	secretID := flag.String("secret-id", "fake-secret-id", "Secret ID clients log in with")
+	secretKey := flag.String("secret-key", "fake-secret-key", "Secret key clients log in with")
+	dailyLimit := flag.Int("daily-limit", 4, "Requests a day each data endpoint of an account answers, 0 for unlimited")
+	flag.Parse()
+
+	fake := fakegocardless.New(*secretID, *secretKey)
+	for _, account := range fake.AddSandboxAccounts(time.Now(), *dailyLimit) {
+		logger.Info("account", "id", account.ID, "institution_id", account.InstitutionID, "iban", account.IBAN)
+	}
+
+	logger.Info("serving fake GoCardless API", "gc_base_url", "http://"+*addr+fakegocardless.APIPath)
+	if err := http.ListenAndServe(*addr, fake); err != nil {
+		logger.Error("failed to serve", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,288 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package auth
+
+import (
+	"context"
+
+	mock "github.com/stretchr/testify/mock"
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+// NewMockGoCardlessClient creates a new instance of MockGoCardlessClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockGoCardlessClient(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockGoCardlessClient {
+	mock := &MockGoCardlessClient{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockGoCardlessClient is an autogenerated mock type for the GoCardlessClient type
+type MockGoCardlessClient struct {
+	mock.Mock
+}
+
+type MockGoCardlessClient_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockGoCardlessClient) EXPECT() *MockGoCardlessClient_Expecter {
+	return &MockGoCardlessClient_Expecter{mock: &_m.Mock}
+}
+
+// CreateAgreement provides a mock function for the type MockGoCardlessClient
+func (_mock *MockGoCardlessClient) CreateAgreement(ctx context.Context, request gocardless.AgreementRequest) (gocardless.Agreement, error) {
+	ret := _mock.Called(ctx, request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAgreement")
+	}
+
+	var r0 gocardless.Agreement
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, gocardless.AgreementRequest) (gocardless.Agreement, error)); ok {
+		return returnFunc(ctx, request)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, gocardless.AgreementRequest) gocardless.Agreement); ok {
+		r0 = returnFunc(ctx, request)
+	} else {
+		r0 = ret.Get(0).(gocardless.Agreement)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, gocardless.AgreementRequest) error); ok {
+		r1 = returnFunc(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockGoCardlessClient_CreateAgreement_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAgreement'
+type MockGoCardlessClient_CreateAgreement_Call struct {
+	*mock.Call
+}
+
+// CreateAgreement is a helper method to define mock.On call
+//   - ctx context.Context
+//   - request gocardless.AgreementRequest
+func (_e *MockGoCardlessClient_Expecter) CreateAgreement(ctx interface{}, request interface{}) *MockGoCardlessClient_CreateAgreement_Call {
+	return &MockGoCardlessClient_CreateAgreement_Call{Call: _e.mock.On("CreateAgreement", ctx, request)}
+}
+
+func (_c *MockGoCardlessClient_CreateAgreement_Call) Run(run func(ctx context.Context, request gocardless.AgreementRequest)) *MockGoCardlessClient_CreateAgreement_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 gocardless.AgreementRequest
+		if args[1] != nil {
+			arg1 = args[1].(gocardless.AgreementRequest)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockGoCardlessClient_CreateAgreement_Call) Return(agreement gocardless.Agreement, err error) *MockGoCardlessClient_CreateAgreement_Call {
+	_c.Call.Return(agreement, err)
+	return _c
+}
+
+func (_c *MockGoCardlessClient_CreateAgreement_Call) RunAndReturn(run func(ctx context.Context, request gocardless.AgreementRequest) (gocardless.Agreement, error)) *MockGoCardlessClient_CreateAgreement_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateRequisition provides a mock function for the type MockGoCardlessClient
+func (_mock *MockGoCardlessClient) CreateRequisition(ctx context.Context, request gocardless.RequisitionRequest) (gocardless.Requisition, error) {
+	ret := _mock.Called(ctx, request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRequisition")
+	}
+
+	var r0 gocardless.Requisition
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, gocardless.RequisitionRequest) (gocardless.Requisition, error)); ok {
+		return returnFunc(ctx, request)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, gocardless.RequisitionRequest) gocardless.Requisition); ok {
+		r0 = returnFunc(ctx, request)
+	} else {
+		r0 = ret.Get(0).(gocardless.Requisition)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, gocardless.RequisitionRequest) error); ok {
+		r1 = returnFunc(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockGoCardlessClient_CreateRequisition_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRequisition'
+type MockGoCardlessClient_CreateRequisition_Call struct {
+	*mock.Call
+}
+
+// CreateRequisition is a helper method to define mock.On call
+//   - ctx context.Context
+//   - request gocardless.RequisitionRequest
+func (_e *MockGoCardlessClient_Expecter) CreateRequisition(ctx interface{}, request interface{}) *MockGoCardlessClient_CreateRequisition_Call {
+	return &MockGoCardlessClient_CreateRequisition_Call{Call: _e.mock.On("CreateRequisition", ctx, request)}
+}
+
+func (_c *MockGoCardlessClient_CreateRequisition_Call) Run(run func(ctx context.Context, request gocardless.RequisitionRequest)) *MockGoCardlessClient_CreateRequisition_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 gocardless.RequisitionRequest
+		if args[1] != nil {
+			arg1 = args[1].(gocardless.RequisitionRequest)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockGoCardlessClient_CreateRequisition_Call) Return(requisition gocardless.Requisition, err error) *MockGoCardlessClient_CreateRequisition_Call {
+	_c.Call.Return(requisition, err)
+	return _c
+}
+
+func (_c *MockGoCardlessClient_CreateRequisition_Call) RunAndReturn(run func(ctx context.Context, request gocardless.RequisitionRequest) (gocardless.Requisition, error)) *MockGoCardlessClient_CreateRequisition_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRequisition provides a mock function for the type MockGoCardlessClient
+func (_mock *MockGoCardlessClient) GetRequisition(ctx context.Context, requisitionID string) (gocardless.Requisition, error) {
+	ret := _mock.Called(ctx, requisitionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRequisition")
+	}
+
+	var r0 gocardless.Requisition
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (gocardless.Requisition, error)); ok {
+		return returnFunc(ctx, requisitionID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) gocardless.Requisition); ok {
+		r0 = returnFunc(ctx, requisitionID)
+	} else {
+		r0 = ret.Get(0).(gocardless.Requisition)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, requisitionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockGoCardlessClient_GetRequisition_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRequisition'
+type MockGoCardlessClient_GetRequisition_Call struct {
+	*mock.Call
+}
+
+// GetRequisition is a helper method to define mock.On call
+//   - ctx context.Context
+//   - requisitionID string
+func (_e *MockGoCardlessClient_Expecter) GetRequisition(ctx interface{}, requisitionID interface{}) *MockGoCardlessClient_GetRequisition_Call {
+	return &MockGoCardlessClient_GetRequisition_Call{Call: _e.mock.On("GetRequisition", ctx, requisitionID)}
+}
+
+func (_c *MockGoCardlessClient_GetRequisition_Call) Run(run func(ctx context.Context, requisitionID string)) *MockGoCardlessClient_GetRequisition_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockGoCardlessClient_GetRequisition_Call) Return(requisition gocardless.Requisition, err error) *MockGoCardlessClient_GetRequisition_Call {
+	_c.Call.Return(requisition, err)
+	return _c
+}
+
+func (_c *MockGoCardlessClient_GetRequisition_Call) RunAndReturn(run func(ctx context.Context, requisitionID string) (gocardless.Requisition, error)) *MockGoCardlessClient_GetRequisition_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LogIn provides a mock function for the type MockGoCardlessClient
+func (_mock *MockGoCardlessClient) LogIn(ctx context.Context) error {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LogIn")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockGoCardlessClient_LogIn_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LogIn'
+type MockGoCardlessClient_LogIn_Call struct {
+	*mock.Call
+}
+
+// LogIn is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockGoCardlessClient_Expecter) LogIn(ctx interface{}) *MockGoCardlessClient_LogIn_Call {
+	return &MockGoCardlessClient_LogIn_Call{Call: _e.mock.On("LogIn", ctx)}
+}
+
+func (_c *MockGoCardlessClient_LogIn_Call) Run(run func(ctx context.Context)) *MockGoCardlessClient_LogIn_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockGoCardlessClient_LogIn_Call) Return(err error) *MockGoCardlessClient_LogIn_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockGoCardlessClient_LogIn_Call) RunAndReturn(run func(ctx context.Context) error) *MockGoCardlessClient_LogIn_Call {
+	_c.Call.Return(run)
+	return _c
+}
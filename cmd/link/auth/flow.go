@@ -10,14 +10,22 @@ import (
 
 	"github.com/pkg/errors"
 
-	"psmarcin.github.com/open-ynab-sync/cmd/link/api"
 	"psmarcin.github.com/open-ynab-sync/cmd/link/config"
 	"psmarcin.github.com/open-ynab-sync/cmd/link/server"
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
 )
 
+// GoCardlessClient defines the part of the GoCardless API the authorization flow uses
+type GoCardlessClient interface {
+	LogIn(ctx context.Context) error
+	CreateAgreement(ctx context.Context, request gocardless.AgreementRequest) (gocardless.Agreement, error)
+	CreateRequisition(ctx context.Context, request gocardless.RequisitionRequest) (gocardless.Requisition, error)
+	GetRequisition(ctx context.Context, requisitionID string) (gocardless.Requisition, error)
+}
+
 // AuthFlow manages the authorization flow
 type AuthFlow struct {
-	Client          api.GoCardlessClient
+	Client          GoCardlessClient
 	Config          *config.Config
 	Logger          *slog.Logger
 	CallbackSrv     *server.CallbackServer
@@ -25,7 +33,7 @@ type AuthFlow struct {
 }
 
 // NewAuthFlow creates a new authorization flow
-func NewAuthFlow(client api.GoCardlessClient, cfg *config.Config, logger *slog.Logger, callbackSrv *server.CallbackServer) *AuthFlow {
+func NewAuthFlow(client GoCardlessClient, cfg *config.Config, logger *slog.Logger, callbackSrv *server.CallbackServer) *AuthFlow {
 	return &AuthFlow{
 		Client:      client,
 		Config:      cfg,
@@ -54,17 +62,28 @@ func (a *AuthFlow) Execute(ctx context.Context) ([]string, error) {
 	}
 
 	// Create agreement
-	agreementID, err := a.Client.CreateAgreement(ctx, a.Config.InstitutionID)
+	agreement, err := a.Client.CreateAgreement(ctx, gocardless.AgreementRequest{
+		InstitutionID:      a.Config.InstitutionID,
+		MaxHistoricalDays:  90,
+		AccessValidForDays: 179,
+		AccessScope:        []string{"balances", "details", "transactions"},
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create agreement")
 	}
 
 	// Create requisition
-	redirectURL := a.CallbackSrv.GetCallbackURL()
-	requisitionID, link, err := a.Client.CreateRequisition(ctx, a.Config.InstitutionID, agreementID, redirectURL)
+	requisition, err := a.Client.CreateRequisition(ctx, gocardless.RequisitionRequest{
+		Redirect:      a.CallbackSrv.GetCallbackURL(),
+		InstitutionID: a.Config.InstitutionID,
+		Reference:     generateReference(),
+		Agreement:     agreement.ID,
+		UserLanguage:  "EN",
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create requisition")
 	}
+	link := requisition.Link
 
 	if a.AutoOpenBrowser {
 		// Open the link in the browser
@@ -85,20 +104,25 @@ func (a *AuthFlow) Execute(ctx context.Context) ([]string, error) {
 	time.Sleep(2 * time.Second)
 
 	// Check requisition status
-	status, accounts, err := a.Client.GetRequisitionStatus(ctx, requisitionID)
+	requisition, err = a.Client.GetRequisition(ctx, requisition.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get requisition status")
 	}
 
-	if status != "LN" {
-		return nil, errors.Errorf("requisition is not in linked state (LN), current state: %s", status)
+	if requisition.Status != gocardless.RequisitionStatusLinked {
+		return nil, errors.Errorf("requisition is not in linked state (LN), current state: %s", requisition.Status)
 	}
 
-	if len(accounts) == 0 {
+	if len(requisition.Accounts) == 0 {
 		return nil, errors.New("no accounts linked")
 	}
 
-	return accounts, nil
+	return requisition.Accounts, nil
+}
+
+// generateReference creates a random reference string
+func generateReference() string {
+	return fmt.Sprintf("REF_%d", time.Now().UnixNano())
 }
 
 // openBrowser opens a URL in the default browser
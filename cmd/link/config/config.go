@@ -14,6 +14,8 @@ type Config struct {
 	// GoCardless credentials
 	GCSecretID  string
 	GCSecretKey string
+	// GCBaseURL is the base URL of the GoCardless API, the real one when empty
+	GCBaseURL string
 
 	// Institution ID to link with
 	InstitutionID string
@@ -50,6 +52,7 @@ func LoadConfig() (*Config, error) {
 	// Get GoCardless credentials from environment variables
 	secretID := os.Getenv("GC_SECRET_ID")
 	secretKey := os.Getenv("GC_SECRET_KEY")
+	baseURL := os.Getenv("GC_BASE_URL")
 
 	if secretID == "" || secretKey == "" {
 		return nil, fmt.Errorf("GC_SECRET_ID and GC_SECRET_KEY environment variables are required")
@@ -58,6 +61,7 @@ func LoadConfig() (*Config, error) {
 	return &Config{
 		GCSecretID:    secretID,
 		GCSecretKey:   secretKey,
+		GCBaseURL:     baseURL,
 		InstitutionID: *institutionID,
 		Port:          *port,
 		AuthTimeout:   *authTimeout,
@@ -7,10 +7,11 @@ import (
 	"os/signal"
 	"syscall"
 
-	"psmarcin.github.com/open-ynab-sync/cmd/link/api"
 	"psmarcin.github.com/open-ynab-sync/cmd/link/auth"
 	"psmarcin.github.com/open-ynab-sync/cmd/link/config"
 	"psmarcin.github.com/open-ynab-sync/cmd/link/server"
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+	"psmarcin.github.com/open-ynab-sync/internal/retry"
 )
 
 func main() {
@@ -38,7 +39,14 @@ func main() {
 	}
 
 	// Create GoCardless client
-	gcClient := api.NewGoCardless(cfg.GCSecretID, cfg.GCSecretKey, cfg.HTTPTimeout, logger)
+	gcClient := gocardless.New(gocardless.Config{
+		SecretID:  cfg.GCSecretID,
+		SecretKey: cfg.GCSecretKey,
+		BaseURL:   cfg.GCBaseURL,
+		Timeout:   cfg.HTTPTimeout,
+		Retry:     retry.DefaultPolicy(),
+		Logger:    logger,
+	})
 
 	// Create callback server
 	callbackServer := server.NewCallbackServer(cfg.Port, logger)
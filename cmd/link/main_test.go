@@ -3,30 +3,37 @@ package main
 import (
 	"context"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	apimock "psmarcin.github.com/open-ynab-sync/cmd/link/api/apimock"
 	"psmarcin.github.com/open-ynab-sync/cmd/link/auth"
+	authmock "psmarcin.github.com/open-ynab-sync/cmd/link/auth/authmock"
 	"psmarcin.github.com/open-ynab-sync/cmd/link/config"
 	"psmarcin.github.com/open-ynab-sync/cmd/link/server"
+	"psmarcin.github.com/open-ynab-sync/internal/fakegocardless"
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
 )
 
 // TestMainFlow tests the main flow of the application without actually running main()
 func TestMainFlow(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		// Mock the GoCardless client
-		mockClient := apimock.NewMockGoCardlessClient(t)
+		mockClient := authmock.NewMockGoCardlessClient(t)
 
 		// Setup expectations
 		mockClient.On("LogIn", mock.Anything).Return(nil)
-		mockClient.On("CreateAgreement", mock.Anything, "test-institution").Return("agreement-id", nil)
-		mockClient.On("CreateRequisition", mock.Anything, "test-institution", "agreement-id", mock.Anything).
-			Return("requisition-id", "http://auth-link", nil)
-		mockClient.On("GetRequisitionStatus", mock.Anything, "requisition-id").
-			Return("LN", []string{"account-id-1", "account-id-2"}, nil)
+		mockClient.On("CreateAgreement", mock.Anything, mock.MatchedBy(func(r gocardless.AgreementRequest) bool {
+			return r.InstitutionID == "test-institution"
+		})).Return(gocardless.Agreement{ID: "agreement-id"}, nil)
+		mockClient.On("CreateRequisition", mock.Anything, mock.MatchedBy(func(r gocardless.RequisitionRequest) bool {
+			return r.InstitutionID == "test-institution" && r.Agreement == "agreement-id"
+		})).Return(gocardless.Requisition{ID: "requisition-id", Link: "http://auth-link"}, nil)
+		mockClient.On("GetRequisition", mock.Anything, "requisition-id").
+			Return(gocardless.Requisition{ID: "requisition-id", Status: "LN", Accounts: []string{"account-id-1", "account-id-2"}}, nil)
 
 		// Create configuration
 		cfg := &config.Config{
@@ -72,4 +79,58 @@ func TestMainFlow(t *testing.T) {
 		// Verify all expectations were met
 		mockClient.AssertExpectations(t)
 	})
+	t.Run("fake server", func(t *testing.T) {
+		// Serve a fake GoCardless API with two accounts of the institution
+		fake := fakegocardless.New("test-id", "test-key")
+		fake.AddAccount(fakegocardless.Account{ID: "account-id-1", InstitutionID: "test-institution"})
+		fake.AddAccount(fakegocardless.Account{ID: "account-id-2", InstitutionID: "test-institution"})
+		fakeServer := httptest.NewServer(fake)
+		defer fakeServer.Close()
+
+		// Create configuration
+		cfg := &config.Config{
+			GCSecretID:    "test-id",
+			GCSecretKey:   "test-key",
+			GCBaseURL:     fakeServer.URL + fakegocardless.APIPath,
+			InstitutionID: "test-institution",
+			Port:          8081,
+			AuthTimeout:   5 * time.Second,
+			HTTPTimeout:   time.Second,
+		}
+
+		logger := slog.Default()
+		client := gocardless.New(gocardless.Config{
+			SecretID:  cfg.GCSecretID,
+			SecretKey: cfg.GCSecretKey,
+			BaseURL:   cfg.GCBaseURL,
+			Timeout:   cfg.HTTPTimeout,
+			Logger:    logger,
+		})
+		authFlow := auth.NewAuthFlow(client, cfg, logger, server.NewCallbackServer(cfg.Port, logger))
+
+		// Simulate the user authorizing the requisition, the fake redirects to the callback server
+		go func() {
+			for {
+				if requisitions := fake.Requisitions(); len(requisitions) > 0 {
+					response, err := http.Get(requisitions[0].Link)
+					if assert.NoError(t, err) {
+						_ = response.Body.Close()
+					}
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+
+		// Execute the flow
+		accounts, err := authFlow.Execute(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"account-id-1", "account-id-2"}, accounts)
+
+		requisitions, err := client.ListRequisitions(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, requisitions, 1) {
+			assert.Equal(t, "LN", requisitions[0].Status)
+		}
+	})
 }
@@ -0,0 +1,691 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api/account"
+
+	"psmarcin.github.com/open-ynab-sync/cmd/link/auth"
+	linkconfig "psmarcin.github.com/open-ynab-sync/cmd/link/config"
+	"psmarcin.github.com/open-ynab-sync/cmd/link/server"
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+// command is a subcommand of the binary, it either runs something or groups further subcommands
+type command struct {
+	name        string
+	description string
+	// needsJobs marks commands that have nothing to do without configured jobs
+	needsJobs   bool
+	run         func(ctx context.Context, c *ServiceContainer, args []string) error
+	subcommands []command
+}
+
+// commands returns the command tree of the binary
+func commands() []command {
+	return []command{
+		{
+			name:        "run",
+			description: "Synchronize all jobs on the cron schedule (default)",
+			needsJobs:   true,
+			run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+				return runScheduler(ctx, c.SyncService(), c.config)
+			},
+		},
+		{
+			name:        "sync",
+			description: "Synchronize jobs, once with -once",
+			needsJobs:   true,
+			run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+				return runSync(ctx, c.SyncService(), c.config, args)
+			},
+		},
+		{
+			name:        "backfill",
+			description: "Upload the available transaction history of jobs",
+			needsJobs:   true,
+			run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+				return runBackfill(ctx, c.SyncService(), c.config.Jobs, args)
+			},
+		},
+		{
+			name:        "dry-run",
+			description: "Print what a synchronization of every job would upload",
+			needsJobs:   true,
+			run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+				return runDryRun(ctx, c.SyncService(), c.config.Jobs)
+			},
+		},
+		{
+			name:        "migrate-import-ids",
+			description: "Record uploaded transactions before switching a job's import ID strategy",
+			needsJobs:   true,
+			run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+				return runMigrateImportIDs(ctx, c.SyncService(), c.config.Jobs, args)
+			},
+		},
+		{
+			name:        "status",
+			description: "Print the synchronization state of jobs",
+			needsJobs:   true,
+			run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+				return runStatus(os.Stdout, c.StateStore(), c.config.Jobs, args)
+			},
+		},
+		{
+			name:        "budgets",
+			description: "List YNAB budgets",
+			run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+				return runBudgets(os.Stdout, c.YNABService())
+			},
+		},
+		{
+			name:        "accounts",
+			description: "List YNAB accounts of the jobs' budgets or of -budget",
+			run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+				return runAccounts(os.Stdout, c.YNABService(), c.config.Jobs, args)
+			},
+		},
+		{
+			name:        "jobs",
+			description: "Inspect the configured jobs",
+			subcommands: []command{
+				{
+					name:        "validate",
+					description: "Check that the accounts of every job exist and can be synchronized",
+					needsJobs:   true,
+					run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+						return runValidateJobs(ctx, os.Stdout, c.GCService(), c.YNABService(), c.config.Jobs)
+					},
+				},
+			},
+		},
+		{
+			name:        "rules",
+			description: "Inspect the rules of RULES_FILE",
+			subcommands: []command{
+				{
+					name:        "test",
+					description: "Print what the rules make of the recent transactions of jobs",
+					needsJobs:   true,
+					run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+						return runRulesTest(ctx, os.Stdout, c.GCService(), c.SyncService(), c.Rules(), c.config.Jobs, args)
+					},
+				},
+			},
+		},
+		{
+			name:        "link",
+			description: "Link a bank account in GoCardless",
+			run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+				return runLink(ctx, c.config, args)
+			},
+		},
+	}
+}
+
+// runCommand finds the command named by the first argument and runs it with the remaining ones
+func runCommand(ctx context.Context, c *ServiceContainer, cmds []command, args []string) error {
+	if len(args) == 0 {
+		printUsage(os.Stderr, cmds)
+		return fmt.Errorf("missing command")
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(os.Stdout, cmds)
+		return nil
+	}
+
+	for _, cmd := range cmds {
+		if cmd.name != name {
+			continue
+		}
+
+		if len(cmd.subcommands) > 0 {
+			if err := runCommand(ctx, c, cmd.subcommands, args[1:]); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			return nil
+		}
+		if cmd.needsJobs && len(c.config.Jobs) == 0 {
+			return fmt.Errorf("%s: JOBS environment variable is required", name)
+		}
+
+		return cmd.run(ctx, c, args[1:])
+	}
+
+	printUsage(os.Stderr, cmds)
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// printUsage writes the commands, including the nested ones, with their descriptions
+func printUsage(w io.Writer, cmds []command) {
+	_, _ = fmt.Fprintln(w, "Usage: open-ynab-sync <command> [flags]")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Commands:")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var write func(prefix string, cmds []command)
+	write = func(prefix string, cmds []command) {
+		for _, cmd := range cmds {
+			if len(cmd.subcommands) > 0 {
+				write(prefix+cmd.name+" ", cmd.subcommands)
+				continue
+			}
+			_, _ = fmt.Fprintf(tw, "  %s%s\t%s\n", prefix, cmd.name, cmd.description)
+		}
+	}
+	write("", cmds)
+	_ = tw.Flush()
+
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Run open-ynab-sync <command> -h for the flags of a command.")
+}
+
+// runSync synchronizes the selected jobs once and exits, or runs the scheduler without --once
+func runSync(ctx context.Context, syncService SynchronizationServicer, config Config, args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	once := flags.Bool("once", false, "Synchronize once and exit instead of running on the cron schedule")
+	selector := flags.String("job", "", "Name or GoCardless account ID of the job to synchronize (default: all jobs)")
+	dryRun := flags.Bool("dry-run", false, "Print what would be uploaded instead of uploading it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !*once {
+		if *selector != "" || *dryRun {
+			return fmt.Errorf("-job and -dry-run require -once")
+		}
+		return runScheduler(ctx, syncService, config)
+	}
+
+	jobs, err := selectJobs(config.Jobs, *selector)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		jobs = withDryRun(jobs)
+	}
+
+	// The same run the scheduler makes, with its shared session, worker pool and rate limits
+	report, err := syncService.SynchronizeTransactions(ctx, jobs)
+	printSyncReport(os.Stdout, report)
+
+	return err
+}
+
+// withDryRun returns copies of jobs that print what they would upload instead of uploading it
+func withDryRun(jobs []job) []job {
+	dryRunJobs := make([]job, len(jobs))
+	for i, j := range jobs {
+		j.DryRun = true
+		dryRunJobs[i] = j
+	}
+	return dryRunJobs
+}
+
+// printSyncReport writes a line with the outcome of every job
+func printSyncReport(w io.Writer, report SyncReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, result := range report.Results {
+		status, detail := "ok", fmt.Sprintf("fetched %d, uploaded %d", result.Fetched, result.Uploaded)
+		if result.Skipped > 0 {
+			detail += fmt.Sprintf(", skipped %d in another currency", result.Skipped)
+		}
+		if result.Err != nil {
+			status, detail = "FAIL", result.Err.Error()
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", status, result.Job, result.Duration.Round(time.Millisecond), detail)
+	}
+	_ = tw.Flush()
+}
+
+// runBackfill uploads the available transaction history of the selected jobs, one job after another
+func runBackfill(ctx context.Context, syncService SynchronizationServicer, jobs []job, args []string) error {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	selector := flags.String("job", "", "Name or GoCardless account ID of the job to backfill (default: all jobs)")
+	days := flags.Int("days", maxHistoricalDays, "Number of days of history to backfill")
+	chunkDays := flags.Int("chunk-days", 30, "Number of days fetched per request, every request uses the account's daily quota")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	selected, err := selectJobs(jobs, *selector)
+	if err != nil {
+		return err
+	}
+
+	for _, j := range selected {
+		if err := syncService.Backfill(ctx, j, *days, *chunkDays); err != nil {
+			return fmt.Errorf("failed to backfill job %s: %w", j.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// runDryRun prints what a synchronization of every job would upload, without uploading anything. A failing job
+// doesn't stop the others, the returned error lists the failed ones like sync -once does.
+func runDryRun(ctx context.Context, syncService SynchronizationServicer, jobs []job) error {
+	report, err := syncService.SynchronizeTransactions(ctx, withDryRun(jobs))
+	printSyncReport(os.Stdout, report)
+
+	return err
+}
+
+// runMigrateImportIDs records the YNAB transactions a job already uploaded, so it can switch import ID strategy
+func runMigrateImportIDs(ctx context.Context, syncService SynchronizationServicer, jobs []job, args []string) error {
+	flags := flag.NewFlagSet("migrate-import-ids", flag.ContinueOnError)
+	selector := flags.String("job", "", "Name or GoCardless account ID of the job to migrate (required)")
+	from := flags.String("from", string(importIDAmountDate), "Import ID strategy the job used so far")
+	days := flags.Int("days", maxHistoricalDays, "Number of days of history to match")
+	dryRun := flags.Bool("dry-run", false, "Only report what would be recorded")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *selector == "" {
+		return fmt.Errorf("-job is required")
+	}
+	if importIDStrategy(*from) != importIDAmountDate && importIDStrategy(*from) != importIDTransactionID {
+		return fmt.Errorf("invalid -from %q, expected %s or %s", *from, importIDAmountDate, importIDTransactionID)
+	}
+
+	selected, err := selectJobs(jobs, *selector)
+	if err != nil {
+		return err
+	}
+
+	for _, j := range selected {
+		migration, err := syncService.MigrateImportIDs(ctx, j, importIDStrategy(*from), *days, *dryRun)
+		if err != nil {
+			return fmt.Errorf("failed to migrate job %s: %w", j.Name, err)
+		}
+
+		fmt.Printf("matched:          %d\n", migration.Matched)
+		fmt.Printf("already recorded: %d\n", migration.AlreadyRecorded)
+		fmt.Printf("not in YNAB:      %d\n", migration.NotInYNAB)
+		fmt.Printf("pending:          %d\n", migration.Pending)
+		fmt.Printf("without bank ID:  %d\n", migration.WithoutID)
+		fmt.Printf("unmatched YNAB:   %d\n", migration.UnmatchedYNAB)
+	}
+
+	return nil
+}
+
+// runStatus prints the stored synchronization state of the selected jobs
+func runStatus(w io.Writer, stateStore StateStorer, jobs []job, args []string) error {
+	flags := flag.NewFlagSet("status", flag.ContinueOnError)
+	selector := flags.String("job", "", "Name or GoCardless account ID of the job (default: all jobs)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	selected, err := selectJobs(jobs, *selector)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "JOB\tGOCARDLESS ACCOUNT\tYNAB ACCOUNT\tLAST SYNCED\tBACKFILLED TO\tUPLOADED\tPENDING\tQUOTA")
+	for _, j := range selected {
+		state, err := stateStore.JobState(j.key())
+		if err != nil {
+			return fmt.Errorf("failed to load state of job %s: %w", j.Name, err)
+		}
+		limit, err := stateStore.RateLimit(j.GCAccountID)
+		if err != nil {
+			return fmt.Errorf("failed to load rate limit of job %s: %w", j.Name, err)
+		}
+
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\n",
+			j.Name,
+			j.GCAccountID,
+			j.YNABAccountID,
+			formatStatusTime(state.LastSyncedAt, "2006-01-02 15:04 MST", "never"),
+			formatStatusTime(state.BackfilledTo, "2006-01-02", "-"),
+			len(state.Uploaded),
+			len(state.Pending),
+			formatQuota(limit, time.Now()),
+		)
+	}
+
+	return tw.Flush()
+}
+
+// formatQuota formats the remaining daily quota of an account, with its reset time when it's used up
+func formatQuota(limit gocardless.RateLimit, now time.Time) string {
+	switch {
+	case limit.UpdatedAt.IsZero() || !now.Before(limit.ResetAt):
+		return "unknown"
+	case limit.Exhausted(now):
+		return fmt.Sprintf("%d/%d until %s", limit.Remaining, limit.Limit, limit.ResetAt.Format("2006-01-02 15:04 MST"))
+	default:
+		return fmt.Sprintf("%d/%d", limit.Remaining, limit.Limit)
+	}
+}
+
+// formatStatusTime formats t with layout, or returns empty when t is zero
+func formatStatusTime(t time.Time, layout, empty string) string {
+	if t.IsZero() {
+		return empty
+	}
+	return t.Format(layout)
+}
+
+// runBudgets prints the YNAB budgets the token has access to
+func runBudgets(w io.Writer, ynabService YNABServicer) error {
+	budgets, err := ynabService.GetBudgets()
+	if err != nil {
+		return fmt.Errorf("failed to list budgets: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tNAME\tCURRENCY\tLAST MODIFIED")
+	for _, b := range budgets {
+		currency := ""
+		if b.CurrencyFormat != nil {
+			currency = b.CurrencyFormat.ISOCode
+		}
+		lastModified := ""
+		if b.LastModifiedOn != nil {
+			lastModified = b.LastModifiedOn.Format("2006-01-02 15:04 MST")
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", b.ID, b.Name, currency, lastModified)
+	}
+
+	return tw.Flush()
+}
+
+// runAccounts prints the YNAB accounts of the jobs' budgets, of every budget when there are no jobs,
+// or of the budget given with -budget
+func runAccounts(w io.Writer, ynabService YNABServicer, jobs []job, args []string) error {
+	flags := flag.NewFlagSet("accounts", flag.ContinueOnError)
+	budgetID := flags.String("budget", "", "ID of the YNAB budget (default: budgets of all jobs)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var budgetIDs []string
+	switch {
+	case *budgetID != "":
+		budgetIDs = []string{*budgetID}
+	case len(jobs) > 0:
+		seen := make(map[string]bool)
+		for _, j := range jobs {
+			if !seen[j.YNABBudgetID] {
+				seen[j.YNABBudgetID] = true
+				budgetIDs = append(budgetIDs, j.YNABBudgetID)
+			}
+		}
+	default:
+		budgets, err := ynabService.GetBudgets()
+		if err != nil {
+			return fmt.Errorf("failed to list budgets: %w", err)
+		}
+		for _, b := range budgets {
+			budgetIDs = append(budgetIDs, b.ID)
+		}
+	}
+
+	jobNames := make(map[string][]string)
+	for _, j := range jobs {
+		jobNames[j.YNABAccountID] = append(jobNames[j.YNABAccountID], j.Name)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "BUDGET\tID\tNAME\tTYPE\tBALANCE\tCLOSED\tJOBS")
+	for _, id := range budgetIDs {
+		accounts, err := ynabService.GetAccounts(id)
+		if err != nil {
+			return fmt.Errorf("failed to list accounts of budget %s: %w", id, err)
+		}
+
+		for _, a := range accounts {
+			if a.Deleted {
+				continue
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%t\t%s\n",
+				id, a.ID, a.Name, a.Type, formatMilliunits(a.Balance), a.Closed, strings.Join(jobNames[a.ID], ","))
+		}
+	}
+
+	return tw.Flush()
+}
+
+// runValidateJobs checks every job for duplicates, a missing or closed YNAB account and a GoCardless account
+// that can't be synchronized, and prints the problems found
+func runValidateJobs(ctx context.Context, w io.Writer, gcService GoCardlessServicer, ynabService YNABServicer, jobs []job) error {
+	problems := make(map[string][]string)
+
+	names := make(map[string]int)
+	keys := make(map[string]int)
+	for _, j := range jobs {
+		names[j.Name]++
+		keys[j.key()]++
+	}
+	for _, j := range jobs {
+		if names[j.Name] > 1 {
+			problems[j.Name] = append(problems[j.Name], "name is used by more than one job")
+		}
+		if keys[j.key()] > 1 {
+			problems[j.Name] = append(problems[j.Name], "another job synchronizes the same accounts")
+		}
+	}
+
+	accountsByBudget := make(map[string]map[string]*account.Account)
+	budgetErrors := make(map[string]error)
+	for _, j := range jobs {
+		if _, ok := accountsByBudget[j.YNABBudgetID]; !ok && budgetErrors[j.YNABBudgetID] == nil {
+			accounts, err := ynabService.GetAccounts(j.YNABBudgetID)
+			if err != nil {
+				budgetErrors[j.YNABBudgetID] = err
+			} else {
+				byID := make(map[string]*account.Account, len(accounts))
+				for _, a := range accounts {
+					byID[a.ID] = a
+				}
+				accountsByBudget[j.YNABBudgetID] = byID
+			}
+		}
+
+		if err := budgetErrors[j.YNABBudgetID]; err != nil {
+			problems[j.Name] = append(problems[j.Name], fmt.Sprintf("failed to list accounts of YNAB budget %s: %s", j.YNABBudgetID, err))
+			continue
+		}
+		a, ok := accountsByBudget[j.YNABBudgetID][j.YNABAccountID]
+		switch {
+		case !ok || a.Deleted:
+			problems[j.Name] = append(problems[j.Name], fmt.Sprintf("YNAB account %s not found in budget %s", j.YNABAccountID, j.YNABBudgetID))
+		case a.Closed:
+			problems[j.Name] = append(problems[j.Name], fmt.Sprintf("YNAB account %s is closed", j.YNABAccountID))
+		}
+	}
+
+	if err := gcService.LogIn(ctx); err != nil {
+		return fmt.Errorf("failed to log in to GoCardless: %w", err)
+	}
+	for _, j := range jobs {
+		a, err := gcService.GetAccount(ctx, j.GCAccountID)
+		if err != nil {
+			problems[j.Name] = append(problems[j.Name], fmt.Sprintf("failed to get GoCardless account %s: %s", j.GCAccountID, err))
+			continue
+		}
+		if a.Status != gocardless.AccountStatusReady {
+			problems[j.Name] = append(problems[j.Name], fmt.Sprintf("GoCardless account %s is %s", j.GCAccountID, a.Status))
+		}
+	}
+
+	invalid := 0
+	reported := make(map[string]bool)
+	for _, j := range jobs {
+		if reported[j.Name] {
+			continue
+		}
+		reported[j.Name] = true
+
+		if len(problems[j.Name]) == 0 {
+			_, _ = fmt.Fprintf(w, "ok\t%s\n", j.Name)
+			continue
+		}
+		invalid++
+		_, _ = fmt.Fprintf(w, "FAIL\t%s\t%s\n", j.Name, strings.Join(problems[j.Name], "; "))
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d jobs are invalid", invalid, len(reported))
+	}
+
+	return nil
+}
+
+// runLink links a bank account in GoCardless through the requisition flow and prints the linked account IDs
+func runLink(ctx context.Context, config Config, args []string) error {
+	flags := flag.NewFlagSet("link", flag.ContinueOnError)
+	institutionID := flags.String("institution", "", "Institution ID (required)")
+	port := flags.Int("port", 8080, "Port to listen for callback")
+	authTimeout := flags.Duration("auth-timeout", 5*time.Minute, "Timeout for waiting for authorization callback")
+	httpTimeout := flags.Duration("http-timeout", 20*time.Second, "Timeout for HTTP requests")
+	openBrowser := flags.Bool("open-browser", true, "Open the authorization link in the browser")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *institutionID == "" {
+		return fmt.Errorf("-institution is required")
+	}
+
+	cfg := &linkconfig.Config{
+		GCSecretID:    config.GCSecretID,
+		GCSecretKey:   config.GCSecretKey,
+		GCBaseURL:     config.GCBaseURL,
+		InstitutionID: *institutionID,
+		Port:          *port,
+		AuthTimeout:   *authTimeout,
+		HTTPTimeout:   *httpTimeout,
+	}
+
+	logger := slog.Default()
+	gcClient := gocardless.New(gocardless.Config{
+		SecretID:  cfg.GCSecretID,
+		SecretKey: cfg.GCSecretKey,
+		BaseURL:   cfg.GCBaseURL,
+		Timeout:   cfg.HTTPTimeout,
+		Retry:     config.Retry,
+		Logger:    logger,
+	})
+	authFlow := auth.NewAuthFlow(gcClient, cfg, logger, server.NewCallbackServer(cfg.Port, logger))
+	authFlow.AutoOpenBrowser = *openBrowser
+
+	accounts, err := authFlow.Execute(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to link accounts: %w", err)
+	}
+
+	sort.Strings(accounts)
+	for _, accountID := range accounts {
+		fmt.Printf("linked account: %s\n", accountID)
+	}
+	fmt.Println("add a job for every account to JOBS: GC_ACCOUNT_ID,YNAB_BUDGET_ID,YNAB_ACCOUNT_ID")
+
+	return nil
+}
+
+// runRulesTest prints the recent transactions of the selected jobs with the payee the rules rename them to and the
+// category they assign. The rules see the transactions the way a synchronization gives them to the rules.
+// Every job uses a request of its GoCardless account's daily quota.
+func runRulesTest(ctx context.Context, w io.Writer, gcService GoCardlessServicer, syncService SynchronizationServicer, loaded *rulesFile, jobs []job, args []string) error {
+	flags := flag.NewFlagSet("rules test", flag.ContinueOnError)
+	selector := flags.String("job", "", "Name or GoCardless account ID of the job (default: all jobs)")
+	days := flags.Int("days", defaultLookbackDays, "Number of days of transactions to test")
+	file := flags.String("file", "", "Rules file to test instead of RULES_FILE")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *days < 1 || *days > maxHistoricalDays {
+		return fmt.Errorf("-days must be between 1 and %d, got %d", maxHistoricalDays, *days)
+	}
+	if *file != "" {
+		var err error
+		if loaded, err = newRulesFile(*file); err != nil {
+			return err
+		}
+	}
+	if loaded == nil {
+		return fmt.Errorf("no rules to test, set RULES_FILE or -file")
+	}
+	r := loaded.current(ctx)
+
+	selected, err := selectJobs(jobs, *selector)
+	if err != nil {
+		return err
+	}
+
+	if err := gcService.LogIn(ctx); err != nil {
+		return fmt.Errorf("failed to log in to GoCardless: %w", err)
+	}
+
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -*days)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "JOB\tDATE\tAMOUNT\tPAYEE\tNEW PAYEE\tCATEGORY\tRULES")
+	var notes []string
+	for _, j := range selected {
+		transactions, skipped, err := syncService.ListJobTransactions(ctx, j, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to list transactions of job %s: %w", j.Name, err)
+		}
+		if skipped > 0 {
+			notes = append(notes, fmt.Sprintf("%s: skipped %d in another currency", j.Name, skipped))
+		}
+
+		for _, t := range transactions {
+			newPayee, category := "-", "-"
+			var matched []string
+			renamed := t
+			if i, payee := r.matchPayee(t); i >= 0 {
+				newPayee, renamed.Name = payee, payee
+				matched = append(matched, r.payeeRuleLabel(i))
+			}
+			if i := r.matchCategory(renamed); i >= 0 {
+				category = r.Categories[i].Category
+				if r.Categories[i].Approve {
+					category += " (approved)"
+				}
+				matched = append(matched, r.categoryRuleLabel(i))
+			}
+			if len(matched) == 0 {
+				matched = []string{"-"}
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				j.Name,
+				t.Date.Format("2006-01-02"),
+				formatMilliunits(t.AmountMili),
+				t.Name,
+				newPayee,
+				category,
+				strings.Join(matched, ", "),
+			)
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, note := range notes {
+		_, _ = fmt.Fprintln(w, note)
+	}
+	return nil
+}
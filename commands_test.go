@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api/account"
+	"github.com/brunomvsouza/ynab.go/api/budget"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+func TestRunCommand(t *testing.T) {
+	config := Config{Jobs: []job{{Name: "checking", GCAccountID: "GC1"}}}
+
+	t.Run("runs nested command", func(t *testing.T) {
+		ran := false
+		cmds := []command{{
+			name: "jobs",
+			subcommands: []command{{
+				name: "validate",
+				run: func(ctx context.Context, c *ServiceContainer, args []string) error {
+					ran = true
+					assert.Equal(t, []string{"-x"}, args)
+					return nil
+				},
+			}},
+		}}
+
+		err := runCommand(context.Background(), &ServiceContainer{config: config}, cmds, []string{"jobs", "validate", "-x"})
+		assert.NoError(t, err)
+		assert.True(t, ran)
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		err := runCommand(context.Background(), &ServiceContainer{config: config}, commands(), []string{"unknown"})
+		assert.EqualError(t, err, `unknown command "unknown"`)
+	})
+
+	t.Run("unknown subcommand", func(t *testing.T) {
+		err := runCommand(context.Background(), &ServiceContainer{config: config}, commands(), []string{"jobs", "unknown"})
+		assert.EqualError(t, err, `jobs: unknown command "unknown"`)
+	})
+
+	t.Run("requires jobs", func(t *testing.T) {
+		err := runCommand(context.Background(), &ServiceContainer{}, commands(), []string{"sync", "-once"})
+		assert.EqualError(t, err, "sync: JOBS environment variable is required")
+	})
+
+	t.Run("sync once", func(t *testing.T) {
+		syncMock := NewMockSynchronizationServicer(t)
+		syncMock.EXPECT().SynchronizeTransactions(mock.Anything, config.Jobs).Return(SyncReport{Results: []JobResult{{Job: config.Jobs[0].Name}}}, nil)
+
+		err := runCommand(context.Background(), &ServiceContainer{config: config, syncService: syncMock}, commands(), []string{"sync", "-once"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestRunSyncOnce(t *testing.T) {
+	config := Config{Jobs: []job{
+		{Name: "checking", GCAccountID: "GC1"},
+		{Name: "savings", GCAccountID: "GC2"},
+	}}
+
+	t.Run("all jobs succeed", func(t *testing.T) {
+		syncMock := NewMockSynchronizationServicer(t)
+		syncMock.EXPECT().SynchronizeTransactions(mock.Anything, config.Jobs).Return(SyncReport{Results: []JobResult{{Job: "checking"}, {Job: "savings"}}}, nil)
+
+		err := runSync(context.Background(), syncMock, config, []string{"-once"})
+		assert.NoError(t, err)
+		assert.Equal(t, exitOK, exitCode(err))
+	})
+
+	t.Run("selected job with dry run", func(t *testing.T) {
+		syncMock := NewMockSynchronizationServicer(t)
+		dryRunJob := config.Jobs[1]
+		dryRunJob.DryRun = true
+		syncMock.EXPECT().SynchronizeTransactions(mock.Anything, []job{dryRunJob}).Return(SyncReport{Results: []JobResult{{Job: "savings"}}}, nil)
+
+		err := runSync(context.Background(), syncMock, config, []string{"-once", "-job", "savings", "-dry-run"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("some jobs fail", func(t *testing.T) {
+		syncMock := NewMockSynchronizationServicer(t)
+		report := SyncReport{Results: []JobResult{{Job: "checking", Err: assert.AnError}, {Job: "savings"}}}
+		syncMock.EXPECT().SynchronizeTransactions(mock.Anything, config.Jobs).Return(report, report.Err())
+
+		err := runSync(context.Background(), syncMock, config, []string{"-once"})
+		assert.Equal(t, exitSomeJobsFailed, exitCode(err))
+	})
+
+	t.Run("all jobs fail", func(t *testing.T) {
+		syncMock := NewMockSynchronizationServicer(t)
+		report := SyncReport{Results: []JobResult{{Job: "checking", Err: assert.AnError}, {Job: "savings", Err: assert.AnError}}}
+		syncMock.EXPECT().SynchronizeTransactions(mock.Anything, config.Jobs).Return(report, report.Err())
+
+		err := runSync(context.Background(), syncMock, config, []string{"-once"})
+		assert.Equal(t, exitAllJobsFailed, exitCode(err))
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		syncMock := NewMockSynchronizationServicer(t)
+
+		err := runSync(context.Background(), syncMock, config, []string{"-once", "-job", "unknown"})
+		assert.Equal(t, exitError, exitCode(err))
+	})
+}
+
+func TestPrintSyncReport(t *testing.T) {
+	var out bytes.Buffer
+	printSyncReport(&out, SyncReport{Results: []JobResult{
+		{Job: "checking", Fetched: 5, Uploaded: 2, Skipped: 1},
+		{Job: "savings", Fetched: 3},
+		{Job: "cards", Err: assert.AnError},
+	}})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Contains(t, lines[0], "fetched 5, uploaded 2, skipped 1 in another currency")
+		assert.Contains(t, lines[1], "fetched 3, uploaded 0")
+		assert.NotContains(t, lines[1], "skipped")
+		assert.Contains(t, lines[2], assert.AnError.Error())
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	assert.NoError(t, err)
+
+	jobs := []job{
+		{Name: "checking", GCAccountID: "GC1", YNABAccountID: "Y1"},
+		{Name: "savings", GCAccountID: "GC2", YNABAccountID: "Y2"},
+	}
+	state := JobState{LastSyncedAt: time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)}
+	state.MarkUploaded("1", UploadedTransaction{ImportID: "GC:1", Date: time.Now().UTC(), UploadedAt: time.Now().UTC()})
+	assert.NoError(t, stateStore.SaveJobState(jobs[0].key(), state))
+
+	var out bytes.Buffer
+	assert.NoError(t, runStatus(&out, stateStore, jobs, nil))
+	assert.Contains(t, out.String(), "checking  GC1                 Y1            2024-05-01 06:00 UTC  -              1         0")
+	assert.Contains(t, out.String(), "savings   GC2                 Y2            never                 -              0         0")
+}
+
+func TestRunValidateJobs(t *testing.T) {
+	jobs := []job{
+		{Name: "checking", GCAccountID: "GC1", YNABBudgetID: "B1", YNABAccountID: "Y1"},
+		{Name: "savings", GCAccountID: "GC2", YNABBudgetID: "B1", YNABAccountID: "Y2"},
+		{Name: "old", GCAccountID: "GC3", YNABBudgetID: "B1", YNABAccountID: "Y3"},
+	}
+
+	gcMock := NewMockGoCardlessServicer(t)
+	gcMock.EXPECT().LogIn(mock.Anything).Return(nil)
+	gcMock.EXPECT().GetAccount(mock.Anything, "GC1").Return(gocardless.Account{ID: "GC1", Status: gocardless.AccountStatusReady}, nil)
+	gcMock.EXPECT().GetAccount(mock.Anything, "GC2").Return(gocardless.Account{ID: "GC2", Status: "EXPIRED"}, nil)
+	gcMock.EXPECT().GetAccount(mock.Anything, "GC3").Return(gocardless.Account{ID: "GC3", Status: gocardless.AccountStatusReady}, nil)
+
+	ynabMock := NewMockYNABServicer(t)
+	ynabMock.EXPECT().GetAccounts("B1").Return([]*account.Account{
+		{ID: "Y1"},
+		{ID: "Y2"},
+		{ID: "Y3", Closed: true},
+	}, nil).Once()
+
+	var out bytes.Buffer
+	err := runValidateJobs(context.Background(), &out, gcMock, ynabMock, jobs)
+	assert.EqualError(t, err, "2 of 3 jobs are invalid")
+	assert.Equal(t, "ok\tchecking\n"+
+		"FAIL\tsavings\tGoCardless account GC2 is EXPIRED\n"+
+		"FAIL\told\tYNAB account Y3 is closed\n", out.String())
+}
+
+func TestRunRulesTest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{
+		"payees": [{"name": "card", "match": {"regex": "^CARD \\d+ (\\w+)"}, "payee": "${1}"}],
+		"categories": [
+			{"match": {"payee": "lidl"}, "category": "Groceries", "approve": true},
+			{"name": "salary", "match": {"direction": "in"}, "category": "Inflow: Ready to Assign"}
+		]
+	}`), 0o600))
+	rules, err := newRulesFile(path)
+	assert.NoError(t, err)
+
+	date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	checking := job{Name: "checking", GCAccountID: "GC1"}
+	gcMock := NewMockGoCardlessServicer(t)
+	gcMock.EXPECT().LogIn(mock.Anything).Return(nil)
+	syncMock := NewMockSynchronizationServicer(t)
+	syncMock.EXPECT().ListJobTransactions(mock.Anything, checking, mock.Anything, mock.Anything).Return([]Transaction{
+		{Date: date, ValueDate: date, AmountMili: -12340, Name: "CARD 1234 LIDL WARSZAWA"},
+		{Date: date, ValueDate: date, AmountMili: 500000, Name: "ACME Corp"},
+		{Date: date, ValueDate: date, AmountMili: -1000, Name: "Shop"},
+	}, 0, nil)
+
+	var out bytes.Buffer
+	err = runRulesTest(context.Background(), &out, gcMock, syncMock, rules, []job{checking}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "JOB       DATE        AMOUNT  PAYEE                    NEW PAYEE  CATEGORY                 RULES\n"+
+		"checking  2024-05-01  -12.34  CARD 1234 LIDL WARSZAWA  LIDL       Groceries (approved)     card, category rule 1\n"+
+		"checking  2024-05-01  500.00  ACME Corp                -          Inflow: Ready to Assign  salary\n"+
+		"checking  2024-05-01  -1.00   Shop                     -          -                        -\n", out.String())
+
+	t.Run("sees transactions like a synchronization", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rules.json")
+		assert.NoError(t, os.WriteFile(path, []byte(`{
+			"categories": [{"name": "big", "match": {"direction": "out", "min_amount": "11"}, "category": "Big"}]
+		}`), 0o600))
+		rules, err := newRulesFile(path)
+		assert.NoError(t, err)
+
+		budgetJob := job{Name: "checking", GCAccountID: "GC1", YNABBudgetID: "budget"}
+		gcMock := NewMockGoCardlessServicer(t)
+		gcMock.EXPECT().LogIn(mock.Anything).Return(nil)
+		gcMock.EXPECT().ListTransactions(mock.Anything, "GC1", mock.Anything, mock.Anything).Return([]Transaction{
+			// Matched against the amount in the budget's currency, 11.70 EUR
+			{Date: date, AmountMili: -10000, Currency: "GBP", Name: "London Cafe", CurrencyExchange: []gocardless.CurrencyExchange{
+				{SourceCurrency: "GBP", TargetCurrency: "EUR", ExchangeRate: "1.17"},
+			}},
+			{Date: date, AmountMili: -5000, Currency: "USD", Name: "Online Shop"},
+		}, nil)
+		ynabMock := NewMockYNABServicer(t)
+		ynabMock.EXPECT().GetBudgetSettings("budget").Return(&budget.Settings{CurrencyFormat: &budget.CurrencyFormat{ISOCode: "EUR"}}, nil)
+		syncService := NewSyncService(gcMock, ynabMock, nil, nil, nil, []job{budgetJob}, 1)
+
+		var out bytes.Buffer
+		err = runRulesTest(context.Background(), &out, gcMock, syncService, rules, []job{budgetJob}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "JOB       DATE        AMOUNT  PAYEE        NEW PAYEE  CATEGORY  RULES\n"+
+			"checking  2024-05-01  -11.70  London Cafe  -          Big       big\n"+
+			"checking: skipped 1 in another currency\n", out.String())
+	})
+
+	t.Run("without rules", func(t *testing.T) {
+		err := runRulesTest(context.Background(), &out, NewMockGoCardlessServicer(t), NewMockSynchronizationServicer(t), nil, []job{checking}, nil)
+		assert.EqualError(t, err, "no rules to test, set RULES_FILE or -file")
+	})
+}
+
+func TestRunDryRun(t *testing.T) {
+	jobs := []job{
+		{Name: "checking", GCAccountID: "GC1"},
+		{Name: "savings", GCAccountID: "GC2"},
+	}
+
+	t.Run("previews every job", func(t *testing.T) {
+		syncMock := NewMockSynchronizationServicer(t)
+		syncMock.EXPECT().SynchronizeTransactions(mock.Anything, withDryRun(jobs)).Return(SyncReport{Results: []JobResult{{Job: "checking"}, {Job: "savings"}}}, nil)
+
+		err := runDryRun(context.Background(), syncMock, jobs)
+		assert.NoError(t, err)
+		assert.Equal(t, exitOK, exitCode(err))
+	})
+
+	t.Run("some jobs fail", func(t *testing.T) {
+		syncMock := NewMockSynchronizationServicer(t)
+		report := SyncReport{Results: []JobResult{{Job: "checking", Err: assert.AnError}, {Job: "savings"}}}
+		syncMock.EXPECT().SynchronizeTransactions(mock.Anything, withDryRun(jobs)).Return(report, report.Err())
+
+		err := runDryRun(context.Background(), syncMock, jobs)
+		assert.Equal(t, exitSomeJobsFailed, exitCode(err))
+	})
+
+	t.Run("all jobs fail", func(t *testing.T) {
+		syncMock := NewMockSynchronizationServicer(t)
+		report := SyncReport{Results: []JobResult{{Job: "checking", Err: assert.AnError}, {Job: "savings", Err: assert.AnError}}}
+		syncMock.EXPECT().SynchronizeTransactions(mock.Anything, withDryRun(jobs)).Return(report, report.Err())
+
+		err := runDryRun(context.Background(), syncMock, jobs)
+		assert.Equal(t, exitAllJobsFailed, exitCode(err))
+	})
+}
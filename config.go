@@ -3,22 +3,49 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+	"psmarcin.github.com/open-ynab-sync/internal/retry"
 )
 
+// defaultSyncConcurrency is how many jobs are synchronized in parallel when SYNC_CONCURRENCY isn't set
+const defaultSyncConcurrency = 4
+
 // Config holds all configuration parameters for the application
 type Config struct {
 	// GoCardless configuration
 	GCSecretID  string
 	GCSecretKey string
+	// GCBaseURL is the base URL of the GoCardless API, e.g. of a local fake server
+	GCBaseURL string
 
 	// YNAB configuration
 	YNABToken string
+	// YNABBaseURL is the base URL of the YNAB API, e.g. of a local fake server
+	YNABBaseURL string
 
 	// Synchronization configuration
 	CronSchedule string
 	Jobs         []job
+	// SyncConcurrency is how many jobs are synchronized in parallel
+	SyncConcurrency int
+
+	// DryRun prints what would be uploaded to YNAB for every job instead of uploading it
+	DryRun bool
+
+	// Retry decides how GoCardless and YNAB requests failing for a transient reason are sent again
+	Retry retry.Policy
+
+	// State configuration
+	StateFile string
+
+	// RulesFile is the JSON file of the rules transactions are rewritten with, none are applied when it's empty
+	RulesFile string
 
 	// Monitoring configuration
 	NewRelicLicenseKey string
@@ -37,20 +64,61 @@ func LoadConfigFromEnv() (Config, error) {
 
 	secretID := os.Getenv("GC_SECRET_ID")
 	secretKey := os.Getenv("GC_SECRET_KEY")
+	gcBaseURL := os.Getenv("GC_BASE_URL")
 	ynabToken := os.Getenv("YNAB_TOKEN")
+	ynabBaseURL := os.Getenv("YNAB_BASE_URL")
 	cronSchedule := os.Getenv("CRON_SCHEDULE")
 	newRelicLicenseKey := os.Getenv("NEW_RELIC_LICENCE_KEY")
 	newRelicAppName := os.Getenv("NEW_RELIC_APP_NAME")
+	stateFile := os.Getenv("STATE_FILE")
+	rulesFile := os.Getenv("RULES_FILE")
 
 	// Validate required configuration
 	if secretID == "" || secretKey == "" || ynabToken == "" {
 		return Config{}, fmt.Errorf("GC_SECRET_ID, GC_SECRET_KEY, and YNAB_TOKEN environment variables are required")
 	}
 
-	// Parse jobs from the environment
-	jobs, err := envToJobs(os.Getenv("JOBS"))
+	// Parse jobs from the environment, commands like budgets or link work without them
+	var jobs []job
+	if source := os.Getenv("JOBS"); source != "" {
+		parsed, err := envToJobs(source)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to parse jobs: %w", err)
+		}
+		jobs = parsed
+	}
+
+	// Dry run applies to all jobs
+	dryRun := false
+	if value := os.Getenv("DRY_RUN"); value != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to parse DRY_RUN: %w", err)
+		}
+	}
+	if dryRun {
+		for i := range jobs {
+			jobs[i].DryRun = true
+		}
+	}
+
+	// Parse how many jobs run in parallel
+	syncConcurrency := defaultSyncConcurrency
+	if value := os.Getenv("SYNC_CONCURRENCY"); value != "" {
+		var err error
+		syncConcurrency, err = strconv.Atoi(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to parse SYNC_CONCURRENCY: %w", err)
+		}
+		if syncConcurrency < 1 {
+			return Config{}, fmt.Errorf("SYNC_CONCURRENCY must be at least 1, got %d", syncConcurrency)
+		}
+	}
+
+	retryPolicy, err := retryPolicyFromEnv()
 	if err != nil {
-		return Config{}, fmt.Errorf("failed to parse jobs: %w", err)
+		return Config{}, err
 	}
 
 	// Set the default cron schedule if not provided
@@ -58,13 +126,82 @@ func LoadConfigFromEnv() (Config, error) {
 		cronSchedule = "0 6,18 * * *"
 	}
 
+	// Set the default GoCardless base URL if not provided
+	if gcBaseURL == "" {
+		gcBaseURL = gocardless.DefaultBaseURL
+	}
+
+	// Set the default YNAB base URL if not provided
+	if ynabBaseURL == "" {
+		ynabBaseURL = ynabAPIURL
+	}
+
+	// Set the default state file if not provided
+	if stateFile == "" {
+		stateFile = "state.json"
+	}
+
 	return Config{
 		GCSecretID:         secretID,
 		GCSecretKey:        secretKey,
+		GCBaseURL:          gcBaseURL,
 		YNABToken:          ynabToken,
+		YNABBaseURL:        ynabBaseURL,
 		CronSchedule:       cronSchedule,
 		Jobs:               jobs,
+		SyncConcurrency:    syncConcurrency,
+		DryRun:             dryRun,
+		Retry:              retryPolicy,
+		StateFile:          stateFile,
+		RulesFile:          rulesFile,
 		NewRelicLicenseKey: newRelicLicenseKey,
 		NewRelicAppName:    newRelicAppName,
 	}, nil
 }
+
+// retryPolicyFromEnv returns the default retry policy overridden by the RETRY_* environment variables
+func retryPolicyFromEnv() (retry.Policy, error) {
+	policy := retry.DefaultPolicy()
+
+	if value := os.Getenv("RETRY_MAX_ATTEMPTS"); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts < 1 {
+			return retry.Policy{}, fmt.Errorf("RETRY_MAX_ATTEMPTS must be a number of at least 1, got %q", value)
+		}
+		policy.MaxAttempts = attempts
+	}
+	if value := os.Getenv("RETRY_INITIAL_BACKOFF"); value != "" {
+		backoff, err := time.ParseDuration(value)
+		if err != nil || backoff < 0 {
+			return retry.Policy{}, fmt.Errorf("RETRY_INITIAL_BACKOFF must be a duration like 1s, got %q", value)
+		}
+		policy.InitialBackoff = backoff
+	}
+	if value := os.Getenv("RETRY_MAX_BACKOFF"); value != "" {
+		backoff, err := time.ParseDuration(value)
+		if err != nil || backoff < 0 {
+			return retry.Policy{}, fmt.Errorf("RETRY_MAX_BACKOFF must be a duration like 30s, got %q", value)
+		}
+		policy.MaxBackoff = backoff
+	}
+	if value := os.Getenv("RETRY_JITTER"); value != "" {
+		jitter, err := strconv.ParseFloat(value, 64)
+		if err != nil || jitter < 0 || jitter > 1 {
+			return retry.Policy{}, fmt.Errorf("RETRY_JITTER must be a number between 0 and 1, got %q", value)
+		}
+		policy.Jitter = jitter
+	}
+	if value := os.Getenv("RETRY_STATUS_CODES"); value != "" {
+		codes := make(map[int]bool)
+		for _, field := range strings.Split(value, ",") {
+			code, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || code < 100 || code > 599 {
+				return retry.Policy{}, fmt.Errorf("RETRY_STATUS_CODES must be a comma separated list of HTTP status codes, got %q", value)
+			}
+			codes[code] = true
+		}
+		policy.Retryable = func(code int) bool { return codes[code] }
+	}
+
+	return policy, nil
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+	"psmarcin.github.com/open-ynab-sync/internal/retry"
 )
 
 func TestLoadConfigFromEnv(t *testing.T) {
@@ -16,4 +20,78 @@ func TestLoadConfigFromEnv(t *testing.T) {
 	assert.Equal(t, "secret", c.GCSecretKey)
 	assert.Equal(t, "id", c.GCSecretID)
 	assert.Equal(t, "token", c.YNABToken)
+	assert.Equal(t, gocardless.DefaultBaseURL, c.GCBaseURL)
+	assert.Equal(t, ynabAPIURL, c.YNABBaseURL)
+
+	t.Setenv("GC_BASE_URL", "http://localhost:8090/api/v2")
+	c, err = LoadConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8090/api/v2", c.GCBaseURL)
+}
+
+func TestLoadConfigFromEnvWithoutJobs(t *testing.T) {
+	t.Setenv("GC_SECRET_KEY", "secret")
+	t.Setenv("GC_SECRET_ID", "id")
+	t.Setenv("YNAB_TOKEN", "token")
+	t.Setenv("JOBS", "")
+	c, err := LoadConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Empty(t, c.Jobs)
+}
+
+func TestLoadConfigFromEnvSyncConcurrency(t *testing.T) {
+	t.Setenv("GC_SECRET_KEY", "secret")
+	t.Setenv("GC_SECRET_ID", "id")
+	t.Setenv("YNAB_TOKEN", "token")
+
+	c, err := LoadConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultSyncConcurrency, c.SyncConcurrency)
+
+	t.Setenv("SYNC_CONCURRENCY", "8")
+	c, err = LoadConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 8, c.SyncConcurrency)
+
+	t.Setenv("SYNC_CONCURRENCY", "0")
+	_, err = LoadConfigFromEnv()
+	assert.Error(t, err)
+}
+
+func TestLoadConfigFromEnvRetry(t *testing.T) {
+	t.Setenv("GC_SECRET_KEY", "secret")
+	t.Setenv("GC_SECRET_ID", "id")
+	t.Setenv("YNAB_TOKEN", "token")
+
+	c, err := LoadConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, retry.DefaultPolicy(), c.Retry)
+
+	t.Setenv("RETRY_MAX_ATTEMPTS", "5")
+	t.Setenv("RETRY_INITIAL_BACKOFF", "500ms")
+	t.Setenv("RETRY_MAX_BACKOFF", "1m")
+	t.Setenv("RETRY_JITTER", "0")
+	c, err = LoadConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, c.Retry.MaxAttempts)
+	assert.Equal(t, 500*time.Millisecond, c.Retry.InitialBackoff)
+	assert.Equal(t, time.Minute, c.Retry.MaxBackoff)
+	assert.Equal(t, 0.0, c.Retry.Jitter)
+
+	t.Setenv("RETRY_STATUS_CODES", "502, 503")
+	c, err = LoadConfigFromEnv()
+	assert.NoError(t, err)
+	if assert.NotNil(t, c.Retry.Retryable) {
+		assert.True(t, c.Retry.Retryable(503))
+		assert.False(t, c.Retry.Retryable(500))
+	}
+
+	t.Setenv("RETRY_STATUS_CODES", "5xx")
+	_, err = LoadConfigFromEnv()
+	assert.Error(t, err)
+	t.Setenv("RETRY_STATUS_CODES", "")
+
+	t.Setenv("RETRY_JITTER", "2")
+	_, err = LoadConfigFromEnv()
+	assert.Error(t, err)
 }
@@ -1,21 +1,28 @@
 package main
 
 import (
+	"context"
 	"fmt"
 )
 
 // ServiceContainer manages service instantiation and dependencies
 type ServiceContainer struct {
+	// ctx is cancelled on shutdown
+	ctx            context.Context
 	config         Config
 	gcService      GoCardlessServicer
 	ynabService    YNABServicer
 	monitorService MonitoringServicer
+	stateStore     StateStorer
+	rules          *rulesFile
 	syncService    SynchronizationServicer
 }
 
-// NewServiceContainer creates a new service container with the given configuration
-func NewServiceContainer(config Config) (*ServiceContainer, error) {
+// NewServiceContainer creates a new service container with the given configuration, whose services stop waiting
+// for the APIs once ctx is cancelled
+func NewServiceContainer(ctx context.Context, config Config) (*ServiceContainer, error) {
 	container := &ServiceContainer{
+		ctx:    ctx,
 		config: config,
 	}
 
@@ -36,6 +43,20 @@ func (c *ServiceContainer) initializeServices() error {
 	}
 	c.monitorService = monitorService
 
+	// Initialize state store
+	stateStore, err := c.createStateStore()
+	if err != nil {
+		return fmt.Errorf("failed to initialize state store: %w", err)
+	}
+	c.stateStore = stateStore
+
+	// Initialize rules, which are reloaded when their file changes
+	rules, err := c.createRules()
+	if err != nil {
+		return fmt.Errorf("failed to load rules: %w", err)
+	}
+	c.rules = rules
+
 	// Initialize GoCardless service
 	c.gcService = c.createGoCardlessService()
 
@@ -53,19 +74,29 @@ func (c *ServiceContainer) createMonitoringService() (MonitoringServicer, error)
 	return NewMonitoringService(c.config.NewRelicAppName, c.config.NewRelicLicenseKey)
 }
 
+// createStateStore creates a new state store
+func (c *ServiceContainer) createStateStore() (StateStorer, error) {
+	return NewFileStateStore(c.config.StateFile)
+}
+
+// createRules loads the rules of the rules file, nil when none is configured
+func (c *ServiceContainer) createRules() (*rulesFile, error) {
+	return newRulesFile(c.config.RulesFile)
+}
+
 // createGoCardlessService creates a new GoCardless service
 func (c *ServiceContainer) createGoCardlessService() GoCardlessServicer {
-	return NewGoCardlessService(c.config.GCSecretID, c.config.GCSecretKey)
+	return NewGoCardlessService(c.config.GCSecretID, c.config.GCSecretKey, c.config.GCBaseURL, c.stateStore, c.config.Retry)
 }
 
 // createYNABService creates a new YNAB service
 func (c *ServiceContainer) createYNABService() YNABServicer {
-	return NewYNABService(c.config.YNABToken)
+	return NewYNABService(c.ctx, c.config.YNABToken, c.config.YNABBaseURL, c.config.Retry)
 }
 
 // createSyncService creates a new synchronization service
 func (c *ServiceContainer) createSyncService() SynchronizationServicer {
-	return NewSyncService(c.gcService, c.ynabService, c.monitorService, c.config.Jobs)
+	return NewSyncService(c.gcService, c.ynabService, c.monitorService, c.stateStore, c.rules, c.config.Jobs, c.config.SyncConcurrency)
 }
 
 // Service getters
@@ -85,6 +116,16 @@ func (c *ServiceContainer) MonitorService() MonitoringServicer {
 	return c.monitorService
 }
 
+// StateStore returns the state store
+func (c *ServiceContainer) StateStore() StateStorer {
+	return c.stateStore
+}
+
+// Rules returns the rules of the rules file, nil when none is configured
+func (c *ServiceContainer) Rules() *rulesFile {
+	return c.rules
+}
+
 // SyncService returns the synchronization service
 func (c *ServiceContainer) SyncService() SynchronizationServicer {
 	return c.syncService
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+// toBudgetCurrency converts a transaction to the currency of its YNAB budget, using the amount the bank exchanged
+// it to or its exchange rate, and notes the original amount and rate in the memo. Transactions already in the
+// budget's currency keep their amount and only get the note when the bank exchanged them from another currency.
+// It returns an error for a transaction in another currency the bank didn't give an exchange for.
+func toBudgetCurrency(t Transaction, budgetCurrency string) (Transaction, error) {
+	if budgetCurrency == "" || t.Currency == "" {
+		return t, nil
+	}
+
+	if t.Currency == budgetCurrency {
+		for _, exchange := range t.CurrencyExchange {
+			foreign := foreignCurrency(exchange, budgetCurrency)
+			if foreign == "" {
+				continue
+			}
+			if original, ok := exchangedAmount(t.AmountMili, budgetCurrency, foreign, exchange); ok {
+				t.Memo = withExchangeNote(t.Memo, original, foreign, exchange.ExchangeRate)
+				return t, nil
+			}
+		}
+		return t, nil
+	}
+
+	for _, exchange := range t.CurrencyExchange {
+		amount, ok := exchangedAmount(t.AmountMili, t.Currency, budgetCurrency, exchange)
+		if !ok {
+			continue
+		}
+
+		t.Memo = withExchangeNote(t.Memo, t.AmountMili, t.Currency, exchange.ExchangeRate)
+		t.AmountMili = amount
+		t.Currency = budgetCurrency
+		return t, nil
+	}
+
+	return Transaction{}, errors.Errorf("transaction in %s doesn't match the budget currency %s and has no exchange to it", t.Currency, budgetCurrency)
+}
+
+// foreignCurrency returns the currency of an exchange that isn't the budget's, empty when there's none
+func foreignCurrency(exchange gocardless.CurrencyExchange, budgetCurrency string) string {
+	currencies := []string{exchange.SourceCurrency, exchange.TargetCurrency}
+	if exchange.InstructedAmount != nil {
+		currencies = append(currencies, exchange.InstructedAmount.Currency)
+	}
+
+	for _, currency := range currencies {
+		if currency != "" && currency != budgetCurrency {
+			return currency
+		}
+	}
+	return ""
+}
+
+// exchangedAmount returns the amount of milliunits in from exchanged to to, the instructed amount when the bank
+// gave it in to, otherwise the amount converted at the exchange's rate
+func exchangedAmount(milliunits int64, from, to string, exchange gocardless.CurrencyExchange) (int64, bool) {
+	if instructed := exchange.InstructedAmount; instructed != nil && instructed.Currency == to {
+		amount, err := parseMilliunits(instructed.Amount, instructed.Currency)
+		if err == nil {
+			// banks give the instructed amount without the direction of the transaction
+			if (amount < 0) != (milliunits < 0) {
+				amount = -amount
+			}
+			return amount, true
+		}
+	}
+
+	rate, ok := exchangeRate(exchange, from, to)
+	if !ok {
+		return 0, false
+	}
+	amount, err := convertMilliunits(milliunits, rate, to)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
+
+// exchangeRate returns the rate an amount in from is multiplied by to get it in to. The rate of an exchange is the
+// price of one unit currency in the other one, the unit currency defaults to the source currency.
+func exchangeRate(exchange gocardless.CurrencyExchange, from, to string) (*big.Rat, bool) {
+	if !(exchange.SourceCurrency == from && exchange.TargetCurrency == to) &&
+		!(exchange.SourceCurrency == to && exchange.TargetCurrency == from) {
+		return nil, false
+	}
+
+	rate, ok := new(big.Rat).SetString(exchange.ExchangeRate)
+	if !ok || rate.Sign() <= 0 {
+		return nil, false
+	}
+
+	unit := exchange.UnitCurrency
+	if unit == "" {
+		unit = exchange.SourceCurrency
+	}
+	switch unit {
+	case from:
+		return rate, true
+	case to:
+		return rate.Inv(rate), true
+	default:
+		return nil, false
+	}
+}
+
+// convertMilliunits multiplies milliunits by rate, rounding half away from zero to the decimal places of currency
+func convertMilliunits(milliunits int64, rate *big.Rat, currency string) (int64, error) {
+	digits, err := minorUnits(currency)
+	if err != nil {
+		return 0, err
+	}
+
+	// step is the number of milliunits of the smallest unit of the currency
+	step := big.NewInt(1)
+	for range milliunitDigits - digits {
+		step.Mul(step, big.NewInt(10))
+	}
+
+	steps := new(big.Rat).Mul(new(big.Rat).SetInt64(milliunits), rate)
+	steps.Quo(steps, new(big.Rat).SetInt(step))
+
+	quotient, remainder := new(big.Int).QuoRem(steps.Num(), steps.Denom(), new(big.Int))
+	if new(big.Int).Abs(new(big.Int).Mul(remainder, big.NewInt(2))).Cmp(steps.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(steps.Sign())))
+	}
+
+	quotient.Mul(quotient, step)
+	if !quotient.IsInt64() {
+		return 0, errors.Errorf("converted amount of %d milliunits is too large", milliunits)
+	}
+	return quotient.Int64(), nil
+}
+
+// withExchangeNote appends the original amount of a transaction, and the rate it was exchanged at, to its memo
+func withExchangeNote(memo string, milliunits int64, currency, rate string) string {
+	note := fmt.Sprintf("%s %s", formatAmount(milliunits, currency), currency)
+	if rate != "" {
+		note += " @ " + rate
+	}
+
+	if memo == "" {
+		return note
+	}
+	return fmt.Sprintf("%s (%s)", memo, note)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api/budget"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+func TestToBudgetCurrency(t *testing.T) {
+	gbpToEUR := gocardless.CurrencyExchange{SourceCurrency: "GBP", TargetCurrency: "EUR", ExchangeRate: "1.17"}
+
+	tests := []struct {
+		name        string
+		transaction Transaction
+		budget      string
+		want        Transaction
+		wantErr     string
+	}{
+		{
+			name:        "same currency",
+			transaction: Transaction{AmountMili: -1000, Currency: "EUR", Memo: "Coffee"},
+			budget:      "EUR",
+			want:        Transaction{AmountMili: -1000, Currency: "EUR", Memo: "Coffee"},
+		},
+		{
+			name:        "unknown budget currency",
+			transaction: Transaction{AmountMili: -1000, Currency: "GBP"},
+			want:        Transaction{AmountMili: -1000, Currency: "GBP"},
+		},
+		{
+			name:        "exchanged by the bank",
+			transaction: Transaction{AmountMili: -11700, Currency: "EUR", Memo: "Tea", CurrencyExchange: []gocardless.CurrencyExchange{gbpToEUR}},
+			budget:      "EUR",
+			want:        Transaction{AmountMili: -11700, Currency: "EUR", Memo: "Tea (-10.00 GBP @ 1.17)", CurrencyExchange: []gocardless.CurrencyExchange{gbpToEUR}},
+		},
+		{
+			name:        "converted at the rate",
+			transaction: Transaction{AmountMili: -10000, Currency: "GBP", CurrencyExchange: []gocardless.CurrencyExchange{gbpToEUR}},
+			budget:      "EUR",
+			want:        Transaction{AmountMili: -11700, Currency: "EUR", Memo: "-10.00 GBP @ 1.17", CurrencyExchange: []gocardless.CurrencyExchange{gbpToEUR}},
+		},
+		{
+			name:        "converted against the rate",
+			transaction: Transaction{AmountMili: 11700, Currency: "EUR", CurrencyExchange: []gocardless.CurrencyExchange{gbpToEUR}},
+			budget:      "GBP",
+			want:        Transaction{AmountMili: 10000, Currency: "GBP", Memo: "11.70 EUR @ 1.17", CurrencyExchange: []gocardless.CurrencyExchange{gbpToEUR}},
+		},
+		{
+			name: "instructed amount",
+			transaction: Transaction{AmountMili: -10000, Currency: "GBP", CurrencyExchange: []gocardless.CurrencyExchange{
+				{SourceCurrency: "GBP", TargetCurrency: "EUR", ExchangeRate: "1.17", InstructedAmount: &gocardless.Amount{Amount: "11.68", Currency: "EUR"}},
+			}},
+			budget: "EUR",
+			want: Transaction{AmountMili: -11680, Currency: "EUR", Memo: "-10.00 GBP @ 1.17", CurrencyExchange: []gocardless.CurrencyExchange{
+				{SourceCurrency: "GBP", TargetCurrency: "EUR", ExchangeRate: "1.17", InstructedAmount: &gocardless.Amount{Amount: "11.68", Currency: "EUR"}},
+			}},
+		},
+		{
+			name:        "no exchange",
+			transaction: Transaction{AmountMili: -5000, Currency: "USD"},
+			budget:      "EUR",
+			wantErr:     "transaction in USD doesn't match the budget currency EUR and has no exchange to it",
+		},
+		{
+			name:        "exchange of other currencies",
+			transaction: Transaction{AmountMili: -5000, Currency: "USD", CurrencyExchange: []gocardless.CurrencyExchange{gbpToEUR}},
+			budget:      "EUR",
+			wantErr:     "transaction in USD doesn't match the budget currency EUR and has no exchange to it",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toBudgetCurrency(tt.transaction, tt.budget)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExchangeRate(t *testing.T) {
+	exchange := gocardless.CurrencyExchange{SourceCurrency: "EUR", TargetCurrency: "PLN", ExchangeRate: "4.25"}
+
+	rate, ok := exchangeRate(exchange, "EUR", "PLN")
+	assert.True(t, ok)
+	assert.Equal(t, big.NewRat(17, 4), rate)
+
+	rate, ok = exchangeRate(exchange, "PLN", "EUR")
+	assert.True(t, ok)
+	assert.Equal(t, big.NewRat(4, 17), rate)
+
+	// The unit currency is the one a single unit of is priced
+	exchange.UnitCurrency = "PLN"
+	rate, ok = exchangeRate(exchange, "EUR", "PLN")
+	assert.True(t, ok)
+	assert.Equal(t, big.NewRat(4, 17), rate)
+
+	_, ok = exchangeRate(gocardless.CurrencyExchange{SourceCurrency: "EUR", TargetCurrency: "PLN", ExchangeRate: "n/a"}, "EUR", "PLN")
+	assert.False(t, ok)
+	_, ok = exchangeRate(gocardless.CurrencyExchange{SourceCurrency: "EUR", TargetCurrency: "PLN", ExchangeRate: "0"}, "EUR", "PLN")
+	assert.False(t, ok)
+}
+
+func TestConvertMilliunits(t *testing.T) {
+	tests := []struct {
+		name       string
+		milliunits int64
+		rate       *big.Rat
+		currency   string
+		want       int64
+	}{
+		{name: "exact", milliunits: -10000, rate: big.NewRat(117, 100), currency: "EUR", want: -11700},
+		{name: "rounds half up", milliunits: 1000, rate: big.NewRat(1005, 1000), currency: "EUR", want: 1010},
+		{name: "rounds half away from zero", milliunits: -1000, rate: big.NewRat(1005, 1000), currency: "EUR", want: -1010},
+		{name: "rounds down", milliunits: 1000, rate: big.NewRat(1004, 1000), currency: "EUR", want: 1000},
+		{name: "currency without decimals", milliunits: 10000, rate: big.NewRat(1575, 10), currency: "JPY", want: 1575000},
+		{name: "currency with three decimals", milliunits: 10000, rate: big.NewRat(3071, 10000), currency: "KWD", want: 3071},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertMilliunits(tt.milliunits, tt.rate, tt.currency)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSyncServiceListTransactionsCurrency(t *testing.T) {
+	testJob := job{GCAccountID: "aaa", YNABAccountID: "bbb", YNABBudgetID: "ccc"}
+	now := time.Now().UTC()
+
+	t.Run("checks transactions against the budget currency", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService := &SyncService{gcService: goCardlessMock, ynabService: ynabMock}
+
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", mock.Anything, mock.Anything).Return([]Transaction{
+			{ID: "eur", AmountMili: -1000, Currency: "EUR"},
+			{ID: "usd", AmountMili: -2000, Currency: "USD"},
+		}, nil)
+		// The currency of a budget is fetched once
+		ynabMock.EXPECT().GetBudgetSettings("ccc").Return(&budget.Settings{CurrencyFormat: &budget.CurrencyFormat{ISOCode: "EUR"}}, nil).Once()
+
+		for range 2 {
+			transactions, skipped, err := syncService.listTransactions(context.Background(), testJob, now, now)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, skipped)
+			if assert.Len(t, transactions, 1) {
+				assert.Equal(t, "eur", transactions[0].ID)
+			}
+		}
+	})
+
+	t.Run("fails without the budget currency", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService := &SyncService{gcService: goCardlessMock, ynabService: ynabMock}
+
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", mock.Anything, mock.Anything).Return([]Transaction{{ID: "eur", Currency: "EUR"}}, nil)
+		ynabMock.EXPECT().GetBudgetSettings("ccc").Return(nil, assert.AnError)
+
+		_, _, err := syncService.listTransactions(context.Background(), testJob, now, now)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api"
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/pkg/errors"
+)
+
+// dryRunStatus says what uploading a transaction would do
+type dryRunStatus string
+
+const (
+	// dryRunNew is a transaction YNAB doesn't have yet
+	dryRunNew dryRunStatus = "new"
+	// dryRunDuplicate is a transaction YNAB already has, it would be skipped
+	dryRunDuplicate dryRunStatus = "duplicate"
+	// dryRunChanged is a transaction YNAB has with a different amount, date or payee
+	dryRunChanged dryRunStatus = "changed"
+	// dryRunMatched is a transaction the user entered in YNAB, it would get the import ID instead of being uploaded
+	dryRunMatched dryRunStatus = "matched"
+)
+
+// dryRunEntry is a single row of the dry-run diff
+type dryRunEntry struct {
+	Status  dryRunStatus
+	Payload transaction.PayloadTransaction
+	// Existing is the YNAB transaction with the same import ID, or the entered one it matches, if there is one
+	Existing *transaction.Transaction
+}
+
+// dryRun fetches the transactions of a job between from and to, maps them the way a synchronization would and
+// prints how they compare to what is already in YNAB, without changing anything. The numbers of fetched and skipped
+// transactions are added to result.
+func (s *SyncService) dryRun(ctx context.Context, j job, state JobState, from, to time.Time, result *JobResult) error {
+	transactions, skipped, err := s.listTransactions(ctx, j, from, to)
+	if err != nil {
+		return errors.Wrap(err, "failed to list transactions")
+	}
+	result.Fetched += len(transactions)
+	result.Skipped += skipped
+
+	payloadTransactions := toYNABTransaction(j, state, transactions)
+
+	// Transactions entered in YNAB may be dated a few days before the bank booked them
+	sinceDate := api.Date{Time: from.AddDate(0, 0, -manualMatchDays)}
+	ynabTransactions, err := s.ynabService.GetTransactionsByAccount(j.YNABBudgetID, j.YNABAccountID, &transaction.Filter{Since: &sinceDate})
+	if err != nil {
+		return errors.Wrap(err, "failed to list YNAB transactions")
+	}
+
+	entries := diffTransactions(state, transactions, payloadTransactions, ynabTransactions)
+	var diff bytes.Buffer
+	printDryRun(&diff, j, from, to, entries)
+
+	s.outputMu.Lock()
+	defer s.outputMu.Unlock()
+	_, _ = s.output.Write(diff.Bytes())
+
+	return nil
+}
+
+// diffTransactions compares the payloads that would be uploaded with the transactions already in YNAB
+func diffTransactions(state JobState, transactions []Transaction, payloadTransactions []transaction.PayloadTransaction, ynabTransactions []*transaction.Transaction) []dryRunEntry {
+	byImportID := make(map[string]*transaction.Transaction, len(ynabTransactions))
+	byID := make(map[string]*transaction.Transaction, len(ynabTransactions))
+	for _, t := range ynabTransactions {
+		if t.Deleted {
+			continue
+		}
+		byID[t.ID] = t
+		if t.ImportID != nil {
+			byImportID[*t.ImportID] = t
+		}
+	}
+
+	// Transactions entered in YNAB that the state doesn't tie to a fetched one yet can be matched
+	taken := make(map[string]bool)
+	for _, uploaded := range state.Uploaded {
+		taken[uploaded.YNABID] = true
+	}
+	for _, pending := range state.Pending {
+		taken[pending.YNABID] = true
+	}
+	var entered []*transaction.Transaction
+	for _, t := range ynabTransactions {
+		if isManual(t) {
+			entered = append(entered, t)
+		}
+	}
+
+	entries := make([]dryRunEntry, 0, len(payloadTransactions))
+	for i, p := range payloadTransactions {
+		existing := byImportID[*p.ImportID]
+		if existing == nil {
+			// Transactions uploaded under a different import ID are found through the state
+			if uploaded, ok := state.Uploaded[uploadKey(transactions[i], *p.ImportID)]; ok {
+				existing = byID[uploaded.YNABID]
+				if existing == nil {
+					existing = byImportID[uploaded.ImportID]
+				}
+			}
+		}
+
+		entry := dryRunEntry{Payload: p, Existing: existing}
+		switch {
+		case existing == nil && state.IsUploaded(uploadKey(transactions[i], *p.ImportID)):
+			// Uploaded before but no longer in YNAB, e.g. deleted by the user, it won't be uploaded again
+			entry.Status = dryRunDuplicate
+		case existing == nil && transactions[i].Pending && state.IsPending(*p.ImportID):
+			entry.Status = dryRunDuplicate
+		case existing == nil:
+			entry.Status = dryRunNew
+			if match := matchManualTransaction(transactions[i], entered, taken); match != nil {
+				taken[match.ID] = true
+				entry.Status, entry.Existing = dryRunMatched, match
+			}
+		case existing.Amount != p.Amount || !existing.Date.Equal(p.Date.Time) || !samePayee(existing, p):
+			entry.Status = dryRunChanged
+		default:
+			entry.Status = dryRunDuplicate
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// samePayee reports whether the payload's payee name matches the existing transaction's
+func samePayee(existing *transaction.Transaction, p transaction.PayloadTransaction) bool {
+	if existing.PayeeName == nil || p.PayeeName == nil {
+		return existing.PayeeName == p.PayeeName
+	}
+	return *existing.PayeeName == *p.PayeeName
+}
+
+// printDryRun writes the dry-run diff of a job as a table
+func printDryRun(w io.Writer, j job, from, to time.Time, entries []dryRunEntry) {
+	counts := make(map[dryRunStatus]int)
+	for _, e := range entries {
+		counts[e.Status]++
+	}
+
+	_, _ = fmt.Fprintf(w, "job %s -> budget %s account %s, %s to %s: %d new, %d duplicate, %d changed, %d matched\n",
+		j.GCAccountID, j.YNABBudgetID, j.YNABAccountID, from.Format("2006-01-02"), to.Format("2006-01-02"),
+		counts[dryRunNew], counts[dryRunDuplicate], counts[dryRunChanged], counts[dryRunMatched])
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "STATUS\tDATE\tAMOUNT\tCLEARED\tPAYEE\tMEMO\tIMPORT ID\tIN YNAB")
+	for _, e := range entries {
+		inYNAB := ""
+		if e.Existing != nil {
+			inYNAB = fmt.Sprintf("%s %s %s", e.Existing.Date.Format("2006-01-02"), formatMilliunits(e.Existing.Amount), valueOrEmpty(e.Existing.PayeeName))
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Status,
+			e.Payload.Date.Format("2006-01-02"),
+			formatMilliunits(e.Payload.Amount),
+			e.Payload.Cleared,
+			valueOrEmpty(e.Payload.PayeeName),
+			valueOrEmpty(e.Payload.Memo),
+			valueOrEmpty(e.Payload.ImportID),
+			inYNAB,
+		)
+	}
+	_ = tw.Flush()
+}
+
+// formatMilliunits formats a YNAB milliunits amount as a decimal number with at least two decimal places
+func formatMilliunits(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	formatted := fmt.Sprintf("%s%d.%03d", sign, amount/1000, amount%1000)
+	return strings.TrimSuffix(formatted, "0")
+}
+
+func valueOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
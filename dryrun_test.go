@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api"
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDryRun(t *testing.T) {
+	// Setup
+	goCardlessMock := newMockgoCardlesser(t)
+	ynabMock := newMockynaber(t)
+	monitorMock := NewMockMonitoringServicer(t)
+
+	nowTS := time.Now().UTC().Truncate(time.Hour)
+	date := nowTS.AddDate(0, 0, -2).Truncate(24 * time.Hour)
+	testJob := job{
+		GCAccountID:      "aaa",
+		YNABAccountID:    "bbb",
+		YNABBudgetID:     "ccc",
+		LookbackDays:     defaultLookbackDays,
+		ImportIDStrategy: importIDTransactionID,
+		DryRun:           true,
+	}
+
+	transactions := []Transaction{
+		{ID: "new", Date: date, AmountMili: -1000, Name: "New"},
+		{ID: "same", Date: date, AmountMili: -2000, Name: "Same"},
+		{ID: "changed", Date: date, AmountMili: -3000, Name: "Changed"},
+		{ID: "entered", Date: date, AmountMili: -4000, Name: "BAKERY 123"},
+	}
+	sameImportID := "GC:same"
+	samePayee := "Same"
+	changedImportID := "GC:changed"
+	changedPayee := "Changed"
+	enteredPayee := "Bakery"
+
+	goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil)
+	goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", mock.Anything, nowTS).Return(transactions, nil)
+	ynabMock.EXPECT().GetTransactionsByAccount("ccc", "bbb", mock.Anything).Return([]*transaction.Transaction{
+		{ID: "y1", Date: api.Date{Time: date}, Amount: -2000, PayeeName: &samePayee, ImportID: &sameImportID},
+		{ID: "y2", Date: api.Date{Time: date}, Amount: -3500, PayeeName: &changedPayee, ImportID: &changedImportID},
+		{ID: "y3", Date: api.Date{Time: date.AddDate(0, 0, -1)}, Amount: -4000, PayeeName: &enteredPayee, Cleared: transaction.ClearingStatusUncleared, Approved: true},
+	}, nil)
+
+	mockTxn := &newrelic.Transaction{}
+	monitorMock.On("StartTransaction", "synchronization").Return(mockTxn)
+	monitorMock.On("AddAttribute", mockTxn, mock.Anything, mock.Anything).Return()
+	monitorMock.On("NewContext", mock.Anything, mockTxn).Return(context.Background())
+
+	stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	assert.NoError(t, err)
+
+	syncService := NewSyncService(goCardlessMock, ynabMock, monitorMock, stateStore, nil, []job{testJob}, 1)
+	output := &bytes.Buffer{}
+	syncService.(*SyncService).output = output
+
+	// Test
+	_, err = syncService.SynchronizeTransaction(context.Background(), testJob)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Contains(t, output.String(), "1 new, 1 duplicate, 1 changed, 1 matched")
+	assert.Regexp(t, `new\s+\S+\s+-1\.00\s+cleared\s+New`, output.String())
+	assert.Regexp(t, `changed\s+\S+\s+-3\.00.*-3\.50 Changed`, output.String())
+	assert.Regexp(t, `matched\s+\S+\s+-4\.00.*-4\.00 Bakery`, output.String())
+
+	// Nothing was recorded
+	state, err := stateStore.JobState(testJob.key())
+	assert.NoError(t, err)
+	assert.True(t, state.LastSyncedAt.IsZero())
+	assert.Empty(t, state.Uploaded)
+}
+
+func TestFormatMilliunits(t *testing.T) {
+	assert.Equal(t, "0.00", formatMilliunits(0))
+	assert.Equal(t, "-19.99", formatMilliunits(-19990))
+	assert.Equal(t, "1.234", formatMilliunits(1234))
+	assert.Equal(t, "-0.50", formatMilliunits(-500))
+}
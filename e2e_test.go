@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"psmarcin.github.com/open-ynab-sync/internal/fakegocardless"
+	"psmarcin.github.com/open-ynab-sync/internal/fakeynab"
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+	"psmarcin.github.com/open-ynab-sync/internal/retry"
+)
+
+// e2e runs the synchronization against fake GoCardless and YNAB servers through the real API clients
+type e2e struct {
+	gc         *fakegocardless.Server
+	ynab       *fakeynab.Server
+	gcURL      string
+	ynabURL    string
+	stateStore *FileStateStore
+	rules      *rulesFile
+	jobs       []job
+}
+
+func newE2E(t *testing.T) *e2e {
+	gc := fakegocardless.New("id", "secret")
+	gcServer := httptest.NewServer(gc)
+	t.Cleanup(gcServer.Close)
+
+	ynab := fakeynab.New("token")
+	ynab.AddBudget(fakeynab.Budget{ID: "budget", Name: "Budget", CurrencyISOCode: "EUR"})
+	ynab.AddAccount(fakeynab.Account{ID: "checking", BudgetID: "budget", Name: "Checking", OnBudget: true})
+	ynab.AddAccount(fakeynab.Account{ID: "savings", BudgetID: "budget", Name: "Savings", OnBudget: true})
+	ynabServer := httptest.NewServer(ynab)
+	t.Cleanup(ynabServer.Close)
+
+	stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+
+	return &e2e{
+		gc:         gc,
+		ynab:       ynab,
+		gcURL:      gcServer.URL + fakegocardless.APIPath,
+		ynabURL:    ynabServer.URL + fakeynab.APIPath,
+		stateStore: stateStore,
+		jobs: []job{
+			{Name: "checking", GCAccountID: "gc-checking", YNABBudgetID: "budget", YNABAccountID: "checking", LookbackDays: defaultLookbackDays, ImportIDStrategy: importIDAmountDate},
+			{Name: "savings", GCAccountID: "gc-savings", YNABBudgetID: "budget", YNABAccountID: "savings", LookbackDays: defaultLookbackDays, ImportIDStrategy: importIDTransactionID},
+		},
+	}
+}
+
+// sync synchronizes all jobs with new clients, like a restarted process would
+func (e *e2e) sync(t *testing.T) SyncReport {
+	policy := retry.Policy{MaxAttempts: 2}
+	syncService := NewSyncService(
+		NewGoCardlessService("id", "secret", e.gcURL, e.stateStore, policy),
+		NewYNABService(context.Background(), "token", e.ynabURL, policy),
+		&NoOpMonitoring{},
+		e.stateStore,
+		e.rules,
+		e.jobs,
+		2,
+	)
+
+	report, err := syncService.SynchronizeTransactions(context.Background(), e.jobs)
+	if err != nil {
+		t.Logf("synchronization failed: %v", err)
+	}
+	return report
+}
+
+// transactions returns the transactions of a YNAB account that weren't deleted
+func (e *e2e) transactions(accountID string) []fakeynab.Transaction {
+	var transactions []fakeynab.Transaction
+	for _, t := range e.ynab.Transactions("budget") {
+		if t.AccountID == accountID && !t.Deleted {
+			transactions = append(transactions, t)
+		}
+	}
+	return transactions
+}
+
+func day(daysAgo int) string {
+	return time.Now().UTC().AddDate(0, 0, -daysAgo).Format(time.DateOnly)
+}
+
+func eur(amount string) fakegocardless.Amount {
+	return fakegocardless.Amount{Amount: amount, Currency: "EUR"}
+}
+
+func TestE2ESynchronization(t *testing.T) {
+	t.Run("uploads new transactions once", func(t *testing.T) {
+		e := newE2E(t)
+		e.gc.AddAccount(fakegocardless.Account{
+			ID: "gc-checking",
+			Booked: []fakegocardless.Transaction{
+				{TransactionID: "c1", BookingDate: day(5), ValueDate: day(5), TransactionAmount: eur("-12.34"), CreditorName: "Shop", RemittanceInformationUnstructured: "Groceries"},
+				{TransactionID: "c2", BookingDate: day(3), ValueDate: day(3), TransactionAmount: eur("2500.00"), DebtorName: "ACME Corp"},
+			},
+		})
+		e.gc.AddAccount(fakegocardless.Account{
+			ID: "gc-savings",
+			Booked: []fakegocardless.Transaction{
+				{TransactionID: "s1", BookingDate: day(2), ValueDate: day(2), TransactionAmount: eur("500.00"), DebtorName: "John Doe"},
+			},
+		})
+
+		report := e.sync(t)
+		assert.NoError(t, report.Err())
+
+		checking := e.transactions("checking")
+		if assert.Len(t, checking, 2) {
+			assert.Equal(t, int64(-12340), checking[0].Amount)
+			assert.Equal(t, "Shop", *checking[0].PayeeName)
+			assert.Equal(t, "Groceries", *checking[0].Memo)
+			assert.Equal(t, "cleared", checking[0].Cleared)
+			assert.Equal(t, "YNAB:-12340:"+day(5)+":1", *checking[0].ImportID)
+		}
+		savings := e.transactions("savings")
+		if assert.Len(t, savings, 1) {
+			assert.Equal(t, "GC:s1", *savings[0].ImportID)
+		}
+
+		// The state knows what was uploaded, so nothing is sent again
+		creates := countRequests(e.ynab.Requests(), http.MethodPost+" ")
+		report = e.sync(t)
+		assert.NoError(t, report.Err())
+		assert.Equal(t, creates, countRequests(e.ynab.Requests(), http.MethodPost+" "))
+
+		// Without the state YNAB skips the transactions by their import IDs
+		stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		require.NoError(t, err)
+		e.stateStore = stateStore
+		report = e.sync(t)
+		assert.NoError(t, report.Err())
+		assert.Len(t, e.transactions("checking"), 2)
+		assert.Len(t, e.transactions("savings"), 1)
+	})
+
+	t.Run("books pending transactions in place", func(t *testing.T) {
+		e := newE2E(t)
+		e.jobs = e.jobs[:1]
+		e.gc.AddAccount(fakegocardless.Account{
+			ID: "gc-checking",
+			Pending: []fakegocardless.Transaction{
+				{ValueDate: day(1), TransactionAmount: eur("-4.50"), CreditorName: "Corner Cafe"},
+				{ValueDate: day(1), TransactionAmount: eur("-99.00"), CreditorName: "Hotel"},
+			},
+		})
+
+		report := e.sync(t)
+		assert.NoError(t, report.Err())
+		pending := e.transactions("checking")
+		if assert.Len(t, pending, 2) {
+			assert.Equal(t, "uncleared", pending[0].Cleared)
+		}
+
+		// The cafe transaction is booked with a tip, the hotel pre-authorization is released
+		e.gc.SetTransactions("gc-checking", []fakegocardless.Transaction{
+			{TransactionID: "c1", BookingDate: day(0), ValueDate: day(0), TransactionAmount: eur("-5.00"), CreditorName: "Corner Cafe"},
+		}, nil)
+
+		report = e.sync(t)
+		assert.NoError(t, report.Err())
+		booked := e.transactions("checking")
+		if assert.Len(t, booked, 1) {
+			assert.Equal(t, pending[0].ID, booked[0].ID)
+			assert.Equal(t, int64(-5000), booked[0].Amount)
+			assert.Equal(t, day(0), booked[0].Date)
+			assert.Equal(t, "cleared", booked[0].Cleared)
+		}
+	})
+
+	t.Run("matches transactions entered in YNAB", func(t *testing.T) {
+		e := newE2E(t)
+		e.jobs = e.jobs[:1]
+		bakery, memo := "Bakery", "Birthday cake"
+		entered := e.ynab.AddTransaction("budget", fakeynab.Transaction{AccountID: "checking", Date: day(4), Amount: -25000, PayeeName: &bakery, Memo: &memo, Approved: true})
+		e.gc.AddAccount(fakegocardless.Account{
+			ID: "gc-checking",
+			Booked: []fakegocardless.Transaction{
+				{TransactionID: "c1", BookingDate: day(2), ValueDate: day(2), TransactionAmount: eur("-25.50"), CreditorName: "BAKERY"},
+				{TransactionID: "c2", BookingDate: day(1), ValueDate: day(1), TransactionAmount: eur("-4.20"), CreditorName: "Lidl"},
+			},
+		})
+
+		report := e.sync(t)
+		assert.NoError(t, report.Err())
+		checking := e.transactions("checking")
+		if assert.Len(t, checking, 2) {
+			assert.Equal(t, entered, checking[0].ID)
+			assert.Equal(t, int64(-25500), checking[0].Amount)
+			assert.Equal(t, day(4), checking[0].Date)
+			assert.Equal(t, "cleared", checking[0].Cleared)
+			assert.Equal(t, memo, *checking[0].Memo)
+			assert.NotNil(t, checking[0].ImportID)
+			assert.Equal(t, "Lidl", *checking[1].PayeeName)
+		}
+
+		// The matched transaction isn't uploaded again
+		report = e.sync(t)
+		assert.NoError(t, report.Err())
+		assert.Len(t, e.transactions("checking"), 2)
+	})
+
+	t.Run("retries transient YNAB failures", func(t *testing.T) {
+		e := newE2E(t)
+		e.jobs = e.jobs[:1]
+		e.gc.AddAccount(fakegocardless.Account{
+			ID: "gc-checking",
+			Booked: []fakegocardless.Transaction{
+				{TransactionID: "c1", BookingDate: day(1), ValueDate: day(1), TransactionAmount: eur("-1.00"), CreditorName: "Shop"},
+			},
+		})
+		e.ynab.FailNext(http.StatusServiceUnavailable)
+
+		report := e.sync(t)
+		assert.NoError(t, report.Err())
+		assert.Len(t, e.transactions("checking"), 1)
+	})
+
+	t.Run("numbers a transaction of the same amount and day after the uploaded one", func(t *testing.T) {
+		e := newE2E(t)
+		e.jobs = e.jobs[:1]
+		coffee := func(id string) fakegocardless.Transaction {
+			return fakegocardless.Transaction{TransactionID: id, BookingDate: day(1), ValueDate: day(1), TransactionAmount: eur("-4.50"), CreditorName: "Cafe"}
+		}
+		e.gc.AddAccount(fakegocardless.Account{ID: "gc-checking", Booked: []fakegocardless.Transaction{coffee("c1")}})
+		assert.NoError(t, e.sync(t).Err())
+
+		// GoCardless lists the newest transactions first, so the second coffee comes before the uploaded one
+		e.gc.AddAccount(fakegocardless.Account{ID: "gc-checking", Booked: []fakegocardless.Transaction{coffee("c2"), coffee("c1")}})
+		report := e.sync(t)
+		assert.NoError(t, report.Err())
+		if assert.Len(t, report.Results, 1) {
+			assert.Equal(t, 1, report.Results[0].Uploaded)
+		}
+
+		checking := e.transactions("checking")
+		if assert.Len(t, checking, 2) {
+			assert.ElementsMatch(t, []string{"YNAB:-4500:" + day(1) + ":1", "YNAB:-4500:" + day(1) + ":2"}, []string{*checking[0].ImportID, *checking[1].ImportID})
+		}
+	})
+
+	t.Run("converts foreign currency transactions", func(t *testing.T) {
+		e := newE2E(t)
+		e.jobs = e.jobs[:1]
+		e.gc.AddAccount(fakegocardless.Account{
+			ID: "gc-checking",
+			Booked: []fakegocardless.Transaction{
+				// The bank exchanged the card spend to the account's currency
+				{TransactionID: "c1", BookingDate: day(3), ValueDate: day(3), TransactionAmount: eur("-11.70"), CreditorName: "London Cafe",
+					CurrencyExchange: []fakegocardless.CurrencyExchange{{SourceCurrency: "GBP", TargetCurrency: "EUR", ExchangeRate: "1.17", InstructedAmount: &fakegocardless.Amount{Amount: "10.00", Currency: "GBP"}}}},
+				// The bank gave the amount in the currency it was made in
+				{TransactionID: "c2", BookingDate: day(2), ValueDate: day(2), TransactionAmount: fakegocardless.Amount{Amount: "-20.00", Currency: "GBP"}, CreditorName: "London Shop",
+					CurrencyExchange: []fakegocardless.CurrencyExchange{{SourceCurrency: "GBP", TargetCurrency: "EUR", ExchangeRate: "1.1725"}}},
+				// Without an exchange the amount can't be put in the budget
+				{TransactionID: "c3", BookingDate: day(1), ValueDate: day(1), TransactionAmount: fakegocardless.Amount{Amount: "-5.00", Currency: "USD"}, CreditorName: "Online Shop"},
+			},
+		})
+
+		report := e.sync(t)
+		assert.NoError(t, report.Err())
+		if assert.Len(t, report.Results, 1) {
+			assert.Equal(t, 2, report.Results[0].Fetched)
+			assert.Equal(t, 1, report.Results[0].Skipped)
+		}
+
+		checking := e.transactions("checking")
+		if assert.Len(t, checking, 2) {
+			assert.Equal(t, int64(-11700), checking[0].Amount)
+			assert.Equal(t, "-10.00 GBP @ 1.17", *checking[0].Memo)
+			assert.Equal(t, int64(-23450), checking[1].Amount)
+			assert.Equal(t, "-20.00 GBP @ 1.1725", *checking[1].Memo)
+		}
+	})
+
+	t.Run("renames payees with rules", func(t *testing.T) {
+		e := newE2E(t)
+		e.jobs = e.jobs[:1]
+		path := filepath.Join(t.TempDir(), "rules.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"payees": [{"match": {"regex": "^CARD \\d+ (\\w+)"}, "payee": "${1}"}]}`), 0o600))
+		rules, err := newRulesFile(path)
+		require.NoError(t, err)
+		e.rules = rules
+		e.gc.AddAccount(fakegocardless.Account{
+			ID: "gc-checking",
+			Booked: []fakegocardless.Transaction{
+				{TransactionID: "c1", BookingDate: day(1), ValueDate: day(1), TransactionAmount: eur("-4.20"), RemittanceInformationUnstructured: "CARD 1234 LIDL WARSZAWA 12/03"},
+			},
+		})
+
+		report := e.sync(t)
+		assert.NoError(t, report.Err())
+		if checking := e.transactions("checking"); assert.Len(t, checking, 1) {
+			assert.Equal(t, "LIDL", *checking[0].PayeeName)
+			assert.Equal(t, "CARD 1234 LIDL WARSZAWA 12/03", *checking[0].Memo)
+		}
+	})
+
+	t.Run("categorizes transactions with rules", func(t *testing.T) {
+		e := newE2E(t)
+		e.jobs = e.jobs[:1]
+		e.ynab.AddCategory(fakeynab.Category{ID: "groceries", BudgetID: "budget", Group: "Everyday", Name: "Groceries"})
+		e.ynab.AddCategory(fakeynab.Category{ID: "rent", BudgetID: "budget", Group: "Bills", Name: "Rent"})
+		path := filepath.Join(t.TempDir(), "rules.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"categories": [
+			{"match": {"payee": "Lidl"}, "category": "groceries", "approve": true},
+			{"match": {"direction": "out", "min_amount": "1000"}, "category": "Bills: Rent"},
+			{"match": {"direction": "in"}, "category": "Salary"}
+		]}`), 0o600))
+		rules, err := newRulesFile(path)
+		require.NoError(t, err)
+		e.rules = rules
+		e.gc.AddAccount(fakegocardless.Account{
+			ID: "gc-checking",
+			Booked: []fakegocardless.Transaction{
+				{TransactionID: "c1", BookingDate: day(3), ValueDate: day(3), TransactionAmount: eur("-4.20"), CreditorName: "Lidl"},
+				{TransactionID: "c2", BookingDate: day(2), ValueDate: day(2), TransactionAmount: eur("-1500.00"), CreditorName: "Landlord"},
+				{TransactionID: "c3", BookingDate: day(1), ValueDate: day(1), TransactionAmount: eur("2500.00"), DebtorName: "ACME Corp"},
+			},
+		})
+
+		report := e.sync(t)
+		assert.NoError(t, report.Err())
+		if checking := e.transactions("checking"); assert.Len(t, checking, 3) {
+			assert.Equal(t, "Groceries", *checking[0].CategoryName)
+			assert.True(t, checking[0].Approved)
+			assert.Equal(t, "Rent", *checking[1].CategoryName)
+			assert.False(t, checking[1].Approved)
+			// The budget has no Salary category, so the transaction stays uncategorized
+			assert.Nil(t, checking[2].CategoryID)
+		}
+	})
+
+	t.Run("renders memos with templates", func(t *testing.T) {
+		e := newE2E(t)
+		e.jobs = e.jobs[:1]
+		path := filepath.Join(t.TempDir(), "memo.tmpl")
+		require.NoError(t, os.WriteFile(path, []byte(`{{ coalesce .RemittanceInformationUnstructured (.AdditionalInformation | extract "TITLE: (.+)") | squash }}`), 0o600))
+		memo, err := parseMemoTemplate(path)
+		require.NoError(t, err)
+		e.jobs[0].MemoTemplate = memo
+		e.gc.AddAccount(fakegocardless.Account{
+			ID: "gc-checking",
+			Booked: []fakegocardless.Transaction{
+				{TransactionID: "c1", BookingDate: day(2), ValueDate: day(2), TransactionAmount: eur("-4.20"), CreditorName: "Bakery", AdditionalInformation: "CARD 1234 TITLE: Bread  and   rolls"},
+				{TransactionID: "c2", BookingDate: day(1), ValueDate: day(1), TransactionAmount: eur("-9.99"), CreditorName: "Shop", RemittanceInformationUnstructured: strings.Repeat("x", 250)},
+			},
+		})
+
+		report := e.sync(t)
+		assert.NoError(t, report.Err())
+		if checking := e.transactions("checking"); assert.Len(t, checking, 2) {
+			assert.Equal(t, "Bread and rolls", *checking[0].Memo)
+			// Memos are cut to the length YNAB accepts
+			assert.Equal(t, strings.Repeat("x", maxMemoLength), *checking[1].Memo)
+		}
+	})
+
+	t.Run("links transfers between jobs", func(t *testing.T) {
+		e := newE2E(t)
+		const checkingIBAN, savingsIBAN = "PL61109010140000071219812874", "PL27114020040000300201355387"
+		e.gc.AddAccount(fakegocardless.Account{
+			ID:   "gc-checking",
+			IBAN: checkingIBAN,
+			Booked: []fakegocardless.Transaction{
+				{TransactionID: "c1", BookingDate: day(3), ValueDate: day(3), TransactionAmount: eur("-100.00"), CreditorName: "Me", CreditorAccount: &fakegocardless.AccountReference{IBAN: savingsIBAN}},
+				{TransactionID: "c2", BookingDate: day(2), ValueDate: day(2), TransactionAmount: eur("-100.00"), CreditorName: "Shop"},
+			},
+		})
+		e.gc.AddAccount(fakegocardless.Account{
+			ID:   "gc-savings",
+			IBAN: savingsIBAN,
+			Booked: []fakegocardless.Transaction{
+				// The savings bank books the transfer a day later
+				{TransactionID: "s1", BookingDate: day(2), ValueDate: day(2), TransactionAmount: eur("100.00"), DebtorAccount: &fakegocardless.AccountReference{IBAN: savingsIBAN[:4] + " " + checkingIBAN[4:]}},
+			},
+		})
+
+		// Whichever job runs first creates the transfer and the other one links its side to it
+		for range 2 {
+			report := e.sync(t)
+			assert.NoError(t, report.Err())
+		}
+
+		checking := e.transactions("checking")
+		savings := e.transactions("savings")
+		if assert.Len(t, checking, 2) && assert.Len(t, savings, 1) {
+			transfer := checking[0]
+			if checking[0].TransferAccountID == nil {
+				transfer = checking[1]
+			}
+			if assert.NotNil(t, transfer.TransferAccountID) {
+				assert.Equal(t, "savings", *transfer.TransferAccountID)
+				assert.Equal(t, int64(-100000), transfer.Amount)
+			}
+			if assert.NotNil(t, savings[0].TransferAccountID) {
+				assert.Equal(t, "checking", *savings[0].TransferAccountID)
+			}
+			assert.Equal(t, "cleared", savings[0].Cleared)
+		}
+	})
+
+	t.Run("reports jobs that fail", func(t *testing.T) {
+		e := newE2E(t)
+		e.gc.AddAccount(fakegocardless.Account{
+			ID: "gc-checking",
+			Booked: []fakegocardless.Transaction{
+				{TransactionID: "c1", BookingDate: day(1), ValueDate: day(1), TransactionAmount: eur("-1.00"), CreditorName: "Shop"},
+			},
+			DailyLimit: 1,
+		})
+		e.gc.AddAccount(fakegocardless.Account{ID: "gc-savings", Status: "EXPIRED"})
+
+		report := e.sync(t)
+		if assert.Len(t, report.Failed(), 1) {
+			assert.Equal(t, "savings", report.Failed()[0].Job)
+		}
+		assert.Len(t, e.transactions("checking"), 1)
+
+		// The quota of the checking account is used up, so its job is skipped without a request
+		e.jobs = e.jobs[:1]
+		requests := countRequests(e.gc.Requests(), "GET "+fakegocardless.APIPath+"/accounts/gc-checking/transactions/")
+		report = e.sync(t)
+		var rateLimitErr *gocardless.RateLimitError
+		if assert.Len(t, report.Failed(), 1) {
+			assert.ErrorAs(t, report.Failed()[0].Err, &rateLimitErr)
+		}
+		assert.Equal(t, requests, countRequests(e.gc.Requests(), "GET "+fakegocardless.APIPath+"/accounts/gc-checking/transactions/"))
+	})
+}
+
+// countRequests returns how many of requests start with prefix
+func countRequests(requests []string, prefix string) int {
+	count := 0
+	for _, request := range requests {
+		if strings.HasPrefix(request, prefix) {
+			count++
+		}
+	}
+	return count
+}
@@ -1,262 +1,72 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
 	"log/slog"
-	"net/http"
-	"net/url"
-	"strconv"
+	"strings"
 	"time"
 
-	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/pkg/errors"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
 )
 
 type goCardlesser interface {
 	LogIn(ctx context.Context) error
 	RefreshToken(ctx context.Context) error
 	ListTransactions(ctx context.Context, accountID string, from, to time.Time) ([]Transaction, error)
-}
-
-type GoCardless struct {
-	SecretID     string
-	SecretKey    string
-	accessToken  string
-	refreshToken string
-	httpClient   *http.Client
-	resetIn      time.Duration
-}
-
-func NewGoCardless(secretID, secretKey string) GoCardless {
-	httpClient := http.DefaultClient
-	httpClient.Timeout = 20 * time.Second
-
-	return GoCardless{
-		SecretID:   secretID,
-		SecretKey:  secretKey,
-		httpClient: httpClient,
-	}
-}
-
-type loginRequest struct {
-	SecretID  string `json:"secret_id"`
-	SecretKey string `json:"secret_key"`
-}
-
-type loginResponse struct {
-	Access  string `json:"access"`
-	Refresh string `json:"refresh"`
-}
-
-func (gc *GoCardless) LogIn(ctx context.Context) error {
-	txn := newrelic.FromContext(ctx)
-	seg := txn.StartSegment("goCardlessLogIn")
-	defer seg.End()
-
-	l := slog.Default()
-	requestBody := loginRequest{SecretID: gc.SecretID, SecretKey: gc.SecretKey}
-	requestBodyJSON, err := json.Marshal(requestBody)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal request body")
-	}
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://bankaccountdata.gocardless.com/api/v2/token/new/", bytes.NewReader(requestBodyJSON))
-	if err != nil {
-		return errors.Wrap(err, "failed to create request")
-	}
-	request.Header.Add("Accept", "application/json")
-	request.Header.Add("Content-Type", "application/json")
-
-	response, err := gc.httpClient.Do(request)
-	if err != nil {
-		return errors.Wrap(err, "failed to make request")
-	}
-
-	seg.AddAttribute("responseStatusCode", response.StatusCode)
-
-	if response.StatusCode != 200 {
-		return errors.Errorf("failed to login: %s", response.Status)
-	}
-
-	parsedResponse := loginResponse{}
-	if err := json.NewDecoder(response.Body).Decode(&parsedResponse); err != nil {
-		return errors.Wrap(err, "failed to parse response")
-	}
-
-	l.InfoContext(ctx, "logged in")
-
-	gc.accessToken = parsedResponse.Access
-	gc.refreshToken = parsedResponse.Refresh
-
-	return nil
-}
-
-type refreshTokenRequest struct {
-	RefreshToken string `json:"refresh"`
-}
-
-type refreshTokenResponse struct {
-	AccessToken string `json:"access"`
-}
-
-func (gc *GoCardless) RefreshToken(ctx context.Context) error {
-	l := slog.Default()
-	requestBody := refreshTokenRequest{RefreshToken: gc.refreshToken}
-	requestBodyJSON, err := json.Marshal(requestBody)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal request body")
-	}
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://bankaccountdata.gocardless.com/api/v2/token/refresh/", bytes.NewReader(requestBodyJSON))
-	if err != nil {
-		return errors.Wrap(err, "failed to create request")
-	}
-	request.Header.Add("Accept", "application/json")
-	request.Header.Add("Content-Type", "application/json")
-
-	response, err := gc.httpClient.Do(request)
-	if err != nil {
-		return errors.Wrap(err, "failed to make request")
-	}
-
-	if response.StatusCode != 200 {
-		return errors.Errorf("failed to refresh token: %s", response.Status)
-	}
-
-	parsedResponse := refreshTokenResponse{}
-	if err := json.NewDecoder(response.Body).Decode(&parsedResponse); err != nil {
-		return errors.Wrap(err, "failed to parse response")
-	}
-
-	l.InfoContext(ctx, "got new access token", "accessToken", parsedResponse.AccessToken)
-
-	gc.accessToken = parsedResponse.AccessToken
-	return nil
-}
-
-type goCardlessListTransactionResponse struct {
-	Transactions struct {
-		Booked  []goCardlessListTransactionResponseTransaction `json:"booked"`
-		Pending []goCardlessListTransactionResponseTransaction `json:"pending"`
-	} `json:"transactions"`
-	LastUpdated time.Time `json:"last_updated"`
-}
-
-type goCardlessListTransactionResponseTransaction struct {
-	TransactionId     string `json:"transactionId"`
-	BookingDate       string `json:"bookingDate"`
-	ValueDate         string `json:"valueDate"`
-	TransactionAmount struct {
-		Amount   string `json:"amount"`
-		Currency string `json:"currency"`
-	} `json:"transactionAmount"`
-	RemittanceInformationUnstructured string `json:"remittanceInformationUnstructured"`
-	InternalTransactionId             string `json:"internalTransactionId"`
-	DebtorName                        string `json:"debtorName"`
-	CreditorName                      string `json:"creditorName"`
-	AdditionalInformation             string `json:"additionalInformation"`
+	GetAccount(ctx context.Context, accountID string) (gocardless.Account, error)
 }
 
 type Transaction struct {
-	ID         string
-	Date       time.Time
-	AmountMili int64
-	Memo       string
-	Name       string
-}
-
-func (gc *GoCardless) ListTransactions(ctx context.Context, accountID string, from, to time.Time) ([]Transaction, error) {
-	txn := newrelic.FromContext(ctx)
-	seg := txn.StartSegment("listTransactions")
-	defer seg.End()
-
-	seg.AddAttribute("accountID", accountID)
-	seg.AddAttribute("from", from)
-	seg.AddAttribute("to", to)
-
-	l := slog.Default().With("accountID", accountID, "from", from, "to", to)
-	if gc.resetIn > 0 {
-		l.Info("sleeping for reset in", "reset_in", gc.resetIn)
-		return nil, nil
-	}
-
-	u := fmt.Sprintf("https://bankaccountdata.gocardless.com/api/v2/accounts/%s/transactions/", accountID)
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to create request: GET %s", u)
-	}
-
-	request.Header.Add("Authorization", "Bearer "+gc.accessToken)
-	request.Header.Add("Accept", "application/json")
-
-	queryParams := url.Values{}
-	queryParams.Add("date_from", from.Format("2006-01-02"))
-	queryParams.Add("date_to", to.Format("2006-01-02"))
-	request.URL.RawQuery = queryParams.Encode()
-
-	response, err := gc.httpClient.Do(request)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to make request: GET %s", u)
-	}
-
-	if response.StatusCode == http.StatusTooManyRequests {
-		resetIn := time.Duration(0)
-		accountReset := response.Header[http.CanonicalHeaderKey("http_x_ratelimit_account_success_reset")]
-		if len(accountReset) > 0 && accountReset[0] != "0" {
-			resetIn, err = time.ParseDuration(accountReset[0] + "s")
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to parse rate limit reset header: %s", response.Header[http.CanonicalHeaderKey("http_x_ratelimit_account_success_reset")][0])
-			}
-		}
-
-		l.WarnContext(
-			ctx,
-			"too many requests",
-			"status",
-			response.Status,
-			"http_x_ratelimit_limit",
-			response.Header[http.CanonicalHeaderKey("http_x_ratelimit_limit")],
-			http.CanonicalHeaderKey("http_x_ratelimit_remaining"),
-			response.Header[http.CanonicalHeaderKey("http_x_ratelimit_remaining")],
-			http.CanonicalHeaderKey("http_x_ratelimit_reset"),
-			response.Header[http.CanonicalHeaderKey("http_x_ratelimit_reset")],
-			http.CanonicalHeaderKey("http_x_ratelimit_account_success_limit"),
-			response.Header[http.CanonicalHeaderKey("http_x_ratelimit_account_success_limit")],
-			http.CanonicalHeaderKey("http_x_ratelimit_account_success_remaining"),
-			response.Header[http.CanonicalHeaderKey("http_x_ratelimit_account_success_remaining")],
-			http.CanonicalHeaderKey("http_x_ratelimit_account_success_reset"),
-			response.Header[http.CanonicalHeaderKey("http_x_ratelimit_account_success_reset")],
-			"reset_in",
-			resetIn,
-		)
-		return nil, errors.Errorf("too many requests: %s", response.Status)
-	}
-
-	if response.StatusCode != 200 {
-		l.WarnContext(ctx, "failed to list transactions", "status", response.Status, "headers", response.Header)
-		return nil, errors.Errorf("failed to list transactions: %s", response.Status)
-	}
-
-	seg.AddAttribute("responseStatusCode", response.StatusCode)
-
-	parsedResponse := goCardlessListTransactionResponse{}
-	if err := json.NewDecoder(response.Body).Decode(&parsedResponse); err != nil {
-		return nil, errors.Wrapf(err, "failed to parse response: GET %s", u)
-	}
-
-	transactions := toTransactions(parsedResponse)
-	seg.AddAttribute("transactionsCount", len(transactions))
-	l.InfoContext(ctx, "got transactions", "count", len(transactions))
-
-	return transactions, nil
-}
-
-func toTransactions(response goCardlessListTransactionResponse) []Transaction {
+	ID string
+	// Date is the date of the transaction in YNAB, picked from BookingDate and ValueDate by the job's dateStrategy
+	Date time.Time
+	// BookingDate and ValueDate are the dates the bank gave, zero when it didn't give one
+	BookingDate time.Time
+	ValueDate   time.Time
+	AmountMili  int64
+	// Currency is the ISO 4217 code of the amount
+	Currency string
+	Memo     string
+	Name     string
+	// Pending is set for transactions the bank hasn't booked yet, they may still change or disappear
+	Pending bool
+
+	// The details below are empty when the bank doesn't give them
+	CreditorName string
+	CreditorIBAN string
+	DebtorName   string
+	DebtorIBAN   string
+	// RemittanceInformation are the lines of the unstructured remittance information
+	RemittanceInformation           []string
+	RemittanceInformationStructured string
+	AdditionalInformation           string
+	EntryReference                  string
+	CheckID                         string
+	MerchantCategoryCode            string
+	BankTransactionCode             string
+	ProprietaryBankTransactionCode  string
+	CurrencyExchange                []gocardless.CurrencyExchange
+	// BalanceAfterMili is the balance of the account after the transaction in milliunits, nil when not given
+	BalanceAfterMili *int64
+
+	// Category is the name of the YNAB category a rule assigned, CategoryID the ID it resolved to in the job's budget
+	Category   string
+	CategoryID string
+	// Approved is set when the rule that assigned the category approves the transactions it matches
+	Approved bool
+
+	// Source is the transaction as GoCardless gave it, memo templates are rendered from it
+	Source *gocardless.Transaction
+}
+
+// toTransactions converts the booked and pending transactions of an account, skipping the ones that can't be parsed
+func toTransactions(response gocardless.Transactions) []Transaction {
 	l := slog.Default()
 
 	var transactions []Transaction
-	for _, transaction := range response.Transactions.Booked {
+	for _, transaction := range response.Booked {
 		t, err := toTransaction(transaction)
 		if err != nil {
 			l.Warn("failed to parse transaction", "error", err)
@@ -266,63 +76,159 @@ func toTransactions(response goCardlessListTransactionResponse) []Transaction {
 		transactions = append(transactions, t)
 	}
 
-	for _, transaction := range response.Transactions.Pending {
+	for _, transaction := range response.Pending {
 		t, err := toTransaction(transaction)
 		if err != nil {
 			l.Warn("failed to parse transaction", "error", err)
 			continue
 		}
 
+		t.Pending = true
 		transactions = append(transactions, t)
 	}
 
 	return transactions
 }
 
-func toTransaction(goCardlessTransaction goCardlessListTransactionResponseTransaction) (Transaction, error) {
+func toTransaction(goCardlessTransaction gocardless.Transaction) (Transaction, error) {
 	l := slog.Default()
-	valueDate, err := time.Parse("2006-01-02", goCardlessTransaction.ValueDate)
+	bookingDate, err := parseDate(goCardlessTransaction.BookingDate, goCardlessTransaction.BookingDateTime)
+	if err != nil {
+		l.Warn("failed to parse booking date", "bookingDate", goCardlessTransaction.BookingDate, "bookingDateTime", goCardlessTransaction.BookingDateTime, "error", err)
+		return Transaction{}, errors.Wrap(err, "failed to parse booking date")
+	}
+	valueDate, err := parseDate(goCardlessTransaction.ValueDate, goCardlessTransaction.ValueDateTime)
 	if err != nil {
-		l.Warn("failed to parse value date", "valueDate", goCardlessTransaction.ValueDate, "error", err)
-		return Transaction{}, errors.Wrapf(err, "failed to parse value date: %s", goCardlessTransaction.ValueDate)
+		l.Warn("failed to parse value date", "valueDate", goCardlessTransaction.ValueDate, "valueDateTime", goCardlessTransaction.ValueDateTime, "error", err)
+		return Transaction{}, errors.Wrap(err, "failed to parse value date")
+	}
+	if bookingDate.IsZero() && valueDate.IsZero() {
+		l.Warn("transaction without a date", "id", toID(goCardlessTransaction), "amount", goCardlessTransaction.TransactionAmount.Amount)
+		return Transaction{}, errors.New("transaction has neither a booking date nor a value date")
 	}
 
-	amount, err := strconv.ParseFloat(goCardlessTransaction.TransactionAmount.Amount, 64)
+	amount, err := parseMilliunits(goCardlessTransaction.TransactionAmount.Amount, goCardlessTransaction.TransactionAmount.Currency)
 	if err != nil {
-		l.Warn("failed to parse amount", "amount", goCardlessTransaction.TransactionAmount.Amount, "error", err)
-		return Transaction{}, errors.Wrapf(err, "failed to parse amount: %s", goCardlessTransaction.TransactionAmount.Amount)
+		l.Warn("failed to parse amount", "amount", goCardlessTransaction.TransactionAmount.Amount, "currency", goCardlessTransaction.TransactionAmount.Currency, "error", err)
+		return Transaction{}, errors.Wrap(err, "failed to parse amount")
 	}
 
+	remittanceInformation := toRemittanceInformation(goCardlessTransaction)
 	transaction := Transaction{
-		ID:         toID(goCardlessTransaction),
-		Date:       valueDate,
-		AmountMili: int64(amount * 1000),
-		Memo:       goCardlessTransaction.RemittanceInformationUnstructured,
-		Name:       toName(goCardlessTransaction),
+		ID:                              toID(goCardlessTransaction),
+		BookingDate:                     bookingDate,
+		ValueDate:                       valueDate,
+		AmountMili:                      amount,
+		Currency:                        goCardlessTransaction.TransactionAmount.Currency,
+		Memo:                            strings.Join(remittanceInformation, " "),
+		Name:                            toName(goCardlessTransaction, amount),
+		CreditorName:                    goCardlessTransaction.CreditorName,
+		CreditorIBAN:                    goCardlessTransaction.CreditorAccount.IBAN,
+		DebtorName:                      goCardlessTransaction.DebtorName,
+		DebtorIBAN:                      goCardlessTransaction.DebtorAccount.IBAN,
+		RemittanceInformation:           remittanceInformation,
+		RemittanceInformationStructured: goCardlessTransaction.RemittanceInformationStructured,
+		AdditionalInformation:           goCardlessTransaction.AdditionalInformation,
+		EntryReference:                  goCardlessTransaction.EntryReference,
+		CheckID:                         goCardlessTransaction.CheckID,
+		MerchantCategoryCode:            goCardlessTransaction.MerchantCategoryCode,
+		BankTransactionCode:             goCardlessTransaction.BankTransactionCode,
+		ProprietaryBankTransactionCode:  goCardlessTransaction.ProprietaryBankTransactionCode,
+		CurrencyExchange:                goCardlessTransaction.CurrencyExchange,
+		Source:                          &goCardlessTransaction,
+	}
+	if balance := goCardlessTransaction.BalanceAfterTransaction; balance != nil {
+		// the balance is informational only, a transaction isn't skipped because of it
+		balanceAfter, err := parseMilliunits(balance.BalanceAmount.Amount, balance.BalanceAmount.Currency)
+		if err != nil {
+			l.Warn("failed to parse balance after transaction", "amount", balance.BalanceAmount.Amount, "currency", balance.BalanceAmount.Currency, "error", err)
+		} else {
+			transaction.BalanceAfterMili = &balanceAfter
+		}
 	}
+	transaction.Date = transaction.dateFor(dateValue)
 
-	l.Info("gocardless transaction", "date", goCardlessTransaction.ValueDate, "amount", goCardlessTransaction.TransactionAmount.Amount, "memo", goCardlessTransaction.RemittanceInformationUnstructured, "name", toName(goCardlessTransaction), "debtor_name", goCardlessTransaction.DebtorName, "creditor_name", goCardlessTransaction.CreditorName, "additional_information", goCardlessTransaction.AdditionalInformation)
+	l.Info("gocardless transaction", "booking_date", bookingDate.Format(time.DateOnly), "value_date", valueDate.Format(time.DateOnly), "amount", goCardlessTransaction.TransactionAmount.Amount, "memo", transaction.Memo, "name", transaction.Name, "debtor_name", goCardlessTransaction.DebtorName, "creditor_name", goCardlessTransaction.CreditorName, "additional_information", goCardlessTransaction.AdditionalInformation)
 
 	return transaction, nil
 }
 
-func toID(transaction goCardlessListTransactionResponseTransaction) string {
-	if transaction.TransactionId != "" {
-		return transaction.TransactionId
+// parseDate parses a transaction date, taken from the date time when the date is missing. The date of a date time
+// is the day in the time zone the bank gave. It returns the zero time when both are empty.
+func parseDate(date, dateTime string) (time.Time, error) {
+	if date == "" && len(dateTime) >= len(time.DateOnly) {
+		date = dateTime[:len(time.DateOnly)]
+	}
+	if date == "" {
+		return time.Time{}, nil
 	}
-	return transaction.InternalTransactionId
-}
 
-func toName(transaction goCardlessListTransactionResponseTransaction) string {
-	amountParsed, err := strconv.ParseFloat(transaction.TransactionAmount.Amount, 64)
-	if err == nil {
-		if amountParsed >= 0 && transaction.DebtorName != "" {
-			return transaction.DebtorName
+	parsed, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid date: %s", date)
+	}
+	return parsed, nil
+}
+
+// dateFor returns the date of the transaction according to strategy, falling back to the date the bank gave when
+// it didn't give the preferred one, and to Date when it gave neither
+func (t Transaction) dateFor(strategy dateStrategy) time.Time {
+	booking, value := t.BookingDate, t.ValueDate
+	switch {
+	case booking.IsZero() && value.IsZero():
+		return t.Date
+	case booking.IsZero():
+		return value
+	case value.IsZero():
+		return booking
+	}
+
+	switch strategy {
+	case dateBooking:
+		return booking
+	case dateEarliest:
+		if booking.Before(value) {
+			return booking
 		}
-		if amountParsed < 0 && transaction.CreditorName != "" {
-			return transaction.CreditorName
+		return value
+	case dateLatest:
+		if booking.After(value) {
+			return booking
 		}
+		return value
+	default:
+		return value
+	}
+}
+
+func toID(transaction gocardless.Transaction) string {
+	if transaction.TransactionID != "" {
+		return transaction.TransactionID
+	}
+	return transaction.InternalTransactionID
+}
+
+// toRemittanceInformation returns the lines of the unstructured remittance information, banks give either a single
+// line or a list of them
+func toRemittanceInformation(transaction gocardless.Transaction) []string {
+	if len(transaction.RemittanceInformationUnstructuredArray) > 0 {
+		return transaction.RemittanceInformationUnstructuredArray
+	}
+	if transaction.RemittanceInformationUnstructured != "" {
+		return []string{transaction.RemittanceInformationUnstructured}
+	}
+	return nil
+}
+
+// toName returns the other party of a transaction of amount milliunits: the debtor of money coming in,
+// the creditor of money going out, or the remittance information when the bank didn't name them
+func toName(transaction gocardless.Transaction, amount int64) string {
+	if amount >= 0 && transaction.DebtorName != "" {
+		return transaction.DebtorName
+	}
+	if amount < 0 && transaction.CreditorName != "" {
+		return transaction.CreditorName
 	}
 
-	return transaction.RemittanceInformationUnstructured
+	return strings.Join(toRemittanceInformation(transaction), " ")
 }
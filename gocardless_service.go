@@ -3,31 +3,51 @@ package main
 import (
 	"context"
 	"time"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+	"psmarcin.github.com/open-ynab-sync/internal/retry"
 )
 
-// GoCardlessServicer implementation using the existing GoCardless struct
+// GoCardlessServicer implementation using the shared GoCardless client
 type GoCardlessService struct {
-	gc GoCardless
+	client *gocardless.Client
 }
 
-// NewGoCardlessService creates a new GoCardlessServicer
-func NewGoCardlessService(secretID, secretKey string) GoCardlessServicer {
+// NewGoCardlessService creates a new GoCardlessServicer talking to the API at baseURL, keeping the quota of accounts
+// in rateLimits and retrying failed requests according to retryPolicy
+func NewGoCardlessService(secretID, secretKey, baseURL string, rateLimits gocardless.RateLimitStorer, retryPolicy retry.Policy) GoCardlessServicer {
 	return &GoCardlessService{
-		gc: NewGoCardless(secretID, secretKey),
+		client: gocardless.New(gocardless.Config{
+			SecretID:   secretID,
+			SecretKey:  secretKey,
+			BaseURL:    baseURL,
+			Retry:      retryPolicy,
+			RateLimits: rateLimits,
+		}),
 	}
 }
 
 // LogIn logs in to the GoCardless API
 func (s *GoCardlessService) LogIn(ctx context.Context) error {
-	return s.gc.LogIn(ctx)
+	return s.client.LogIn(ctx)
 }
 
 // RefreshToken refreshes the GoCardless API token
 func (s *GoCardlessService) RefreshToken(ctx context.Context) error {
-	return s.gc.RefreshToken(ctx)
+	return s.client.RefreshToken(ctx)
 }
 
 // ListTransactions lists transactions from the GoCardless API
 func (s *GoCardlessService) ListTransactions(ctx context.Context, accountID string, from, to time.Time) ([]Transaction, error) {
-	return s.gc.ListTransactions(ctx, accountID, from, to)
+	transactions, err := s.client.ListTransactions(ctx, accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return toTransactions(transactions), nil
+}
+
+// GetAccount gets the metadata of a linked account from the GoCardless API
+func (s *GoCardlessService) GetAccount(ctx context.Context, accountID string) (gocardless.Account, error) {
+	return s.client.GetAccount(ctx, accountID)
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"psmarcin.github.com/open-ynab-sync/internal/fakegocardless"
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+	"psmarcin.github.com/open-ynab-sync/internal/retry"
+)
+
+func TestToTransactions(t *testing.T) {
+	response := gocardless.Transactions{
+		Booked: []gocardless.Transaction{
+			{TransactionID: "t1", ValueDate: "2024-04-01", TransactionAmount: gocardless.Amount{Amount: "-8.12", Currency: "EUR"}, CreditorName: "Shop", DebtorName: "Me", RemittanceInformationUnstructured: "Groceries"},
+			{InternalTransactionID: "i2", ValueDate: "2024-04-02", TransactionAmount: gocardless.Amount{Amount: "100.00", Currency: "EUR"}, RemittanceInformationUnstructured: "Refund"},
+			{TransactionID: "broken", ValueDate: "yesterday", TransactionAmount: gocardless.Amount{Amount: "1.00", Currency: "EUR"}},
+			{TransactionID: "comma", ValueDate: "2024-04-02", TransactionAmount: gocardless.Amount{Amount: "1,00", Currency: "EUR"}},
+			{TransactionID: "no-currency", ValueDate: "2024-04-02", TransactionAmount: gocardless.Amount{Amount: "1.00"}},
+		},
+		Pending: []gocardless.Transaction{
+			{ValueDate: "2024-04-03", TransactionAmount: gocardless.Amount{Amount: "5.00", Currency: "EUR"}, DebtorName: "Friend"},
+		},
+	}
+	transactions := toTransactions(response)
+
+	april := func(day int) time.Time { return time.Date(2024, 4, day, 0, 0, 0, 0, time.UTC) }
+	assert.Equal(t, []Transaction{
+		{ID: "t1", Date: april(1), ValueDate: april(1), AmountMili: -8120, Currency: "EUR", Memo: "Groceries", Name: "Shop", CreditorName: "Shop", DebtorName: "Me", RemittanceInformation: []string{"Groceries"}, Source: &response.Booked[0]},
+		{ID: "i2", Date: april(2), ValueDate: april(2), AmountMili: 100000, Currency: "EUR", Memo: "Refund", Name: "Refund", RemittanceInformation: []string{"Refund"}, Source: &response.Booked[1]},
+		{Date: april(3), ValueDate: april(3), AmountMili: 5000, Currency: "EUR", Name: "Friend", Pending: true, DebtorName: "Friend", Source: &response.Pending[0]},
+	}, transactions)
+}
+
+func TestToTransactionDetails(t *testing.T) {
+	transaction, err := toTransaction(gocardless.Transaction{
+		TransactionID:                          "t1",
+		EntryReference:                         "entry",
+		CheckID:                                "check",
+		BookingDate:                            "2024-04-01",
+		TransactionAmount:                      gocardless.Amount{Amount: "-20.00", Currency: "EUR"},
+		CurrencyExchange:                       gocardless.CurrencyExchanges{{SourceCurrency: "USD", TargetCurrency: "EUR", ExchangeRate: "0.92"}},
+		CreditorName:                           "Shop",
+		CreditorAccount:                        gocardless.AccountReference{IBAN: "DE89370400440532013000"},
+		DebtorAccount:                          gocardless.AccountReference{IBAN: "GB33BUKB20201555555555"},
+		RemittanceInformationUnstructuredArray: []string{"Invoice 7", "Thank you"},
+		RemittanceInformationStructured:        "RF18539007547034",
+		MerchantCategoryCode:                   "5411",
+		BankTransactionCode:                    "PMNT-ICDT-STDO",
+		ProprietaryBankTransactionCode:         "TRANSFER",
+		BalanceAfterTransaction:                &gocardless.Balance{BalanceAmount: gocardless.Amount{Amount: "980.50", Currency: "EUR"}, BalanceType: "closingBooked"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Invoice 7 Thank you", transaction.Memo)
+	assert.Equal(t, []string{"Invoice 7", "Thank you"}, transaction.RemittanceInformation)
+	assert.Equal(t, "RF18539007547034", transaction.RemittanceInformationStructured)
+	assert.Equal(t, "DE89370400440532013000", transaction.CreditorIBAN)
+	assert.Equal(t, "GB33BUKB20201555555555", transaction.DebtorIBAN)
+	assert.Equal(t, "entry", transaction.EntryReference)
+	assert.Equal(t, "check", transaction.CheckID)
+	assert.Equal(t, "5411", transaction.MerchantCategoryCode)
+	assert.Equal(t, "PMNT-ICDT-STDO", transaction.BankTransactionCode)
+	assert.Equal(t, "TRANSFER", transaction.ProprietaryBankTransactionCode)
+	assert.Equal(t, []gocardless.CurrencyExchange{{SourceCurrency: "USD", TargetCurrency: "EUR", ExchangeRate: "0.92"}}, transaction.CurrencyExchange)
+	if assert.NotNil(t, transaction.BalanceAfterMili) {
+		assert.Equal(t, int64(980500), *transaction.BalanceAfterMili)
+	}
+
+	t.Run("name from remittance information lines", func(t *testing.T) {
+		transaction, err := toTransaction(gocardless.Transaction{BookingDate: "2024-04-01", TransactionAmount: gocardless.Amount{Amount: "-1.00", Currency: "EUR"}, RemittanceInformationUnstructuredArray: []string{"Card", "Bakery"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "Card Bakery", transaction.Name)
+	})
+
+	t.Run("invalid balance keeps the transaction", func(t *testing.T) {
+		transaction, err := toTransaction(gocardless.Transaction{BookingDate: "2024-04-01", TransactionAmount: gocardless.Amount{Amount: "-1.00", Currency: "EUR"}, BalanceAfterTransaction: &gocardless.Balance{BalanceAmount: gocardless.Amount{Amount: "n/a", Currency: "EUR"}}})
+		assert.NoError(t, err)
+		assert.Nil(t, transaction.BalanceAfterMili)
+	})
+}
+
+func TestToTransactionDates(t *testing.T) {
+	april := func(day int) time.Time { return time.Date(2024, 4, day, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("booking date only", func(t *testing.T) {
+		transaction, err := toTransaction(gocardless.Transaction{TransactionID: "t1", BookingDate: "2024-04-01", TransactionAmount: gocardless.Amount{Amount: "1.00", Currency: "EUR"}})
+		assert.NoError(t, err)
+		assert.Equal(t, april(1), transaction.Date)
+		assert.True(t, transaction.ValueDate.IsZero())
+	})
+
+	t.Run("date times", func(t *testing.T) {
+		transaction, err := toTransaction(gocardless.Transaction{TransactionID: "t1", BookingDateTime: "2024-04-01T23:30:00+02:00", ValueDateTime: "2024-04-02T00:10:00+02:00", TransactionAmount: gocardless.Amount{Amount: "1.00", Currency: "EUR"}})
+		assert.NoError(t, err)
+		assert.Equal(t, april(1), transaction.BookingDate)
+		assert.Equal(t, april(2), transaction.ValueDate)
+	})
+
+	t.Run("no dates", func(t *testing.T) {
+		_, err := toTransaction(gocardless.Transaction{TransactionID: "t1", TransactionAmount: gocardless.Amount{Amount: "1.00", Currency: "EUR"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestTransactionDateFor(t *testing.T) {
+	april := func(day int) time.Time { return time.Date(2024, 4, day, 0, 0, 0, 0, time.UTC) }
+	both := Transaction{BookingDate: april(3), ValueDate: april(1)}
+
+	tests := []struct {
+		name        string
+		transaction Transaction
+		strategy    dateStrategy
+		want        time.Time
+	}{
+		{"value", both, dateValue, april(1)},
+		{"booking", both, dateBooking, april(3)},
+		{"earliest", both, dateEarliest, april(1)},
+		{"latest", both, dateLatest, april(3)},
+		{"default", both, "", april(1)},
+		{"booking falls back to value", Transaction{ValueDate: april(1)}, dateBooking, april(1)},
+		{"value falls back to booking", Transaction{BookingDate: april(3)}, dateValue, april(3)},
+		{"neither keeps date", Transaction{Date: april(5)}, dateEarliest, april(5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.transaction.dateFor(tt.strategy))
+		})
+	}
+}
+
+func TestGoCardlessService(t *testing.T) {
+	now := time.Now().UTC()
+	fake := fakegocardless.New("id", "secret")
+	fake.AddAccount(fakegocardless.Account{
+		ID: "aaa",
+		Booked: []fakegocardless.Transaction{
+			{TransactionID: "t1", BookingDate: now.Format(time.DateOnly), ValueDate: now.Format(time.DateOnly), TransactionAmount: fakegocardless.Amount{Amount: "-12.34", Currency: "EUR"}, CreditorName: "Shop"},
+		},
+	})
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	service := NewGoCardlessService("id", "secret", server.URL+fakegocardless.APIPath, nil, retry.Policy{MaxAttempts: 1})
+	ctx := context.Background()
+
+	transactions, err := service.ListTransactions(ctx, "aaa", now.AddDate(0, 0, -1), now)
+	assert.NoError(t, err)
+	if assert.Len(t, transactions, 1) {
+		assert.Equal(t, "t1", transactions[0].ID)
+		assert.Equal(t, int64(-12340), transactions[0].AmountMili)
+	}
+
+	account, err := service.GetAccount(ctx, "aaa")
+	assert.NoError(t, err)
+	assert.Equal(t, gocardless.AccountStatusReady, account.Status)
+}
@@ -4,8 +4,13 @@ import (
 	"context"
 	"time"
 
+	"github.com/brunomvsouza/ynab.go/api/account"
+	"github.com/brunomvsouza/ynab.go/api/budget"
+	"github.com/brunomvsouza/ynab.go/api/category"
 	"github.com/brunomvsouza/ynab.go/api/transaction"
 	"github.com/newrelic/go-agent/v3/newrelic"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
 )
 
 // GoCardlessServicer defines the interface for interacting with the GoCardless API
@@ -13,17 +18,36 @@ type GoCardlessServicer interface {
 	LogIn(ctx context.Context) error
 	RefreshToken(ctx context.Context) error
 	ListTransactions(ctx context.Context, accountID string, from time.Time, to time.Time) ([]Transaction, error)
+	GetAccount(ctx context.Context, accountID string) (gocardless.Account, error)
 }
 
 // YNABServicer defines the interface for interacting with the YNAB API
 type YNABServicer interface {
 	CreateTransactions(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error)
+	UpdateTransactions(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error)
+	DeleteTransaction(budgetID, transactionID string) (*transaction.Transaction, error)
+	GetTransactionsByAccount(budgetID, accountID string, f *transaction.Filter) ([]*transaction.Transaction, error)
+	GetBudgets() ([]*budget.Summary, error)
+	GetBudgetSettings(budgetID string) (*budget.Settings, error)
+	GetAccounts(budgetID string) ([]*account.Account, error)
+	GetCategories(budgetID string) ([]*category.GroupWithCategories, error)
+	GetTransferPayeeIDs(budgetID string) (map[string]string, error)
 }
 
 // SynchronizationServicer defines the interface for synchronizing transactions between GoCardless and YNAB
 type SynchronizationServicer interface {
-	SynchronizeTransactions(ctx context.Context) error
-	SynchronizeTransaction(ctx context.Context, j job) error
+	SynchronizeTransactions(ctx context.Context, jobs []job) (SyncReport, error)
+	SynchronizeTransaction(ctx context.Context, j job) (JobResult, error)
+	Backfill(ctx context.Context, j job, days int, chunkDays int) error
+	MigrateImportIDs(ctx context.Context, j job, from importIDStrategy, days int, dryRun bool) (ImportIDMigration, error)
+	ListJobTransactions(ctx context.Context, j job, from, to time.Time) ([]Transaction, int, error)
+}
+
+// StateStorer defines the interface for persisting synchronization state between runs
+type StateStorer interface {
+	JobState(key string) (JobState, error)
+	SaveJobState(key string, state JobState) error
+	gocardless.RateLimitStorer
 }
 
 // MonitoringServicer defines the interface for monitoring and instrumentation
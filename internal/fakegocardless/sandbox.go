@@ -0,0 +1,91 @@
+package fakegocardless
+
+import (
+	"time"
+)
+
+// SandboxInstitutionID is the institution of the sandbox accounts, the same ID as GoCardless' own sandbox bank
+const SandboxInstitutionID = "SANDBOXFINANCE_SFIN0000"
+
+// AddSandboxAccounts adds SandboxInstitutionID and two of its accounts with a few weeks of transactions up to now,
+// to try the sync and link flows against in local development
+func (s *Server) AddSandboxAccounts(now time.Time, dailyLimit int) []Account {
+	s.AddInstitution(Institution{
+		ID:                   SandboxInstitutionID,
+		Name:                 "Sandbox Finance",
+		BIC:                  "SFIN0000",
+		TransactionTotalDays: "90",
+		Countries:            []string{"XX"},
+	})
+
+	day := func(daysAgo int) string {
+		return now.AddDate(0, 0, -daysAgo).Format(time.DateOnly)
+	}
+	booked := func(id string, daysAgo int, amount, name, memo string) Transaction {
+		t := Transaction{
+			TransactionID:                     id,
+			BookingDate:                       day(daysAgo),
+			ValueDate:                         day(daysAgo),
+			TransactionAmount:                 Amount{Amount: amount, Currency: "EUR"},
+			RemittanceInformationUnstructured: memo,
+		}
+		if amount[0] == '-' {
+			t.CreditorName = name
+		} else {
+			t.DebtorName = name
+		}
+		return t
+	}
+
+	accounts := []Account{
+		{
+			ID:            "00000000-0000-4000-a000-000000000001",
+			InstitutionID: SandboxInstitutionID,
+			IBAN:          "GL3343697694912188",
+			OwnerName:     "John Doe",
+			Name:          "Main Account",
+			Currency:      "EUR",
+			Booked: []Transaction{
+				booked("sandbox-checking-1", 21, "2500.00", "ACME Corp", "Salary"),
+				booked("sandbox-checking-2", 18, "-850.00", "Landlord Ltd", "Rent"),
+				booked("sandbox-checking-3", 12, "-64.37", "Fresh Market", "Groceries"),
+				booked("sandbox-checking-4", 6, "-12.99", "Streamflix", "Monthly subscription"),
+				booked("sandbox-checking-5", 2, "-41.20", "Fresh Market", "Groceries"),
+			},
+			Pending: []Transaction{
+				{
+					ValueDate:                         day(0),
+					TransactionAmount:                 Amount{Amount: "-4.50", Currency: "EUR"},
+					CreditorName:                      "Corner Cafe",
+					RemittanceInformationUnstructured: "Coffee",
+				},
+			},
+			Balances: []Balance{
+				{BalanceAmount: Amount{Amount: "1521.44", Currency: "EUR"}, BalanceType: "expected", ReferenceDate: day(0)},
+			},
+			DailyLimit: dailyLimit,
+		},
+		{
+			ID:            "00000000-0000-4000-a000-000000000002",
+			InstitutionID: SandboxInstitutionID,
+			IBAN:          "GL0865354374424724",
+			OwnerName:     "John Doe",
+			Name:          "Savings Account",
+			Currency:      "EUR",
+			Booked: []Transaction{
+				booked("sandbox-savings-1", 20, "500.00", "John Doe", "Monthly savings"),
+				booked("sandbox-savings-2", 1, "0.42", "Sandbox Finance", "Interest"),
+			},
+			Balances: []Balance{
+				{BalanceAmount: Amount{Amount: "500.42", Currency: "EUR"}, BalanceType: "expected", ReferenceDate: day(0)},
+			},
+			DailyLimit: dailyLimit,
+		},
+	}
+
+	for _, account := range accounts {
+		s.AddAccount(account)
+	}
+
+	return accounts
+}
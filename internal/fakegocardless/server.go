@@ -0,0 +1,729 @@
+// Package fakegocardless is an in-memory fake of the GoCardless Bank Account Data API. It serves the endpoints the
+// sync and link flows use, so both can run end-to-end in tests and local development without real credentials.
+package fakegocardless
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// APIPath is the path the API is served under, the base URL of a client is the server URL followed by it
+	APIPath = "/api/v2"
+	// AccessTokenTTL and RefreshTokenTTL are how long issued tokens are valid, the same as in the real API
+	AccessTokenTTL  = 24 * time.Hour
+	RefreshTokenTTL = 30 * 24 * time.Hour
+	// AccountStatusReady is the status of an account transactions can be fetched from
+	AccountStatusReady = "READY"
+	// RequisitionStatusCreated and RequisitionStatusLinked are the statuses of a requisition before and after
+	// the end user authorized it
+	RequisitionStatusCreated = "CR"
+	RequisitionStatusLinked  = "LN"
+)
+
+// Amount is an amount of money as the API formats it
+type Amount struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// AccountReference identifies the account of the other party of a transaction
+type AccountReference struct {
+	IBAN      string `json:"iban,omitempty"`
+	BBAN      string `json:"bban,omitempty"`
+	PAN       string `json:"pan,omitempty"`
+	MaskedPAN string `json:"maskedPan,omitempty"`
+	MSISDN    string `json:"msisdn,omitempty"`
+	Currency  string `json:"currency,omitempty"`
+}
+
+// CurrencyExchange is the exchange of a transaction made in another currency than the account's
+type CurrencyExchange struct {
+	SourceCurrency         string `json:"sourceCurrency,omitempty"`
+	ExchangeRate           string `json:"exchangeRate,omitempty"`
+	UnitCurrency           string `json:"unitCurrency,omitempty"`
+	TargetCurrency         string `json:"targetCurrency,omitempty"`
+	QuotationDate          string `json:"quotationDate,omitempty"`
+	ContractIdentification string `json:"contractIdentification,omitempty"`
+	// InstructedAmount is the amount in the currency the transaction was made in, given by some banks
+	InstructedAmount *Amount `json:"instructedAmount,omitempty"`
+}
+
+// Transaction is a booked or pending transaction of an account, empty fields are left out like banks do.
+// CurrencyExchange is served as a list, some banks serve a single object instead.
+type Transaction struct {
+	TransactionID                          string             `json:"transactionId,omitempty"`
+	InternalTransactionID                  string             `json:"internalTransactionId,omitempty"`
+	EntryReference                         string             `json:"entryReference,omitempty"`
+	EndToEndID                             string             `json:"endToEndId,omitempty"`
+	MandateID                              string             `json:"mandateId,omitempty"`
+	CheckID                                string             `json:"checkId,omitempty"`
+	CreditorID                             string             `json:"creditorId,omitempty"`
+	BookingDate                            string             `json:"bookingDate,omitempty"`
+	ValueDate                              string             `json:"valueDate,omitempty"`
+	BookingDateTime                        string             `json:"bookingDateTime,omitempty"`
+	ValueDateTime                          string             `json:"valueDateTime,omitempty"`
+	TransactionAmount                      Amount             `json:"transactionAmount"`
+	CurrencyExchange                       []CurrencyExchange `json:"currencyExchange,omitempty"`
+	CreditorName                           string             `json:"creditorName,omitempty"`
+	CreditorAccount                        *AccountReference  `json:"creditorAccount,omitempty"`
+	UltimateCreditor                       string             `json:"ultimateCreditor,omitempty"`
+	DebtorName                             string             `json:"debtorName,omitempty"`
+	DebtorAccount                          *AccountReference  `json:"debtorAccount,omitempty"`
+	UltimateDebtor                         string             `json:"ultimateDebtor,omitempty"`
+	RemittanceInformationUnstructured      string             `json:"remittanceInformationUnstructured,omitempty"`
+	RemittanceInformationUnstructuredArray []string           `json:"remittanceInformationUnstructuredArray,omitempty"`
+	RemittanceInformationStructured        string             `json:"remittanceInformationStructured,omitempty"`
+	RemittanceInformationStructuredArray   []string           `json:"remittanceInformationStructuredArray,omitempty"`
+	AdditionalInformation                  string             `json:"additionalInformation,omitempty"`
+	PurposeCode                            string             `json:"purposeCode,omitempty"`
+	BankTransactionCode                    string             `json:"bankTransactionCode,omitempty"`
+	ProprietaryBankTransactionCode         string             `json:"proprietaryBankTransactionCode,omitempty"`
+	MerchantCategoryCode                   string             `json:"merchantCategoryCode,omitempty"`
+	BalanceAfterTransaction                *Balance           `json:"balanceAfterTransaction,omitempty"`
+}
+
+// date returns the day the transaction is filtered by, its booking date or its value date when it isn't booked,
+// taken from the date times when the dates are missing
+func (t Transaction) date() string {
+	for _, date := range []string{t.BookingDate, t.BookingDateTime, t.ValueDate, t.ValueDateTime} {
+		if len(date) >= len(time.DateOnly) {
+			return date[:len(time.DateOnly)]
+		}
+	}
+	return ""
+}
+
+// Balance is a balance of an account
+type Balance struct {
+	BalanceAmount Amount `json:"balanceAmount"`
+	BalanceType   string `json:"balanceType"`
+	ReferenceDate string `json:"referenceDate,omitempty"`
+}
+
+// Institution is a bank end users can link accounts of
+type Institution struct {
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	BIC                  string   `json:"bic"`
+	TransactionTotalDays string   `json:"transaction_total_days"`
+	Countries            []string `json:"countries"`
+	Logo                 string   `json:"logo"`
+}
+
+// Account is a bank account served by the fake
+type Account struct {
+	ID string
+	// InstitutionID is the bank of the account, a requisition for the institution links all of its accounts
+	InstitutionID string
+	IBAN          string
+	OwnerName     string
+	// Name and Currency are part of the account's details
+	Name     string
+	Currency string
+	// Status is READY when the account's data can be fetched, it defaults to READY
+	Status   string
+	Booked   []Transaction
+	Pending  []Transaction
+	Balances []Balance
+	// DailyLimit is how many requests a day each of the account's data endpoints answers, unlimited when 0
+	DailyLimit int
+}
+
+// Agreement is an end user agreement created through the API
+type Agreement struct {
+	ID                 string    `json:"id"`
+	Created            time.Time `json:"created"`
+	InstitutionID      string    `json:"institution_id"`
+	MaxHistoricalDays  int       `json:"max_historical_days"`
+	AccessValidForDays int       `json:"access_valid_for_days"`
+	AccessScope        []string  `json:"access_scope"`
+}
+
+// Requisition is a requisition created through the API
+type Requisition struct {
+	ID            string    `json:"id"`
+	Created       time.Time `json:"created"`
+	Redirect      string    `json:"redirect"`
+	Status        string    `json:"status"`
+	InstitutionID string    `json:"institution_id"`
+	Agreement     string    `json:"agreement"`
+	Reference     string    `json:"reference"`
+	Accounts      []string  `json:"accounts"`
+	UserLanguage  string    `json:"user_language"`
+	Link          string    `json:"link"`
+}
+
+// quota is the usage of one data endpoint of an account on the current day
+type quota struct {
+	used    int
+	resetAt time.Time
+}
+
+// Server is the fake API, it's safe for concurrent use
+type Server struct {
+	mu        sync.Mutex
+	secretID  string
+	secretKey string
+	now       func() time.Time
+	mux       *http.ServeMux
+
+	accessTokens  map[string]time.Time
+	refreshTokens map[string]time.Time
+	institutions  map[string]Institution
+	accounts      map[string]*Account
+	quotas        map[string]*quota
+	agreements    map[string]Agreement
+	requisitions  map[string]*Requisition
+	// throttled is how many of the next API requests are rejected with the rate limit of all requests
+	throttled int
+	requests  []string
+	nextID    int
+}
+
+// New creates a fake accepting the given credentials
+func New(secretID, secretKey string) *Server {
+	s := &Server{
+		secretID:      secretID,
+		secretKey:     secretKey,
+		now:           time.Now,
+		mux:           http.NewServeMux(),
+		accessTokens:  make(map[string]time.Time),
+		refreshTokens: make(map[string]time.Time),
+		institutions:  make(map[string]Institution),
+		accounts:      make(map[string]*Account),
+		quotas:        make(map[string]*quota),
+		agreements:    make(map[string]Agreement),
+		requisitions:  make(map[string]*Requisition),
+	}
+
+	s.mux.HandleFunc("POST "+APIPath+"/token/new/{$}", s.handleNewToken)
+	s.mux.HandleFunc("POST "+APIPath+"/token/refresh/{$}", s.handleRefreshToken)
+	s.mux.HandleFunc("GET "+APIPath+"/institutions/{$}", s.authorized(s.handleListInstitutions))
+	s.mux.HandleFunc("GET "+APIPath+"/institutions/{id}/{$}", s.authorized(s.handleGetInstitution))
+	s.mux.HandleFunc("POST "+APIPath+"/agreements/enduser/{$}", s.authorized(s.handleCreateAgreement))
+	s.mux.HandleFunc("POST "+APIPath+"/requisitions/{$}", s.authorized(s.handleCreateRequisition))
+	s.mux.HandleFunc("GET "+APIPath+"/requisitions/{$}", s.authorized(s.handleListRequisitions))
+	s.mux.HandleFunc("GET "+APIPath+"/requisitions/{id}/{$}", s.authorized(s.handleGetRequisition))
+	s.mux.HandleFunc("GET "+APIPath+"/accounts/{id}/{$}", s.authorized(s.handleGetAccount))
+	s.mux.HandleFunc("GET "+APIPath+"/accounts/{id}/transactions/{$}", s.authorized(s.accountData("transactions", s.handleListTransactions)))
+	s.mux.HandleFunc("GET "+APIPath+"/accounts/{id}/balances/{$}", s.authorized(s.accountData("balances", s.handleListBalances)))
+	s.mux.HandleFunc("GET "+APIPath+"/accounts/{id}/details/{$}", s.authorized(s.accountData("details", s.handleGetDetails)))
+	s.mux.HandleFunc("GET /link/{id}", s.handleLink)
+
+	return s
+}
+
+// ServeHTTP serves the API and the page end users authorize requisitions on
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	s.mu.Unlock()
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// SetNow replaces the clock of the fake, which decides when tokens expire and quotas reset
+func (s *Server) SetNow(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.now = now
+}
+
+// AddInstitution adds an institution, or replaces the one with the same ID
+func (s *Server) AddInstitution(institution Institution) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.institutions[institution.ID] = institution
+}
+
+// AddAccount adds an account, or replaces the one with the same ID
+func (s *Server) AddAccount(account Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if account.Status == "" {
+		account.Status = AccountStatusReady
+	}
+	s.accounts[account.ID] = &account
+}
+
+// SetTransactions replaces the booked and pending transactions of an account
+func (s *Server) SetTransactions(accountID string, booked, pending []Transaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if account, ok := s.accounts[accountID]; ok {
+		account.Booked = booked
+		account.Pending = pending
+	}
+}
+
+// SetAccountStatus changes the status of an account, e.g. to EXPIRED to make its data unavailable
+func (s *Server) SetAccountStatus(accountID, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if account, ok := s.accounts[accountID]; ok {
+		account.Status = status
+	}
+}
+
+// Throttle rejects the next n API requests with the rate limit of all requests made with the credentials
+func (s *Server) Throttle(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.throttled = n
+}
+
+// ExpireAccessTokens revokes all issued access tokens, so requests are rejected until the token is refreshed
+func (s *Server) ExpireAccessTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.accessTokens)
+}
+
+// Requests returns the method and path of every request served so far
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+// Requisitions returns the requisitions created so far, oldest first
+func (s *Server) Requisitions() []Requisition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sortedRequisitions()
+}
+
+// sortedRequisitions returns copies of the requisitions ordered by ID, which follows creation, s.mu must be held
+func (s *Server) sortedRequisitions() []Requisition {
+	requisitions := make([]Requisition, 0, len(s.requisitions))
+	for _, requisition := range s.requisitions {
+		requisitions = append(requisitions, *requisition)
+	}
+	slices.SortFunc(requisitions, func(a, b Requisition) int { return strings.Compare(a.ID, b.ID) })
+
+	return requisitions
+}
+
+// newID returns a unique UUID formatted ID, s.mu must be held
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", s.nextID)
+}
+
+func (s *Server) handleNewToken(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		SecretID  string `json:"secret_id"`
+		SecretKey string `json:"secret_key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if request.SecretID != s.secretID || request.SecretKey != s.secretKey {
+		writeError(w, http.StatusUnauthorized, "Authentication failed", "No active account found with the given credentials")
+		return
+	}
+
+	now := s.now()
+	id := s.newID()
+	access, refresh := "access-"+id, "refresh-"+id
+	s.accessTokens[access] = now.Add(AccessTokenTTL)
+	s.refreshTokens[refresh] = now.Add(RefreshTokenTTL)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access":          access,
+		"access_expires":  int(AccessTokenTTL.Seconds()),
+		"refresh":         refresh,
+		"refresh_expires": int(RefreshTokenTTL.Seconds()),
+	})
+}
+
+func (s *Server) handleRefreshToken(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Refresh string `json:"refresh"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	expiresAt, ok := s.refreshTokens[request.Refresh]
+	if !ok || !now.Before(expiresAt) {
+		writeError(w, http.StatusUnauthorized, "Invalid token", "Token is invalid or expired")
+		return
+	}
+
+	access := "access-" + s.newID()
+	s.accessTokens[access] = now.Add(AccessTokenTTL)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access":         access,
+		"access_expires": int(AccessTokenTTL.Seconds()),
+	})
+}
+
+// authorized rejects requests without a valid access token and throttled requests before calling next
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		access, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		expiresAt, ok := s.accessTokens[access]
+		valid := ok && s.now().Before(expiresAt)
+		throttled := valid && s.throttled > 0
+		if throttled {
+			s.throttled--
+		}
+		s.mu.Unlock()
+
+		if !valid {
+			writeError(w, http.StatusUnauthorized, "Invalid token", "Token is invalid or expired")
+			return
+		}
+		if throttled {
+			w.Header().Set("http_x_ratelimit_limit", "0")
+			w.Header().Set("http_x_ratelimit_remaining", "0")
+			w.Header().Set("http_x_ratelimit_reset", "60")
+			writeError(w, http.StatusTooManyRequests, "Rate limit exceeded", "Too many requests, try again later")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// flexibleInt is a number the API accepts both as a JSON number and as a string
+type flexibleInt int
+
+func (i *flexibleInt) UnmarshalJSON(data []byte) error {
+	n, err := strconv.Atoi(strings.Trim(string(data), `"`))
+	if err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*i = flexibleInt(n)
+	return nil
+}
+
+func (s *Server) handleListInstitutions(w http.ResponseWriter, r *http.Request) {
+	country := strings.ToUpper(r.URL.Query().Get("country"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	institutions := []Institution{}
+	for _, institution := range s.institutions {
+		if country == "" || slices.Contains(institution.Countries, country) {
+			institutions = append(institutions, institution)
+		}
+	}
+	slices.SortFunc(institutions, func(a, b Institution) int { return strings.Compare(a.ID, b.ID) })
+
+	writeJSON(w, http.StatusOK, institutions)
+}
+
+func (s *Server) handleGetInstitution(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	institution, ok := s.institutions[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found", "Institution not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, institution)
+}
+
+func (s *Server) handleCreateAgreement(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		InstitutionID      string      `json:"institution_id"`
+		MaxHistoricalDays  flexibleInt `json:"max_historical_days"`
+		AccessValidForDays flexibleInt `json:"access_valid_for_days"`
+		AccessScope        []string    `json:"access_scope"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+	if request.InstitutionID == "" {
+		writeError(w, http.StatusBadRequest, "Invalid request", "institution_id is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agreement := Agreement{
+		ID:                 s.newID(),
+		Created:            s.now(),
+		InstitutionID:      request.InstitutionID,
+		MaxHistoricalDays:  int(request.MaxHistoricalDays),
+		AccessValidForDays: int(request.AccessValidForDays),
+		AccessScope:        request.AccessScope,
+	}
+	s.agreements[agreement.ID] = agreement
+
+	writeJSON(w, http.StatusCreated, agreement)
+}
+
+func (s *Server) handleCreateRequisition(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Redirect      string `json:"redirect"`
+		InstitutionID string `json:"institution_id"`
+		Reference     string `json:"reference"`
+		Agreement     string `json:"agreement"`
+		UserLanguage  string `json:"user_language"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+	if request.Redirect == "" || request.InstitutionID == "" {
+		writeError(w, http.StatusBadRequest, "Invalid request", "redirect and institution_id are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if request.Agreement != "" {
+		agreement, ok := s.agreements[request.Agreement]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not found", "Agreement not found")
+			return
+		}
+		if agreement.InstitutionID != request.InstitutionID {
+			writeError(w, http.StatusBadRequest, "Invalid request", "Agreement is for a different institution")
+			return
+		}
+	}
+
+	id := s.newID()
+	requisition := &Requisition{
+		ID:            id,
+		Created:       s.now(),
+		Redirect:      request.Redirect,
+		Status:        RequisitionStatusCreated,
+		InstitutionID: request.InstitutionID,
+		Agreement:     request.Agreement,
+		Reference:     request.Reference,
+		Accounts:      []string{},
+		UserLanguage:  request.UserLanguage,
+		Link:          "http://" + r.Host + "/link/" + id,
+	}
+	s.requisitions[id] = requisition
+
+	writeJSON(w, http.StatusCreated, requisition)
+}
+
+func (s *Server) handleListRequisitions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requisitions := s.sortedRequisitions()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"count":    len(requisitions),
+		"next":     nil,
+		"previous": nil,
+		"results":  requisitions,
+	})
+}
+
+func (s *Server) handleGetRequisition(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requisition, ok := s.requisitions[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found", "Requisition not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, requisition)
+}
+
+// handleLink plays the end user authorizing a requisition at their bank: it links all accounts of the institution
+// and redirects back to the requisition's redirect URL
+func (s *Server) handleLink(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	requisition, ok := s.requisitions[r.PathValue("id")]
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "Not found", "Requisition not found")
+		return
+	}
+
+	accounts := []string{}
+	for id, account := range s.accounts {
+		if account.InstitutionID == requisition.InstitutionID {
+			accounts = append(accounts, id)
+		}
+	}
+	slices.Sort(accounts)
+	requisition.Status = RequisitionStatusLinked
+	requisition.Accounts = accounts
+	redirect := requisition.Redirect + "?ref=" + requisition.Reference
+	s.mu.Unlock()
+
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (s *Server) handleGetAccount(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found", "Account not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":             account.ID,
+		"iban":           account.IBAN,
+		"institution_id": account.InstitutionID,
+		"owner_name":     account.OwnerName,
+		"status":         account.Status,
+	})
+}
+
+// accountData serves a data endpoint of an account, enforcing the account's status and daily quota of the endpoint
+func (s *Server) accountData(endpoint string, next func(w http.ResponseWriter, r *http.Request, account Account)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		account, ok := s.accounts[r.PathValue("id")]
+		if !ok {
+			s.mu.Unlock()
+			writeError(w, http.StatusNotFound, "Not found", "Account not found")
+			return
+		}
+		if account.Status != AccountStatusReady {
+			s.mu.Unlock()
+			writeError(w, http.StatusConflict, "Account not ready", fmt.Sprintf("Account is %s", account.Status))
+			return
+		}
+
+		if account.DailyLimit > 0 {
+			now := s.now()
+			key := account.ID + ":" + endpoint
+			q, ok := s.quotas[key]
+			if !ok || !now.Before(q.resetAt) {
+				q = &quota{resetAt: now.Add(24 * time.Hour)}
+				s.quotas[key] = q
+			}
+
+			exceeded := q.used >= account.DailyLimit
+			if !exceeded {
+				q.used++
+			}
+			w.Header().Set("http_x_ratelimit_account_success_limit", strconv.Itoa(account.DailyLimit))
+			w.Header().Set("http_x_ratelimit_account_success_remaining", strconv.Itoa(account.DailyLimit-q.used))
+			w.Header().Set("http_x_ratelimit_account_success_reset", strconv.Itoa(int(q.resetAt.Sub(now).Seconds())))
+			if exceeded {
+				s.mu.Unlock()
+				writeError(w, http.StatusTooManyRequests, "Rate limit exceeded", fmt.Sprintf("Daily request limit of %d for the account's %s exceeded", account.DailyLimit, endpoint))
+				return
+			}
+		}
+
+		snapshot := *account
+		s.mu.Unlock()
+
+		next(w, r, snapshot)
+	}
+}
+
+func (s *Server) handleListTransactions(w http.ResponseWriter, r *http.Request, account Account) {
+	query := r.URL.Query()
+	for _, param := range []string{"date_from", "date_to"} {
+		if value := query.Get(param); value != "" {
+			if _, err := time.Parse(time.DateOnly, value); err != nil {
+				writeError(w, http.StatusBadRequest, "Invalid date", fmt.Sprintf("%s must be formatted as YYYY-MM-DD", param))
+				return
+			}
+		}
+	}
+
+	// Dates formatted as YYYY-MM-DD compare the same as strings and as days
+	from, to := query.Get("date_from"), query.Get("date_to")
+	inRange := func(transactions []Transaction) []Transaction {
+		filtered := []Transaction{}
+		for _, t := range transactions {
+			if (from == "" || t.date() >= from) && (to == "" || t.date() <= to) {
+				filtered = append(filtered, t)
+			}
+		}
+		return filtered
+	}
+
+	s.mu.Lock()
+	lastUpdated := s.now()
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"transactions": map[string]any{
+			"booked":  inRange(account.Booked),
+			"pending": inRange(account.Pending),
+		},
+		"last_updated": lastUpdated,
+	})
+}
+
+func (s *Server) handleListBalances(w http.ResponseWriter, r *http.Request, account Account) {
+	balances := account.Balances
+	if balances == nil {
+		balances = []Balance{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"balances": balances})
+}
+
+func (s *Server) handleGetDetails(w http.ResponseWriter, r *http.Request, account Account) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"account": map[string]any{
+			"resourceId": account.ID,
+			"iban":       account.IBAN,
+			"currency":   account.Currency,
+			"ownerName":  account.OwnerName,
+			"name":       account.Name,
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError writes an error formatted like the errors of the API
+func writeError(w http.ResponseWriter, status int, summary, detail string) {
+	writeJSON(w, status, map[string]any{
+		"summary":     summary,
+		"detail":      detail,
+		"status_code": status,
+	})
+}
@@ -0,0 +1,250 @@
+package fakegocardless
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClient sends requests to a fake served by httptest
+type testClient struct {
+	t      *testing.T
+	server *httptest.Server
+	access string
+}
+
+func newTestClient(t *testing.T, fake *Server) *testClient {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return &testClient{t: t, server: server}
+}
+
+// do sends a request with the client's access token and decodes the response into v when it's set
+func (c *testClient) do(method, path string, body, v any) *http.Response {
+	var content []byte
+	if body != nil {
+		var err error
+		content, err = json.Marshal(body)
+		require.NoError(c.t, err)
+	}
+
+	request, err := http.NewRequest(method, c.server.URL+path, bytes.NewReader(content))
+	require.NoError(c.t, err)
+	if c.access != "" {
+		request.Header.Set("Authorization", "Bearer "+c.access)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	response, err := client.Do(request)
+	require.NoError(c.t, err)
+	defer func() { _ = response.Body.Close() }()
+
+	if v != nil {
+		require.NoError(c.t, json.NewDecoder(response.Body).Decode(v))
+	}
+	return response
+}
+
+// logIn requests tokens and keeps the access token for the next requests
+func (c *testClient) logIn() (refresh string) {
+	var tokens struct {
+		Access  string `json:"access"`
+		Refresh string `json:"refresh"`
+	}
+	response := c.do(http.MethodPost, APIPath+"/token/new/", map[string]string{"secret_id": "id", "secret_key": "key"}, &tokens)
+	require.Equal(c.t, http.StatusOK, response.StatusCode)
+
+	c.access = tokens.Access
+	return tokens.Refresh
+}
+
+func TestServerTokens(t *testing.T) {
+	now := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	fake := New("id", "key")
+	fake.SetNow(func() time.Time { return now })
+	fake.AddAccount(Account{ID: "aaa"})
+	client := newTestClient(t, fake)
+
+	response := client.do(http.MethodPost, APIPath+"/token/new/", map[string]string{"secret_id": "id", "secret_key": "wrong"}, nil)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	response = client.do(http.MethodGet, APIPath+"/accounts/aaa/", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	refresh := client.logIn()
+	response = client.do(http.MethodGet, APIPath+"/accounts/aaa/", nil, nil)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// The access token expires before the refresh token
+	now = now.Add(AccessTokenTTL)
+	response = client.do(http.MethodGet, APIPath+"/accounts/aaa/", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	var tokens struct {
+		Access string `json:"access"`
+	}
+	response = client.do(http.MethodPost, APIPath+"/token/refresh/", map[string]string{"refresh": refresh}, &tokens)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	client.access = tokens.Access
+	response = client.do(http.MethodGet, APIPath+"/accounts/aaa/", nil, nil)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	fake.ExpireAccessTokens()
+	response = client.do(http.MethodGet, APIPath+"/accounts/aaa/", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestServerTransactions(t *testing.T) {
+	now := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	fake := New("id", "key")
+	fake.SetNow(func() time.Time { return now })
+	fake.AddAccount(Account{
+		ID: "aaa",
+		Booked: []Transaction{
+			{TransactionID: "1", BookingDate: "2024-04-01", TransactionAmount: Amount{Amount: "-1.00", Currency: "EUR"}},
+			{TransactionID: "2", BookingDate: "2024-04-20", TransactionAmount: Amount{Amount: "-2.00", Currency: "EUR"}},
+		},
+		Pending: []Transaction{
+			{ValueDate: "2024-04-30", TransactionAmount: Amount{Amount: "-3.00", Currency: "EUR"}},
+		},
+		DailyLimit: 2,
+	})
+	client := newTestClient(t, fake)
+	client.logIn()
+
+	t.Run("filters transactions by date", func(t *testing.T) {
+		var body struct {
+			Transactions struct {
+				Booked  []Transaction `json:"booked"`
+				Pending []Transaction `json:"pending"`
+			} `json:"transactions"`
+		}
+		response := client.do(http.MethodGet, APIPath+"/accounts/aaa/transactions/?date_from=2024-04-10&date_to=2024-05-01", nil, &body)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "1", response.Header.Get("http_x_ratelimit_account_success_remaining"))
+		if assert.Len(t, body.Transactions.Booked, 1) {
+			assert.Equal(t, "2", body.Transactions.Booked[0].TransactionID)
+		}
+		assert.Len(t, body.Transactions.Pending, 1)
+	})
+
+	t.Run("rejects requests over the daily limit", func(t *testing.T) {
+		response := client.do(http.MethodGet, APIPath+"/accounts/aaa/transactions/", nil, nil)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		response = client.do(http.MethodGet, APIPath+"/accounts/aaa/transactions/", nil, nil)
+		assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+		assert.Equal(t, "0", response.Header.Get("http_x_ratelimit_account_success_remaining"))
+		assert.Equal(t, "86400", response.Header.Get("http_x_ratelimit_account_success_reset"))
+
+		// Other endpoints have quotas of their own
+		response = client.do(http.MethodGet, APIPath+"/accounts/aaa/balances/", nil, nil)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		now = now.Add(24 * time.Hour)
+		client.logIn()
+		response = client.do(http.MethodGet, APIPath+"/accounts/aaa/transactions/", nil, nil)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	})
+
+	t.Run("rejects throttled requests", func(t *testing.T) {
+		fake.Throttle(1)
+		response := client.do(http.MethodGet, APIPath+"/accounts/aaa/", nil, nil)
+		assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+		assert.Empty(t, response.Header.Get("http_x_ratelimit_account_success_reset"))
+
+		response = client.do(http.MethodGet, APIPath+"/accounts/aaa/", nil, nil)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	})
+
+	t.Run("rejects accounts that aren't ready", func(t *testing.T) {
+		fake.SetAccountStatus("aaa", "EXPIRED")
+		response := client.do(http.MethodGet, APIPath+"/accounts/aaa/transactions/", nil, nil)
+		assert.Equal(t, http.StatusConflict, response.StatusCode)
+	})
+}
+
+func TestServerRequisitions(t *testing.T) {
+	fake := New("id", "key")
+	fake.AddAccount(Account{ID: "aaa", InstitutionID: "BANK"})
+	fake.AddAccount(Account{ID: "bbb", InstitutionID: "BANK"})
+	fake.AddAccount(Account{ID: "ccc", InstitutionID: "OTHER_BANK"})
+	client := newTestClient(t, fake)
+	client.logIn()
+
+	var agreement Agreement
+	response := client.do(http.MethodPost, APIPath+"/agreements/enduser/", map[string]any{
+		"institution_id":      "BANK",
+		"max_historical_days": "90",
+		"access_scope":        []string{"transactions"},
+	}, &agreement)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, 90, agreement.MaxHistoricalDays)
+
+	var requisition Requisition
+	response = client.do(http.MethodPost, APIPath+"/requisitions/", map[string]any{
+		"redirect":       "http://localhost:8080/callback",
+		"institution_id": "BANK",
+		"agreement":      agreement.ID,
+		"reference":      "ref-1",
+	}, &requisition)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, RequisitionStatusCreated, requisition.Status)
+	assert.Equal(t, client.server.URL+"/link/"+requisition.ID, requisition.Link)
+
+	response = client.do(http.MethodGet, "/link/"+requisition.ID, nil, nil)
+	assert.Equal(t, http.StatusFound, response.StatusCode)
+	assert.Equal(t, "http://localhost:8080/callback?ref=ref-1", response.Header.Get("Location"))
+
+	response = client.do(http.MethodGet, APIPath+"/requisitions/"+requisition.ID+"/", nil, &requisition)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, RequisitionStatusLinked, requisition.Status)
+	assert.Equal(t, []string{"aaa", "bbb"}, requisition.Accounts)
+
+	var list struct {
+		Results []Requisition `json:"results"`
+	}
+	response = client.do(http.MethodGet, APIPath+"/requisitions/", nil, &list)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Len(t, list.Results, 1)
+}
+
+func TestServerInstitutionsAndDetails(t *testing.T) {
+	fake := New("id", "key")
+	fake.AddInstitution(Institution{ID: "BANK", Name: "Bank", Countries: []string{"PL"}})
+	fake.AddInstitution(Institution{ID: "OTHER_BANK", Name: "Other Bank", Countries: []string{"DE"}})
+	fake.AddAccount(Account{ID: "aaa", InstitutionID: "BANK", Name: "Checking", Currency: "PLN", DailyLimit: 1})
+	client := newTestClient(t, fake)
+	client.logIn()
+
+	var institutions []Institution
+	response := client.do(http.MethodGet, APIPath+"/institutions/?country=pl", nil, &institutions)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	if assert.Len(t, institutions, 1) {
+		assert.Equal(t, "BANK", institutions[0].ID)
+	}
+
+	response = client.do(http.MethodGet, APIPath+"/institutions/UNKNOWN/", nil, nil)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	var details struct {
+		Account struct {
+			Currency string `json:"currency"`
+			Name     string `json:"name"`
+		} `json:"account"`
+	}
+	response = client.do(http.MethodGet, APIPath+"/accounts/aaa/details/", nil, &details)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "PLN", details.Account.Currency)
+	assert.Equal(t, "Checking", details.Account.Name)
+
+	response = client.do(http.MethodGet, APIPath+"/accounts/aaa/details/", nil, nil)
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+}
@@ -0,0 +1,906 @@
+// Package fakeynab is an in-memory fake of the YNAB API. It serves budgets, accounts and transactions with the
+// import ID deduplication, transfers and server knowledge of the real API, so the ynab.go client can be exercised
+// end-to-end.
+package fakeynab
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// APIPath is the path the API is served under, the base URL of a client is the server URL followed by it
+	APIPath = "/v1"
+	// DefaultRateLimit is how many requests a token may make in a rolling hour, the same as in the real API
+	DefaultRateLimit = 200
+	// maxImportIDLength is the longest import ID the API accepts
+	maxImportIDLength = 36
+	// maxMemoLength is the longest memo the API accepts
+	maxMemoLength = 200
+)
+
+// Budget is a budget served by the fake
+type Budget struct {
+	ID   string
+	Name string
+	// CurrencyISOCode is the currency of the budget, it defaults to USD
+	CurrencyISOCode string
+	// DecimalDigits is the number of digits after the decimal separator of the currency, it defaults to 2
+	DecimalDigits int
+}
+
+// Account is an account of a budget
+type Account struct {
+	ID       string
+	BudgetID string
+	Name     string
+	// Type is the account type, e.g. checking or savings, it defaults to checking
+	Type     string
+	OnBudget bool
+	Closed   bool
+
+	// transferPayeeID is the payee of transactions moving money to the account
+	transferPayeeID string
+}
+
+// Category is a category of a budget, categories with the same Group are served in one category group
+type Category struct {
+	ID       string
+	BudgetID string
+	Group    string
+	Name     string
+	Hidden   bool
+}
+
+// Transaction is a transaction as the API formats it
+type Transaction struct {
+	ID                string  `json:"id"`
+	Date              string  `json:"date"`
+	Amount            int64   `json:"amount"`
+	Memo              *string `json:"memo"`
+	Cleared           string  `json:"cleared"`
+	Approved          bool    `json:"approved"`
+	FlagColor         *string `json:"flag_color"`
+	AccountID         string  `json:"account_id"`
+	AccountName       string  `json:"account_name"`
+	PayeeID           *string `json:"payee_id"`
+	PayeeName         *string `json:"payee_name"`
+	CategoryID        *string `json:"category_id"`
+	CategoryName      *string `json:"category_name"`
+	TransferAccountID *string `json:"transfer_account_id"`
+	// TransferTransactionID is the other side of a transfer, both sides change together
+	TransferTransactionID *string `json:"transfer_transaction_id"`
+	ImportID              *string `json:"import_id"`
+	Deleted               bool    `json:"deleted"`
+	Subtransactions       []any   `json:"subtransactions"`
+
+	// knowledge is the server knowledge of the budget when the transaction last changed
+	knowledge int64
+}
+
+// budgetState is everything stored for a budget
+type budgetState struct {
+	Budget
+	accounts     []*Account
+	categories   []*Category
+	transactions []*Transaction
+	payees       map[string]string
+	knowledge    int64
+}
+
+// Server is the fake API, it's safe for concurrent use
+type Server struct {
+	mu    sync.Mutex
+	token string
+	now   func() time.Time
+	mux   *http.ServeMux
+
+	// rateLimit is how many requests are answered in a rolling hour, unlimited when 0
+	rateLimit int
+	// requested are the times of the requests made in the last hour
+	requested []time.Time
+	budgets   map[string]*budgetState
+	// failures are the statuses the next API requests fail with
+	failures []int
+	requests []string
+	nextID   int
+}
+
+// New creates a fake accepting the given access token
+func New(token string) *Server {
+	s := &Server{
+		token:     token,
+		now:       time.Now,
+		mux:       http.NewServeMux(),
+		rateLimit: DefaultRateLimit,
+		budgets:   make(map[string]*budgetState),
+	}
+
+	s.mux.HandleFunc("GET "+APIPath+"/budgets", s.authorized(s.handleListBudgets))
+	s.mux.HandleFunc("GET "+APIPath+"/budgets/{budget}/settings", s.authorized(s.budget(s.handleGetSettings)))
+	s.mux.HandleFunc("GET "+APIPath+"/budgets/{budget}/accounts", s.authorized(s.budget(s.handleListAccounts)))
+	s.mux.HandleFunc("GET "+APIPath+"/budgets/{budget}/categories", s.authorized(s.budget(s.handleListCategories)))
+	s.mux.HandleFunc("GET "+APIPath+"/budgets/{budget}/transactions", s.authorized(s.budget(s.handleListTransactions)))
+	s.mux.HandleFunc("GET "+APIPath+"/budgets/{budget}/accounts/{account}/transactions", s.authorized(s.budget(s.handleListTransactions)))
+	s.mux.HandleFunc("POST "+APIPath+"/budgets/{budget}/transactions", s.authorized(s.budget(s.handleCreateTransactions)))
+	s.mux.HandleFunc("PATCH "+APIPath+"/budgets/{budget}/transactions", s.authorized(s.budget(s.handleUpdateTransactions)))
+	s.mux.HandleFunc("GET "+APIPath+"/budgets/{budget}/transactions/{id}", s.authorized(s.budget(s.handleGetTransaction)))
+	s.mux.HandleFunc("PUT "+APIPath+"/budgets/{budget}/transactions/{id}", s.authorized(s.budget(s.handleUpdateTransaction)))
+	s.mux.HandleFunc("DELETE "+APIPath+"/budgets/{budget}/transactions/{id}", s.authorized(s.budget(s.handleDeleteTransaction)))
+
+	return s
+}
+
+// ServeHTTP serves the API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	s.mu.Unlock()
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// SetNow replaces the clock of the fake, which decides when the rate limit frees up
+func (s *Server) SetNow(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.now = now
+}
+
+// SetRateLimit changes how many requests are answered in a rolling hour, 0 for unlimited
+func (s *Server) SetRateLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rateLimit = limit
+}
+
+// AddBudget adds a budget, or replaces the one with the same ID together with its accounts and transactions
+func (s *Server) AddBudget(budget Budget) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if budget.CurrencyISOCode == "" {
+		budget.CurrencyISOCode = "USD"
+	}
+	if budget.DecimalDigits == 0 {
+		budget.DecimalDigits = 2
+	}
+	s.budgets[budget.ID] = &budgetState{Budget: budget, payees: make(map[string]string)}
+}
+
+// AddAccount adds an account to its budget, which must have been added before
+func (s *Server) AddAccount(account Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if account.Type == "" {
+		account.Type = "checking"
+	}
+	account.transferPayeeID = s.newID()
+	b := s.budgets[account.BudgetID]
+	b.accounts = append(b.accounts, &account)
+	b.knowledge++
+}
+
+// TransferPayeeID returns the ID of the payee transactions moving money to an account are created with, empty when
+// the budget doesn't have the account
+func (s *Server) TransferPayeeID(budgetID, accountID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.budgets[budgetID]; ok {
+		if account := b.account(accountID); account != nil {
+			return account.transferPayeeID
+		}
+	}
+	return ""
+}
+
+// AddCategory adds a category to its budget, which must have been added before
+func (s *Server) AddCategory(category Category) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.budgets[category.BudgetID]
+	b.categories = append(b.categories, &category)
+	b.knowledge++
+}
+
+// AddTransaction adds a transaction to a budget like a user entering it in YNAB would, it returns its ID
+func (s *Server) AddTransaction(budgetID string, t Transaction) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.budgets[budgetID]
+	t.ID = s.newID()
+	if t.Cleared == "" {
+		t.Cleared = "uncleared"
+	}
+	s.save(b, &t)
+	b.transactions = append(b.transactions, &t)
+	s.saveTransfer(b, &t)
+
+	return t.ID
+}
+
+// Transactions returns all transactions of a budget, including deleted ones, in the order they were created
+func (s *Server) Transactions(budgetID string) []Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.budgets[budgetID]
+	if !ok {
+		return nil
+	}
+
+	transactions := make([]Transaction, 0, len(b.transactions))
+	for _, t := range b.transactions {
+		transactions = append(transactions, *t)
+	}
+	return transactions
+}
+
+// FailNext makes the next API requests fail with the given statuses, one request per status
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, statuses...)
+}
+
+// Requests returns the method and path of every request served so far
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+// newID returns a unique UUID formatted ID, s.mu must be held
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("00000000-0000-4000-9000-%012d", s.nextID)
+}
+
+// save resolves the account, payee and category names of a transaction and bumps the budget's server knowledge, s.mu must be held
+func (s *Server) save(b *budgetState, t *Transaction) {
+	for _, account := range b.accounts {
+		if account.ID == t.AccountID {
+			t.AccountName = account.Name
+		}
+	}
+
+	t.TransferAccountID = nil
+	if t.PayeeID != nil {
+		if destination := b.transferDestination(*t.PayeeID); destination != nil {
+			name := "Transfer : " + destination.Name
+			t.PayeeName = &name
+			t.TransferAccountID = &destination.ID
+		} else {
+			for name, payeeID := range b.payees {
+				if payeeID == *t.PayeeID {
+					t.PayeeName = &name
+				}
+			}
+		}
+	} else if t.PayeeName != nil && *t.PayeeName != "" {
+		payeeID, ok := b.payees[*t.PayeeName]
+		if !ok {
+			payeeID = s.newID()
+			b.payees[*t.PayeeName] = payeeID
+		}
+		t.PayeeID = &payeeID
+	}
+	t.CategoryName = nil
+	if t.CategoryID != nil {
+		if category := b.category(*t.CategoryID); category != nil {
+			t.CategoryName = &category.Name
+		}
+	}
+	if t.Subtransactions == nil {
+		t.Subtransactions = []any{}
+	}
+
+	b.knowledge++
+	t.knowledge = b.knowledge
+}
+
+// saveTransfer creates, updates or deletes the other side of a transfer like YNAB does, after t was saved. The other
+// side is in the account t moves money to, with the opposite amount and the transfer payee of the account of t.
+// s.mu must be held.
+func (s *Server) saveTransfer(b *budgetState, t *Transaction) {
+	var other *Transaction
+	if t.TransferTransactionID != nil {
+		other = b.transaction(*t.TransferTransactionID)
+	}
+
+	if other != nil && (t.TransferAccountID == nil || other.AccountID != *t.TransferAccountID || t.Deleted) {
+		// The transaction isn't a transfer to that account anymore
+		if !other.Deleted {
+			other.Deleted = true
+			other.TransferTransactionID = nil
+			s.save(b, other)
+		}
+		t.TransferTransactionID = nil
+		other = nil
+	}
+	if t.TransferAccountID == nil || t.Deleted {
+		return
+	}
+
+	if other == nil {
+		other = &Transaction{ID: s.newID(), AccountID: *t.TransferAccountID, Cleared: "uncleared", Memo: t.Memo}
+		b.transactions = append(b.transactions, other)
+		t.TransferTransactionID = &other.ID
+		other.TransferTransactionID = &t.ID
+	}
+	other.Date = t.Date
+	other.Amount = -t.Amount
+	payeeID := b.account(t.AccountID).transferPayeeID
+	other.PayeeID = &payeeID
+	s.save(b, other)
+}
+
+// authorized rejects requests without the access token, over the rate limit or set up to fail before calling next
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		if r.Header.Get("Authorization") != "Bearer "+s.token {
+			s.mu.Unlock()
+			writeError(w, http.StatusUnauthorized, "401", "unauthorized", "Unauthorized")
+			return
+		}
+
+		now := s.now()
+		s.requested = slices.DeleteFunc(s.requested, func(at time.Time) bool { return !now.Before(at.Add(time.Hour)) })
+		if s.rateLimit > 0 && len(s.requested) >= s.rateLimit {
+			s.mu.Unlock()
+			writeError(w, http.StatusTooManyRequests, "429", "too_many_requests", "Too many requests")
+			return
+		}
+		s.requested = append(s.requested, now)
+
+		if len(s.failures) > 0 {
+			status := s.failures[0]
+			s.failures = s.failures[1:]
+			s.mu.Unlock()
+			writeError(w, status, strconv.Itoa(status), strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"), http.StatusText(status))
+			return
+		}
+		s.mu.Unlock()
+
+		next(w, r)
+	}
+}
+
+// budget calls next with the budget of the request's path while holding s.mu
+func (s *Server) budget(next func(w http.ResponseWriter, r *http.Request, b *budgetState)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		b, ok := s.budgets[r.PathValue("budget")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "404.2", "resource_not_found", "Resource not found")
+			return
+		}
+
+		next(w, r, b)
+	}
+}
+
+// currencyFormat formats the budget's currency like the API does
+func currencyFormat(b Budget) map[string]any {
+	return map[string]any{
+		"iso_code":          b.CurrencyISOCode,
+		"example_format":    "123,456.78",
+		"decimal_digits":    b.DecimalDigits,
+		"decimal_separator": ".",
+		"symbol_first":      true,
+		"group_separator":   ",",
+		"currency_symbol":   b.CurrencyISOCode,
+		"display_symbol":    true,
+	}
+}
+
+func (s *Server) handleListBudgets(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	budgets := []map[string]any{}
+	for _, b := range s.budgets {
+		budgets = append(budgets, map[string]any{
+			"id":              b.ID,
+			"name":            b.Name,
+			"date_format":     map[string]any{"format": "YYYY-MM-DD"},
+			"currency_format": currencyFormat(b.Budget),
+		})
+	}
+	slices.SortFunc(budgets, func(a, b map[string]any) int { return strings.Compare(a["name"].(string), b["name"].(string)) })
+
+	writeJSON(w, http.StatusOK, map[string]any{"budgets": budgets, "default_budget": nil})
+}
+
+func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request, b *budgetState) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"settings": map[string]any{
+			"date_format":     map[string]any{"format": "YYYY-MM-DD"},
+			"currency_format": currencyFormat(b.Budget),
+		},
+	})
+}
+
+func (s *Server) handleListAccounts(w http.ResponseWriter, r *http.Request, b *budgetState) {
+	accounts := []map[string]any{}
+	for _, account := range b.accounts {
+		var balance, cleared, uncleared int64
+		for _, t := range b.transactions {
+			if t.AccountID != account.ID || t.Deleted {
+				continue
+			}
+			balance += t.Amount
+			if t.Cleared == "uncleared" {
+				uncleared += t.Amount
+			} else {
+				cleared += t.Amount
+			}
+		}
+
+		accounts = append(accounts, map[string]any{
+			"id":                account.ID,
+			"name":              account.Name,
+			"type":              account.Type,
+			"on_budget":         account.OnBudget,
+			"closed":            account.Closed,
+			"note":              nil,
+			"balance":           balance,
+			"cleared_balance":   cleared,
+			"uncleared_balance": uncleared,
+			"deleted":           false,
+			"transfer_payee_id": account.transferPayeeID,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"accounts": accounts, "server_knowledge": b.knowledge})
+}
+
+// handleListCategories lists the categories of a budget in groups, in the order the groups were first added
+func (s *Server) handleListCategories(w http.ResponseWriter, r *http.Request, b *budgetState) {
+	groups := []map[string]any{}
+	byName := make(map[string]map[string]any)
+	for _, category := range b.categories {
+		group, ok := byName[category.Group]
+		if !ok {
+			group = map[string]any{
+				"id":         "group-" + category.Group,
+				"name":       category.Group,
+				"hidden":     false,
+				"deleted":    false,
+				"categories": []map[string]any{},
+			}
+			byName[category.Group] = group
+			groups = append(groups, group)
+		}
+
+		group["categories"] = append(group["categories"].([]map[string]any), map[string]any{
+			"id":                category.ID,
+			"category_group_id": group["id"],
+			"name":              category.Name,
+			"hidden":            category.Hidden,
+			"budgeted":          0,
+			"activity":          0,
+			"balance":           0,
+			"deleted":           false,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"category_groups": groups, "server_knowledge": b.knowledge})
+}
+
+// handleListTransactions lists the transactions of a budget or of one of its accounts. Deleted transactions are
+// only returned by delta requests, which ask for what changed since last_knowledge_of_server.
+func (s *Server) handleListTransactions(w http.ResponseWriter, r *http.Request, b *budgetState) {
+	query := r.URL.Query()
+	accountID := r.PathValue("account")
+	if accountID != "" && b.account(accountID) == nil {
+		writeError(w, http.StatusNotFound, "404.2", "resource_not_found", "Resource not found")
+		return
+	}
+
+	sinceDate := query.Get("since_date")
+	if sinceDate != "" {
+		if _, err := time.Parse(time.DateOnly, sinceDate); err != nil {
+			writeError(w, http.StatusBadRequest, "400", "bad_request", "since_date must be formatted as YYYY-MM-DD")
+			return
+		}
+	}
+	var lastKnowledge int64 = -1
+	if value := query.Get("last_knowledge_of_server"); value != "" {
+		var err error
+		lastKnowledge, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "400", "bad_request", "last_knowledge_of_server must be a number")
+			return
+		}
+	}
+	filter := query.Get("type")
+
+	transactions := []Transaction{}
+	for _, t := range b.transactions {
+		switch {
+		case accountID != "" && t.AccountID != accountID:
+		case sinceDate != "" && t.Date < sinceDate:
+		case lastKnowledge >= 0 && t.knowledge <= lastKnowledge:
+		case lastKnowledge < 0 && t.Deleted:
+		case filter == "uncategorized" && t.CategoryID != nil:
+		case filter == "unapproved" && t.Approved:
+		default:
+			transactions = append(transactions, *t)
+		}
+	}
+	slices.SortStableFunc(transactions, func(a, b Transaction) int { return strings.Compare(a.Date, b.Date) })
+
+	writeJSON(w, http.StatusOK, map[string]any{"transactions": transactions, "server_knowledge": b.knowledge})
+}
+
+func (s *Server) handleGetTransaction(w http.ResponseWriter, r *http.Request, b *budgetState) {
+	t := b.transaction(r.PathValue("id"))
+	if t == nil {
+		writeError(w, http.StatusNotFound, "404.2", "resource_not_found", "Resource not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"transaction": t, "server_knowledge": b.knowledge})
+}
+
+// payloadTransaction is a transaction to create or update, fields that are missing from an update are kept
+type payloadTransaction map[string]json.RawMessage
+
+// string returns the string field key, ok is false when it's missing or null
+func (p payloadTransaction) string(key string) (string, bool) {
+	raw, present := p[key]
+	if !present || string(raw) == "null" {
+		return "", false
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// apply sets the fields present in p on t, returning a message of the first invalid field
+func (p payloadTransaction) apply(b *budgetState, t *Transaction) string {
+	optional := func(key string, field **string) {
+		if _, present := p[key]; !present {
+			return
+		}
+		*field = nil
+		if value, ok := p.string(key); ok {
+			*field = &value
+		}
+	}
+
+	if accountID, ok := p.string("account_id"); ok {
+		if b.account(accountID) == nil {
+			return "account_id does not exist on this budget"
+		}
+		t.AccountID = accountID
+	}
+	if date, ok := p.string("date"); ok {
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return "date must be formatted as YYYY-MM-DD"
+		}
+		t.Date = date
+	}
+	if raw, ok := p["amount"]; ok {
+		if err := json.Unmarshal(raw, &t.Amount); err != nil {
+			return "amount must be a number of milliunits"
+		}
+	}
+	if cleared, ok := p.string("cleared"); ok {
+		if cleared != "cleared" && cleared != "uncleared" && cleared != "reconciled" {
+			return "cleared must be cleared, uncleared or reconciled"
+		}
+		t.Cleared = cleared
+	}
+	if raw, ok := p["approved"]; ok {
+		_ = json.Unmarshal(raw, &t.Approved)
+	}
+	if _, present := p["payee_id"]; present {
+		t.PayeeID = nil
+		if payeeID, ok := p.string("payee_id"); ok {
+			destination := b.transferDestination(payeeID)
+			if destination == nil && !slices.Contains(slices.Collect(maps.Values(b.payees)), payeeID) {
+				return "payee_id does not exist on this budget"
+			}
+			if destination != nil && destination.ID == t.AccountID {
+				return "payee_id is the transfer payee of the transaction's own account"
+			}
+			t.PayeeID = &payeeID
+		}
+	} else if _, present := p["payee_name"]; present {
+		// The payee name is only used without a payee ID
+		t.PayeeID = nil
+	}
+	optional("payee_name", &t.PayeeName)
+	optional("category_id", &t.CategoryID)
+	if t.CategoryID != nil && b.category(*t.CategoryID) == nil {
+		return "category_id does not exist on this budget"
+	}
+	optional("memo", &t.Memo)
+	if t.Memo != nil && utf8.RuneCountInString(*t.Memo) > maxMemoLength {
+		return fmt.Sprintf("memo must not be longer than %d characters", maxMemoLength)
+	}
+	optional("flag_color", &t.FlagColor)
+
+	return ""
+}
+
+// applyImportID sets the import ID of an update to a transaction entered without one, like a transaction entered by
+// the user that an import matched. The import ID of a transaction that has one can't be changed.
+func (p payloadTransaction) applyImportID(b *budgetState, t *Transaction) string {
+	importID, ok := p.string("import_id")
+	if !ok || (t.ImportID != nil && *t.ImportID == importID) {
+		return ""
+	}
+	if t.ImportID != nil {
+		return "import_id of an imported transaction cannot be changed"
+	}
+	if len(importID) > maxImportIDLength {
+		return fmt.Sprintf("import_id must not be longer than %d characters", maxImportIDLength)
+	}
+	if b.imported(t.AccountID, importID) != nil {
+		return "import_id already exists on this account"
+	}
+	t.ImportID = &importID
+	return ""
+}
+
+// account returns the account with the given ID, or nil
+func (b *budgetState) account(id string) *Account {
+	for _, account := range b.accounts {
+		if account.ID == id {
+			return account
+		}
+	}
+	return nil
+}
+
+// transferDestination returns the account payeeID is the transfer payee of, or nil
+func (b *budgetState) transferDestination(payeeID string) *Account {
+	for _, account := range b.accounts {
+		if account.transferPayeeID == payeeID {
+			return account
+		}
+	}
+	return nil
+}
+
+// category returns the category with the given ID, or nil
+func (b *budgetState) category(id string) *Category {
+	for _, category := range b.categories {
+		if category.ID == id {
+			return category
+		}
+	}
+	return nil
+}
+
+// transaction returns the transaction with the given ID, or nil
+func (b *budgetState) transaction(id string) *Transaction {
+	for _, t := range b.transactions {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+// imported returns the transaction of the account with the given import ID, or nil. Deleted transactions keep
+// their import ID, so a deleted import isn't imported again.
+func (b *budgetState) imported(accountID, importID string) *Transaction {
+	for _, t := range b.transactions {
+		if t.AccountID == accountID && t.ImportID != nil && *t.ImportID == importID {
+			return t
+		}
+	}
+	return nil
+}
+
+// decodeTransactions reads the transaction or transactions of a create or update request
+func decodeTransactions(r *http.Request) ([]payloadTransaction, error) {
+	var body struct {
+		Transaction  payloadTransaction   `json:"transaction"`
+		Transactions []payloadTransaction `json:"transactions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Transaction != nil {
+		body.Transactions = append(body.Transactions, body.Transaction)
+	}
+	if len(body.Transactions) == 0 {
+		return nil, fmt.Errorf("transaction or transactions is required")
+	}
+
+	return body.Transactions, nil
+}
+
+// handleCreateTransactions creates transactions, skipping the ones whose import ID the account already has
+func (s *Server) handleCreateTransactions(w http.ResponseWriter, r *http.Request, b *budgetState) {
+	payloads, err := decodeTransactions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "400", "bad_request", err.Error())
+		return
+	}
+
+	// Validate everything first, so an invalid request doesn't create anything
+	created := make([]*Transaction, 0, len(payloads))
+	for _, p := range payloads {
+		t := &Transaction{Cleared: "uncleared"}
+		if message := p.apply(b, t); message != "" {
+			writeError(w, http.StatusBadRequest, "400", "bad_request", message)
+			return
+		}
+		if t.AccountID == "" || t.Date == "" {
+			writeError(w, http.StatusBadRequest, "400", "bad_request", "account_id and date are required")
+			return
+		}
+		if importID, ok := p.string("import_id"); ok {
+			if len(importID) > maxImportIDLength {
+				writeError(w, http.StatusBadRequest, "400", "bad_request", fmt.Sprintf("import_id must not be longer than %d characters", maxImportIDLength))
+				return
+			}
+			t.ImportID = &importID
+		}
+		created = append(created, t)
+	}
+
+	transactionIDs := []string{}
+	duplicateImportIDs := []string{}
+	transactions := []Transaction{}
+	for _, t := range created {
+		if t.ImportID != nil && b.imported(t.AccountID, *t.ImportID) != nil {
+			duplicateImportIDs = append(duplicateImportIDs, *t.ImportID)
+			continue
+		}
+
+		t.ID = s.newID()
+		s.save(b, t)
+		b.transactions = append(b.transactions, t)
+		s.saveTransfer(b, t)
+		transactionIDs = append(transactionIDs, t.ID)
+		transactions = append(transactions, *t)
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"transaction_ids":      transactionIDs,
+		"transactions":         transactions,
+		"duplicate_import_ids": duplicateImportIDs,
+		"server_knowledge":     b.knowledge,
+	})
+}
+
+// handleUpdateTransactions updates transactions identified by their ID or, when it's missing, their import ID
+func (s *Server) handleUpdateTransactions(w http.ResponseWriter, r *http.Request, b *budgetState) {
+	payloads, err := decodeTransactions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "400", "bad_request", err.Error())
+		return
+	}
+
+	targets := make([]*Transaction, 0, len(payloads))
+	for _, p := range payloads {
+		var target *Transaction
+		if id, ok := p.string("id"); ok && id != "" {
+			target = b.transaction(id)
+		} else if importID, ok := p.string("import_id"); ok {
+			accountID, _ := p.string("account_id")
+			target = b.imported(accountID, importID)
+		}
+		if target == nil || target.Deleted {
+			writeError(w, http.StatusBadRequest, "400", "bad_request", "transaction does not exist in this budget")
+			return
+		}
+
+		updated := *target
+		if message := p.apply(b, &updated); message != "" {
+			writeError(w, http.StatusBadRequest, "400", "bad_request", message)
+			return
+		}
+		if message := p.applyImportID(b, &updated); message != "" {
+			writeError(w, http.StatusBadRequest, "400", "bad_request", message)
+			return
+		}
+		targets = append(targets, target)
+	}
+
+	transactionIDs := []string{}
+	transactions := []Transaction{}
+	for i, target := range targets {
+		_ = payloads[i].apply(b, target)
+		_ = payloads[i].applyImportID(b, target)
+		s.save(b, target)
+		s.saveTransfer(b, target)
+		transactionIDs = append(transactionIDs, target.ID)
+		transactions = append(transactions, *target)
+	}
+
+	// The API answers bulk updates with its own 209 status
+	writeJSON(w, 209, map[string]any{
+		"transaction_ids":  transactionIDs,
+		"transactions":     transactions,
+		"server_knowledge": b.knowledge,
+	})
+}
+
+func (s *Server) handleUpdateTransaction(w http.ResponseWriter, r *http.Request, b *budgetState) {
+	target := b.transaction(r.PathValue("id"))
+	if target == nil || target.Deleted {
+		writeError(w, http.StatusNotFound, "404.2", "resource_not_found", "Resource not found")
+		return
+	}
+
+	payloads, err := decodeTransactions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "400", "bad_request", err.Error())
+		return
+	}
+
+	updated := *target
+	if message := payloads[0].apply(b, &updated); message != "" {
+		writeError(w, http.StatusBadRequest, "400", "bad_request", message)
+		return
+	}
+	if message := payloads[0].applyImportID(b, &updated); message != "" {
+		writeError(w, http.StatusBadRequest, "400", "bad_request", message)
+		return
+	}
+	*target = updated
+	s.save(b, target)
+	s.saveTransfer(b, target)
+
+	writeJSON(w, http.StatusOK, map[string]any{"transaction": target, "server_knowledge": b.knowledge})
+}
+
+func (s *Server) handleDeleteTransaction(w http.ResponseWriter, r *http.Request, b *budgetState) {
+	target := b.transaction(r.PathValue("id"))
+	if target == nil || target.Deleted {
+		writeError(w, http.StatusNotFound, "404.2", "resource_not_found", "Resource not found")
+		return
+	}
+
+	target.Deleted = true
+	s.save(b, target)
+	s.saveTransfer(b, target)
+
+	writeJSON(w, http.StatusOK, map[string]any{"transaction": target, "server_knowledge": b.knowledge})
+}
+
+// writeJSON writes body wrapped in the data envelope of the API
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": body})
+}
+
+// writeError writes an error formatted like the errors of the API
+func writeError(w http.ResponseWriter, status int, id, name, detail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"id": id, "name": name, "detail": detail},
+	})
+}
@@ -0,0 +1,356 @@
+package fakeynab
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClient sends requests to a fake served by httptest
+type testClient struct {
+	t      *testing.T
+	server *httptest.Server
+	token  string
+}
+
+func newTestClient(t *testing.T, fake *Server) *testClient {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return &testClient{t: t, server: server, token: "token"}
+}
+
+// do sends a request and decodes the data of the response into v when it's set
+func (c *testClient) do(method, path string, body, v any) *http.Response {
+	var content []byte
+	if body != nil {
+		var err error
+		content, err = json.Marshal(body)
+		require.NoError(c.t, err)
+	}
+
+	request, err := http.NewRequest(method, c.server.URL+APIPath+path, bytes.NewReader(content))
+	require.NoError(c.t, err)
+	request.Header.Set("Authorization", "Bearer "+c.token)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(c.t, err)
+	defer func() { _ = response.Body.Close() }()
+
+	if v != nil {
+		envelope := struct {
+			Data any `json:"data"`
+		}{Data: v}
+		require.NoError(c.t, json.NewDecoder(response.Body).Decode(&envelope))
+	}
+	return response
+}
+
+type operationSummary struct {
+	TransactionIDs     []string      `json:"transaction_ids"`
+	Transactions       []Transaction `json:"transactions"`
+	DuplicateImportIDs []string      `json:"duplicate_import_ids"`
+	ServerKnowledge    int64         `json:"server_knowledge"`
+}
+
+type transactionsList struct {
+	Transactions    []Transaction `json:"transactions"`
+	ServerKnowledge int64         `json:"server_knowledge"`
+}
+
+func newTestServer() *Server {
+	fake := New("token")
+	fake.AddBudget(Budget{ID: "budget", Name: "Budget", CurrencyISOCode: "EUR"})
+	fake.AddAccount(Account{ID: "checking", BudgetID: "budget", Name: "Checking", OnBudget: true})
+	fake.AddAccount(Account{ID: "savings", BudgetID: "budget", Name: "Savings", OnBudget: true})
+	return fake
+}
+
+func TestServerTransactions(t *testing.T) {
+	fake := newTestServer()
+	client := newTestClient(t, fake)
+
+	var created operationSummary
+	response := client.do(http.MethodPost, "/budgets/budget/transactions", map[string]any{
+		"transactions": []map[string]any{
+			{"account_id": "checking", "date": "2024-04-01", "amount": -12340, "payee_name": "Shop", "cleared": "cleared", "import_id": "YNAB:-12340:2024-04-01:1"},
+			{"account_id": "checking", "date": "2024-04-02", "amount": -5000, "payee_name": "Cafe", "cleared": "uncleared", "import_id": "GCP:1"},
+		},
+	}, &created)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Len(t, created.TransactionIDs, 2)
+	assert.Empty(t, created.DuplicateImportIDs)
+	assert.Equal(t, "Checking", created.Transactions[0].AccountName)
+	assert.NotNil(t, created.Transactions[0].PayeeID)
+
+	t.Run("skips import IDs the account already has", func(t *testing.T) {
+		var duplicated operationSummary
+		response := client.do(http.MethodPost, "/budgets/budget/transactions", map[string]any{
+			"transactions": []map[string]any{
+				{"account_id": "checking", "date": "2024-04-01", "amount": -12340, "import_id": "YNAB:-12340:2024-04-01:1"},
+				{"account_id": "savings", "date": "2024-04-01", "amount": -12340, "import_id": "YNAB:-12340:2024-04-01:1"},
+			},
+		}, &duplicated)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		assert.Equal(t, []string{"YNAB:-12340:2024-04-01:1"}, duplicated.DuplicateImportIDs)
+		assert.Len(t, duplicated.TransactionIDs, 1)
+	})
+
+	t.Run("rejects invalid transactions", func(t *testing.T) {
+		response := client.do(http.MethodPost, "/budgets/budget/transactions", map[string]any{
+			"transaction": map[string]any{"account_id": "unknown", "date": "2024-04-01", "amount": 1},
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		response = client.do(http.MethodPost, "/budgets/budget/transactions", map[string]any{
+			"transaction": map[string]any{"account_id": "checking", "date": "2024-04-01", "amount": 1, "import_id": "GC:0123456789012345678901234567890123456789"},
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		response = client.do(http.MethodPost, "/budgets/budget/transactions", map[string]any{
+			"transaction": map[string]any{"account_id": "checking", "date": "2024-04-01", "amount": 1, "memo": strings.Repeat("m", maxMemoLength+1)},
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+
+	t.Run("updates transactions keeping missing fields", func(t *testing.T) {
+		var updated operationSummary
+		response := client.do(http.MethodPatch, "/budgets/budget/transactions", map[string]any{
+			"transactions": []map[string]any{
+				{"id": created.TransactionIDs[1], "amount": -5500, "cleared": "cleared"},
+			},
+		}, &updated)
+		require.Equal(t, 209, response.StatusCode)
+		if assert.Len(t, updated.Transactions, 1) {
+			assert.Equal(t, int64(-5500), updated.Transactions[0].Amount)
+			assert.Equal(t, "cleared", updated.Transactions[0].Cleared)
+			assert.Equal(t, "Cafe", *updated.Transactions[0].PayeeName)
+		}
+	})
+
+	t.Run("returns changes since the server knowledge", func(t *testing.T) {
+		var list transactionsList
+		response := client.do(http.MethodGet, "/budgets/budget/accounts/checking/transactions", nil, &list)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Len(t, list.Transactions, 2)
+		knowledge := list.ServerKnowledge
+
+		response = client.do(http.MethodDelete, "/budgets/budget/transactions/"+created.TransactionIDs[0], nil, nil)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		response = client.do(http.MethodGet, "/budgets/budget/accounts/checking/transactions", nil, &list)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Len(t, list.Transactions, 1)
+
+		response = client.do(http.MethodGet, "/budgets/budget/transactions?last_knowledge_of_server="+strconv.FormatInt(knowledge, 10), nil, &list)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		if assert.Len(t, list.Transactions, 1) {
+			assert.True(t, list.Transactions[0].Deleted)
+		}
+
+		// A deleted import isn't imported again
+		var reimported operationSummary
+		client.do(http.MethodPost, "/budgets/budget/transactions", map[string]any{
+			"transaction": map[string]any{"account_id": "checking", "date": "2024-04-01", "amount": -12340, "import_id": "YNAB:-12340:2024-04-01:1"},
+		}, &reimported)
+		assert.Equal(t, []string{"YNAB:-12340:2024-04-01:1"}, reimported.DuplicateImportIDs)
+	})
+
+	t.Run("filters transactions by date", func(t *testing.T) {
+		var list transactionsList
+		client.do(http.MethodGet, "/budgets/budget/transactions?since_date=2024-04-02", nil, &list)
+		for _, transaction := range list.Transactions {
+			assert.GreaterOrEqual(t, transaction.Date, "2024-04-02")
+		}
+	})
+}
+
+func TestServerEnteredTransactions(t *testing.T) {
+	fake := newTestServer()
+	client := newTestClient(t, fake)
+
+	payee := "Bakery"
+	id := fake.AddTransaction("budget", Transaction{AccountID: "checking", Date: "2024-04-03", Amount: -3000, PayeeName: &payee})
+
+	var updated operationSummary
+	response := client.do(http.MethodPatch, "/budgets/budget/transactions", map[string]any{
+		"transactions": []map[string]any{{"id": id, "import_id": "GC:bakery", "cleared": "cleared"}},
+	}, &updated)
+	require.Equal(t, 209, response.StatusCode)
+	if assert.Len(t, updated.Transactions, 1) && assert.NotNil(t, updated.Transactions[0].ImportID) {
+		assert.Equal(t, "GC:bakery", *updated.Transactions[0].ImportID)
+	}
+
+	// Imported transactions keep their import ID, and an account has every import ID once
+	response = client.do(http.MethodPatch, "/budgets/budget/transactions", map[string]any{
+		"transactions": []map[string]any{{"id": id, "import_id": "GC:other"}},
+	}, nil)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	other := fake.AddTransaction("budget", Transaction{AccountID: "checking", Date: "2024-04-03", Amount: -3000})
+	response = client.do(http.MethodPut, "/budgets/budget/transactions/"+other, map[string]any{
+		"transaction": map[string]any{"import_id": "GC:bakery"},
+	}, nil)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestServerCategories(t *testing.T) {
+	fake := newTestServer()
+	fake.AddCategory(Category{ID: "groceries", BudgetID: "budget", Group: "Everyday", Name: "Groceries"})
+	fake.AddCategory(Category{ID: "rent", BudgetID: "budget", Group: "Bills", Name: "Rent"})
+	fake.AddCategory(Category{ID: "dining", BudgetID: "budget", Group: "Everyday", Name: "Dining Out", Hidden: true})
+	client := newTestClient(t, fake)
+
+	var list struct {
+		CategoryGroups []struct {
+			ID         string `json:"id"`
+			Name       string `json:"name"`
+			Categories []struct {
+				ID              string `json:"id"`
+				CategoryGroupID string `json:"category_group_id"`
+				Name            string `json:"name"`
+				Hidden          bool   `json:"hidden"`
+			} `json:"categories"`
+		} `json:"category_groups"`
+	}
+	response := client.do(http.MethodGet, "/budgets/budget/categories", nil, &list)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	if assert.Len(t, list.CategoryGroups, 2) {
+		everyday := list.CategoryGroups[0]
+		assert.Equal(t, "Everyday", everyday.Name)
+		if assert.Len(t, everyday.Categories, 2) {
+			assert.Equal(t, everyday.ID, everyday.Categories[0].CategoryGroupID)
+			assert.Equal(t, "Groceries", everyday.Categories[0].Name)
+			assert.True(t, everyday.Categories[1].Hidden)
+		}
+		assert.Equal(t, "Bills", list.CategoryGroups[1].Name)
+	}
+
+	t.Run("names the category of transactions", func(t *testing.T) {
+		var created operationSummary
+		response := client.do(http.MethodPost, "/budgets/budget/transactions", map[string]any{
+			"transaction": map[string]any{"account_id": "checking", "date": "2024-04-01", "amount": -1000, "category_id": "rent"},
+		}, &created)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		if assert.Len(t, created.Transactions, 1) {
+			assert.Equal(t, "Rent", *created.Transactions[0].CategoryName)
+		}
+	})
+
+	t.Run("rejects unknown categories", func(t *testing.T) {
+		response := client.do(http.MethodPost, "/budgets/budget/transactions", map[string]any{
+			"transaction": map[string]any{"account_id": "checking", "date": "2024-04-01", "amount": -1000, "category_id": "unknown"},
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+}
+
+func TestServerTransfers(t *testing.T) {
+	fake := newTestServer()
+	client := newTestClient(t, fake)
+	savingsPayee := fake.TransferPayeeID("budget", "savings")
+	require.NotEmpty(t, savingsPayee)
+
+	var accounts struct {
+		Accounts []struct {
+			ID              string `json:"id"`
+			TransferPayeeID string `json:"transfer_payee_id"`
+		} `json:"accounts"`
+	}
+	client.do(http.MethodGet, "/budgets/budget/accounts", nil, &accounts)
+	if assert.Len(t, accounts.Accounts, 2) {
+		assert.Equal(t, savingsPayee, accounts.Accounts[1].TransferPayeeID)
+	}
+
+	var created operationSummary
+	response := client.do(http.MethodPost, "/budgets/budget/transactions", map[string]any{
+		"transaction": map[string]any{"account_id": "checking", "date": "2024-04-01", "amount": -100000, "payee_id": savingsPayee, "payee_name": "ignored", "import_id": "GC:1"},
+	}, &created)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	require.Len(t, created.Transactions, 1)
+	transfer := created.Transactions[0]
+	assert.Equal(t, "Transfer : Savings", *transfer.PayeeName)
+	assert.Equal(t, "savings", *transfer.TransferAccountID)
+
+	other := func() Transaction {
+		for _, transaction := range fake.Transactions("budget") {
+			if transaction.ID == *transfer.TransferTransactionID {
+				return transaction
+			}
+		}
+		t.Fatal("other side of the transfer not found")
+		return Transaction{}
+	}
+	assert.Equal(t, "savings", other().AccountID)
+	assert.Equal(t, int64(100000), other().Amount)
+	assert.Equal(t, "Transfer : Checking", *other().PayeeName)
+	assert.Equal(t, "checking", *other().TransferAccountID)
+	assert.Nil(t, other().ImportID)
+
+	t.Run("updates both sides", func(t *testing.T) {
+		response := client.do(http.MethodPatch, "/budgets/budget/transactions", map[string]any{
+			"transactions": []map[string]any{{"id": transfer.ID, "amount": -120000, "date": "2024-04-02"}},
+		}, nil)
+		require.Equal(t, 209, response.StatusCode)
+		assert.Equal(t, int64(120000), other().Amount)
+		assert.Equal(t, "2024-04-02", other().Date)
+	})
+
+	t.Run("rejects transfers to the same account", func(t *testing.T) {
+		response := client.do(http.MethodPost, "/budgets/budget/transactions", map[string]any{
+			"transaction": map[string]any{"account_id": "savings", "date": "2024-04-01", "amount": 1, "payee_id": savingsPayee},
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+
+	t.Run("deletes both sides", func(t *testing.T) {
+		response := client.do(http.MethodDelete, "/budgets/budget/transactions/"+transfer.ID, nil, nil)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		assert.True(t, other().Deleted)
+	})
+}
+
+func TestServerRequests(t *testing.T) {
+	now := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	fake := newTestServer()
+	fake.SetNow(func() time.Time { return now })
+	fake.SetRateLimit(2)
+	client := newTestClient(t, fake)
+
+	t.Run("rejects unknown tokens", func(t *testing.T) {
+		client.token = "wrong"
+		defer func() { client.token = "token" }()
+
+		response := client.do(http.MethodGet, "/budgets", nil, nil)
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	})
+
+	t.Run("fails requests on demand", func(t *testing.T) {
+		fake.FailNext(http.StatusServiceUnavailable)
+		response := client.do(http.MethodGet, "/budgets", nil, nil)
+		assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	})
+
+	t.Run("rejects requests over the rate limit", func(t *testing.T) {
+		var budgets struct {
+			Budgets []map[string]any `json:"budgets"`
+		}
+		response := client.do(http.MethodGet, "/budgets", nil, &budgets)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Len(t, budgets.Budgets, 1)
+
+		response = client.do(http.MethodGet, "/budgets", nil, nil)
+		assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+
+		now = now.Add(time.Hour)
+		response = client.do(http.MethodGet, "/budgets/budget/settings", nil, nil)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	})
+}
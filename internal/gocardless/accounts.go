@@ -0,0 +1,229 @@
+package gocardless
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/pkg/errors"
+)
+
+// AccountStatusReady is the status of an account whose data can be fetched
+const AccountStatusReady = "READY"
+
+// Account is the metadata of a linked account
+type Account struct {
+	ID            string `json:"id"`
+	IBAN          string `json:"iban"`
+	InstitutionID string `json:"institution_id"`
+	OwnerName     string `json:"owner_name"`
+	// Status is READY when the account's data can be fetched, e.g. EXPIRED or SUSPENDED otherwise
+	Status string `json:"status"`
+}
+
+// AccountDetails are the details of an account as the bank reports them
+type AccountDetails struct {
+	ResourceID      string `json:"resourceId"`
+	IBAN            string `json:"iban"`
+	Currency        string `json:"currency"`
+	OwnerName       string `json:"ownerName"`
+	Name            string `json:"name"`
+	Product         string `json:"product"`
+	CashAccountType string `json:"cashAccountType"`
+}
+
+// Amount is an amount of money, formatted as a decimal number
+type Amount struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Balance is a balance of an account
+type Balance struct {
+	BalanceAmount Amount `json:"balanceAmount"`
+	BalanceType   string `json:"balanceType"`
+	ReferenceDate string `json:"referenceDate"`
+}
+
+// AccountReference identifies the account of the other party of a transaction, banks give any of its fields
+type AccountReference struct {
+	IBAN      string `json:"iban"`
+	BBAN      string `json:"bban"`
+	PAN       string `json:"pan"`
+	MaskedPAN string `json:"maskedPan"`
+	MSISDN    string `json:"msisdn"`
+	Currency  string `json:"currency"`
+}
+
+// CurrencyExchange is the exchange of a transaction made in another currency than the account's
+type CurrencyExchange struct {
+	SourceCurrency         string `json:"sourceCurrency"`
+	ExchangeRate           string `json:"exchangeRate"`
+	UnitCurrency           string `json:"unitCurrency"`
+	TargetCurrency         string `json:"targetCurrency"`
+	QuotationDate          string `json:"quotationDate"`
+	ContractIdentification string `json:"contractIdentification"`
+	// InstructedAmount is the amount in the currency the transaction was made in, given by some banks
+	InstructedAmount *Amount `json:"instructedAmount"`
+}
+
+// CurrencyExchanges are the exchanges of a transaction, banks give either a single one or a list of them
+type CurrencyExchanges []CurrencyExchange
+
+// UnmarshalJSON unmarshals a single currency exchange or a list of them
+func (c *CurrencyExchanges) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var exchange CurrencyExchange
+		if err := json.Unmarshal(trimmed, &exchange); err != nil {
+			return err
+		}
+		*c = CurrencyExchanges{exchange}
+		return nil
+	}
+
+	var exchanges []CurrencyExchange
+	if err := json.Unmarshal(data, &exchanges); err != nil {
+		return err
+	}
+	*c = exchanges
+	return nil
+}
+
+// Transaction is a booked or pending transaction of an account. Dates are formatted as YYYY-MM-DD and date times
+// as ISO 8601, banks give any of them or none.
+type Transaction struct {
+	TransactionID                          string            `json:"transactionId"`
+	InternalTransactionID                  string            `json:"internalTransactionId"`
+	EntryReference                         string            `json:"entryReference"`
+	EndToEndID                             string            `json:"endToEndId"`
+	MandateID                              string            `json:"mandateId"`
+	CheckID                                string            `json:"checkId"`
+	CreditorID                             string            `json:"creditorId"`
+	BookingDate                            string            `json:"bookingDate"`
+	ValueDate                              string            `json:"valueDate"`
+	BookingDateTime                        string            `json:"bookingDateTime"`
+	ValueDateTime                          string            `json:"valueDateTime"`
+	TransactionAmount                      Amount            `json:"transactionAmount"`
+	CurrencyExchange                       CurrencyExchanges `json:"currencyExchange"`
+	CreditorName                           string            `json:"creditorName"`
+	CreditorAccount                        AccountReference  `json:"creditorAccount"`
+	UltimateCreditor                       string            `json:"ultimateCreditor"`
+	DebtorName                             string            `json:"debtorName"`
+	DebtorAccount                          AccountReference  `json:"debtorAccount"`
+	UltimateDebtor                         string            `json:"ultimateDebtor"`
+	RemittanceInformationUnstructured      string            `json:"remittanceInformationUnstructured"`
+	RemittanceInformationUnstructuredArray []string          `json:"remittanceInformationUnstructuredArray"`
+	RemittanceInformationStructured        string            `json:"remittanceInformationStructured"`
+	RemittanceInformationStructuredArray   []string          `json:"remittanceInformationStructuredArray"`
+	AdditionalInformation                  string            `json:"additionalInformation"`
+	PurposeCode                            string            `json:"purposeCode"`
+	BankTransactionCode                    string            `json:"bankTransactionCode"`
+	ProprietaryBankTransactionCode         string            `json:"proprietaryBankTransactionCode"`
+	MerchantCategoryCode                   string            `json:"merchantCategoryCode"`
+	// BalanceAfterTransaction is the balance of the account after the transaction, nil when the bank doesn't give it
+	BalanceAfterTransaction *Balance `json:"balanceAfterTransaction"`
+}
+
+// Transactions are the transactions of an account, pending ones haven't been booked by the bank yet
+type Transactions struct {
+	Booked  []Transaction `json:"booked"`
+	Pending []Transaction `json:"pending"`
+}
+
+// GetAccount gets the metadata of a linked account, it doesn't count towards the account's daily quota
+func (c *Client) GetAccount(ctx context.Context, accountID string) (Account, error) {
+	seg := newrelic.FromContext(ctx).StartSegment("getAccount")
+	defer seg.End()
+
+	seg.AddAttribute("accountID", accountID)
+
+	var account Account
+	if _, err := c.do(ctx, http.MethodGet, "/accounts/"+url.PathEscape(accountID)+"/", nil, nil, &account); err != nil {
+		return Account{}, errors.Wrap(err, "failed to get account")
+	}
+
+	return account, nil
+}
+
+// GetDetails gets the details of an account
+func (c *Client) GetDetails(ctx context.Context, accountID string) (AccountDetails, error) {
+	seg := newrelic.FromContext(ctx).StartSegment("getAccountDetails")
+	defer seg.End()
+
+	seg.AddAttribute("accountID", accountID)
+
+	var response struct {
+		Account AccountDetails `json:"account"`
+	}
+	if err := c.accountData(ctx, accountID, "details", nil, &response); err != nil {
+		return AccountDetails{}, errors.Wrap(err, "failed to get account details")
+	}
+
+	return response.Account, nil
+}
+
+// ListBalances lists the balances of an account
+func (c *Client) ListBalances(ctx context.Context, accountID string) ([]Balance, error) {
+	seg := newrelic.FromContext(ctx).StartSegment("listBalances")
+	defer seg.End()
+
+	seg.AddAttribute("accountID", accountID)
+
+	var response struct {
+		Balances []Balance `json:"balances"`
+	}
+	if err := c.accountData(ctx, accountID, "balances", nil, &response); err != nil {
+		return nil, errors.Wrap(err, "failed to list balances")
+	}
+
+	return response.Balances, nil
+}
+
+// ListTransactions lists the transactions of an account between the days of from and to
+func (c *Client) ListTransactions(ctx context.Context, accountID string, from, to time.Time) (Transactions, error) {
+	seg := newrelic.FromContext(ctx).StartSegment("listTransactions")
+	defer seg.End()
+
+	seg.AddAttribute("accountID", accountID)
+	seg.AddAttribute("from", from)
+	seg.AddAttribute("to", to)
+
+	query := url.Values{}
+	query.Add("date_from", from.Format(time.DateOnly))
+	query.Add("date_to", to.Format(time.DateOnly))
+
+	var response struct {
+		Transactions Transactions `json:"transactions"`
+	}
+	if err := c.accountData(ctx, accountID, "transactions", query, &response); err != nil {
+		c.logger.WarnContext(ctx, "failed to list transactions", "accountID", accountID, "from", from, "to", to, "error", err)
+		return Transactions{}, errors.Wrap(err, "failed to list transactions")
+	}
+
+	seg.AddAttribute("transactionsCount", len(response.Transactions.Booked)+len(response.Transactions.Pending))
+	c.logger.InfoContext(ctx, "got transactions", "accountID", accountID, "from", from, "to", to, "booked", len(response.Transactions.Booked), "pending", len(response.Transactions.Pending))
+
+	return response.Transactions, nil
+}
+
+// accountData gets a data endpoint of an account into v, keeping the request within the endpoint's daily quota
+func (c *Client) accountData(ctx context.Context, accountID, endpoint string, query url.Values, v any) error {
+	key := quotaKey(accountID, endpoint)
+	if err := c.rateLimits.wait(ctx, key); err != nil {
+		return err
+	}
+
+	header, err := c.do(ctx, http.MethodGet, "/accounts/"+url.PathEscape(accountID)+"/"+endpoint+"/", query, nil, v)
+	c.rateLimits.observe(ctx, key, header)
+
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) && !rateLimitErr.Global {
+		c.rateLimits.exhaust(ctx, key, rateLimitErr.ResetIn)
+	}
+
+	return err
+}
@@ -0,0 +1,57 @@
+package gocardless
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionUnmarshal(t *testing.T) {
+	data := `{
+		"transactionId": "t1",
+		"entryReference": "entry",
+		"checkId": "check",
+		"bookingDate": "2024-04-01",
+		"transactionAmount": {"amount": "-20.00", "currency": "EUR"},
+		"currencyExchange": [{"sourceCurrency": "USD", "exchangeRate": "0.92", "targetCurrency": "EUR"}],
+		"creditorName": "Shop",
+		"creditorAccount": {"iban": "DE89370400440532013000"},
+		"debtorAccount": {"bban": "12345678"},
+		"remittanceInformationUnstructuredArray": ["Invoice 7", "Thank you"],
+		"remittanceInformationStructured": "RF18539007547034",
+		"merchantCategoryCode": "5411",
+		"bankTransactionCode": "PMNT-ICDT-STDO",
+		"proprietaryBankTransactionCode": "TRANSFER",
+		"balanceAfterTransaction": {"balanceAmount": {"amount": "980.50", "currency": "EUR"}, "balanceType": "closingBooked"}
+	}`
+
+	var transaction Transaction
+	assert.NoError(t, json.Unmarshal([]byte(data), &transaction))
+
+	assert.Equal(t, "entry", transaction.EntryReference)
+	assert.Equal(t, "check", transaction.CheckID)
+	assert.Equal(t, CurrencyExchanges{{SourceCurrency: "USD", ExchangeRate: "0.92", TargetCurrency: "EUR"}}, transaction.CurrencyExchange)
+	assert.Equal(t, "DE89370400440532013000", transaction.CreditorAccount.IBAN)
+	assert.Equal(t, "12345678", transaction.DebtorAccount.BBAN)
+	assert.Equal(t, []string{"Invoice 7", "Thank you"}, transaction.RemittanceInformationUnstructuredArray)
+	assert.Equal(t, "RF18539007547034", transaction.RemittanceInformationStructured)
+	assert.Equal(t, "5411", transaction.MerchantCategoryCode)
+	assert.Equal(t, "PMNT-ICDT-STDO", transaction.BankTransactionCode)
+	assert.Equal(t, "TRANSFER", transaction.ProprietaryBankTransactionCode)
+	if assert.NotNil(t, transaction.BalanceAfterTransaction) {
+		assert.Equal(t, Amount{Amount: "980.50", Currency: "EUR"}, transaction.BalanceAfterTransaction.BalanceAmount)
+	}
+
+	t.Run("single currency exchange", func(t *testing.T) {
+		var transaction Transaction
+		assert.NoError(t, json.Unmarshal([]byte(`{"currencyExchange": {"sourceCurrency": "GBP", "exchangeRate": "1.17"}}`), &transaction))
+		assert.Equal(t, CurrencyExchanges{{SourceCurrency: "GBP", ExchangeRate: "1.17"}}, transaction.CurrencyExchange)
+	})
+
+	t.Run("no currency exchange", func(t *testing.T) {
+		var transaction Transaction
+		assert.NoError(t, json.Unmarshal([]byte(`{"currencyExchange": null}`), &transaction))
+		assert.Empty(t, transaction.CurrencyExchange)
+	})
+}
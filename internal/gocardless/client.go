@@ -0,0 +1,343 @@
+// Package gocardless is a client of the GoCardless Bank Account Data API. It logs in and renews tokens on its own,
+// retries requests failing for a transient reason and keeps requests within the daily quota of accounts.
+package gocardless
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/pkg/errors"
+
+	"psmarcin.github.com/open-ynab-sync/internal/retry"
+)
+
+const (
+	// DefaultBaseURL is the base URL of the GoCardless Bank Account Data API
+	DefaultBaseURL = "https://bankaccountdata.gocardless.com/api/v2"
+	// DefaultTimeout is the timeout of a request when none is configured
+	DefaultTimeout = 20 * time.Second
+)
+
+// tokenExpiryMargin is how long before its expiry a token is treated as expired, so it doesn't expire mid-request
+const tokenExpiryMargin = time.Minute
+
+// Config configures a Client
+type Config struct {
+	SecretID  string
+	SecretKey string
+	// BaseURL is the base URL of the API, DefaultBaseURL when it's empty
+	BaseURL string
+	// Timeout is the timeout of every request, DefaultTimeout when it's 0
+	Timeout time.Duration
+	// Retry decides how requests failing for a transient reason are sent again, they're sent once when it's zero
+	Retry retry.Policy
+	// RateLimits keeps the daily quota of accounts between runs, quotas aren't tracked when it's nil
+	RateLimits RateLimitStorer
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+}
+
+// Client is a client of the API, it's safe for concurrent use and requests share its tokens
+type Client struct {
+	secretID  string
+	secretKey string
+	// baseURL is the base URL of the API, without a trailing slash
+	baseURL    string
+	httpClient *http.Client
+	retry      retry.Policy
+	// rateLimits keeps requests within the daily quota of accounts, nil when it isn't tracked
+	rateLimits *rateLimitTracker
+	logger     *slog.Logger
+	now        func() time.Time
+
+	// mu guards the tokens
+	mu                    sync.Mutex
+	accessToken           string
+	accessTokenExpiresAt  time.Time
+	refreshToken          string
+	refreshTokenExpiresAt time.Time
+}
+
+// New creates a client configured by config
+func New(config Config) *Client {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	c := &Client{
+		secretID:   config.SecretID,
+		secretKey:  config.SecretKey,
+		baseURL:    strings.TrimSuffix(config.BaseURL, "/"),
+		httpClient: &http.Client{Timeout: config.Timeout},
+		retry:      config.Retry,
+		logger:     config.Logger,
+		now:        time.Now,
+	}
+	if config.RateLimits != nil {
+		c.rateLimits = newRateLimitTracker(config.RateLimits, config.Logger)
+	}
+
+	return c
+}
+
+// Error is returned when the API answers a request with an error
+type Error struct {
+	StatusCode int
+	Status     string
+	Summary    string `json:"summary"`
+	Detail     string `json:"detail"`
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Summary != "" && e.Detail != "":
+		return fmt.Sprintf("%s: %s: %s", e.Status, e.Summary, e.Detail)
+	case e.Summary != "":
+		return fmt.Sprintf("%s: %s", e.Status, e.Summary)
+	default:
+		return e.Status
+	}
+}
+
+// RateLimitError is returned when GoCardless rejects a request because a rate limit was exceeded
+type RateLimitError struct {
+	Status  string
+	ResetIn time.Duration
+	// Global is set when the limit isn't the account's daily quota but the limit of all requests made with the credentials
+	Global bool
+}
+
+func (e *RateLimitError) Error() string {
+	if e.ResetIn > 0 {
+		return fmt.Sprintf("too many requests: %s, resets in %s", e.Status, e.ResetIn)
+	}
+	return fmt.Sprintf("too many requests: %s", e.Status)
+}
+
+type tokenResponse struct {
+	Access         string `json:"access"`
+	AccessExpires  int    `json:"access_expires"`
+	Refresh        string `json:"refresh"`
+	RefreshExpires int    `json:"refresh_expires"`
+}
+
+// LogIn makes sure the client holds a valid access token. The current one is reused until it expires,
+// then it's refreshed, and only when the refresh token expired too a new pair of tokens is requested.
+func (c *Client) LogIn(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.accessToken != "" && now.Add(tokenExpiryMargin).Before(c.accessTokenExpiresAt) {
+		return nil
+	}
+
+	if c.refreshToken != "" && now.Add(tokenExpiryMargin).Before(c.refreshTokenExpiresAt) {
+		err := c.refresh(ctx)
+		if err == nil {
+			return nil
+		}
+		c.logger.WarnContext(ctx, "failed to refresh token, logging in again", "error", err)
+	}
+
+	return c.logIn(ctx)
+}
+
+// RefreshToken exchanges the refresh token for a new access token
+func (c *Client) RefreshToken(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.refresh(ctx)
+}
+
+// logIn requests a new pair of tokens, c.mu must be held
+func (c *Client) logIn(ctx context.Context) error {
+	seg := newrelic.FromContext(ctx).StartSegment("goCardlessLogIn")
+	defer seg.End()
+
+	issuedAt := c.now()
+	var tokens tokenResponse
+	body := map[string]string{"secret_id": c.secretID, "secret_key": c.secretKey}
+	if _, err := c.send(ctx, c.retry, http.MethodPost, "/token/new/", nil, body, "", &tokens); err != nil {
+		return errors.Wrap(err, "failed to log in")
+	}
+
+	c.accessToken = tokens.Access
+	c.accessTokenExpiresAt = issuedAt.Add(time.Duration(tokens.AccessExpires) * time.Second)
+	c.refreshToken = tokens.Refresh
+	c.refreshTokenExpiresAt = issuedAt.Add(time.Duration(tokens.RefreshExpires) * time.Second)
+
+	c.logger.InfoContext(ctx, "logged in", "access_expires_at", c.accessTokenExpiresAt, "refresh_expires_at", c.refreshTokenExpiresAt)
+
+	return nil
+}
+
+// refresh exchanges the refresh token for a new access token, c.mu must be held
+func (c *Client) refresh(ctx context.Context) error {
+	seg := newrelic.FromContext(ctx).StartSegment("goCardlessRefreshToken")
+	defer seg.End()
+
+	issuedAt := c.now()
+	var tokens tokenResponse
+	body := map[string]string{"refresh": c.refreshToken}
+	if _, err := c.send(ctx, c.retry, http.MethodPost, "/token/refresh/", nil, body, "", &tokens); err != nil {
+		return errors.Wrap(err, "failed to refresh token")
+	}
+
+	c.accessToken = tokens.Access
+	c.accessTokenExpiresAt = issuedAt.Add(time.Duration(tokens.AccessExpires) * time.Second)
+
+	c.logger.InfoContext(ctx, "got new access token", "access_expires_at", c.accessTokenExpiresAt)
+
+	return nil
+}
+
+// expireAccessToken marks the access token as expired, unless another request already replaced it
+func (c *Client) expireAccessToken(accessToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken == accessToken {
+		c.accessTokenExpiresAt = time.Time{}
+	}
+}
+
+// do sends an authorized request with a valid access token and decodes the response into v when it's set,
+// returning the headers of the response. When GoCardless rejects the token anyway, e.g. because it was revoked,
+// the token is renewed and the request is sent once more.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, v any) (http.Header, error) {
+	return c.doWithPolicy(ctx, c.retry, method, path, query, body, v)
+}
+
+// create sends an authorized POST creating a resource and decodes the response into v. Unlike other requests it
+// isn't retried on transient failures: a request that timed out may still have created the resource, so sending it
+// again could create it twice.
+func (c *Client) create(ctx context.Context, path string, body, v any) error {
+	once := c.retry
+	once.MaxAttempts = 1
+	_, err := c.doWithPolicy(ctx, once, http.MethodPost, path, nil, body, v)
+	return err
+}
+
+// doWithPolicy is do retrying transient failures according to policy
+func (c *Client) doWithPolicy(ctx context.Context, policy retry.Policy, method, path string, query url.Values, body, v any) (http.Header, error) {
+	for attempt := 1; ; attempt++ {
+		if err := c.LogIn(ctx); err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		accessToken := c.accessToken
+		c.mu.Unlock()
+
+		header, err := c.send(ctx, policy, method, path, query, body, accessToken, v)
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || attempt > 1 {
+			return header, err
+		}
+
+		c.logger.WarnContext(ctx, "access token rejected, renewing it", "path", path)
+		c.expireAccessToken(accessToken)
+	}
+}
+
+// send sends a request, retrying transient failures according to policy, and decodes the response into v when it's
+// set. Error responses are returned as *Error, or *RateLimitError when a rate limit was exceeded, with the headers of
+// the response.
+func (c *Client) send(ctx context.Context, policy retry.Policy, method, path string, query url.Values, body any, accessToken string, v any) (http.Header, error) {
+	var content []byte
+	if body != nil {
+		var err error
+		content, err = json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal request body")
+		}
+	}
+
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	response, err := policy.Do(ctx, c.httpClient, func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(content))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create request: %s %s", method, path)
+		}
+		request.Header.Set("Accept", "application/json")
+		if body != nil {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		if accessToken != "" {
+			request.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		return request, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to make request: %s %s", method, path)
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode == http.StatusTooManyRequests {
+		return response.Header, c.rateLimitError(ctx, path, response)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: response.StatusCode, Status: response.Status}
+		// The body only adds detail, an error without the API's format is still an *Error
+		_ = json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(apiErr)
+		return response.Header, apiErr
+	}
+
+	if v != nil {
+		if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+			return response.Header, errors.Wrapf(err, "failed to parse response: %s %s", method, path)
+		}
+	}
+
+	return response.Header, nil
+}
+
+// rateLimitError describes the rate limit a response rejected with 429 exceeded
+func (c *Client) rateLimitError(ctx context.Context, path string, response *http.Response) error {
+	resetIn := time.Duration(0)
+	accountReset := response.Header[http.CanonicalHeaderKey(rateLimitResetHeader)]
+	if len(accountReset) > 0 && accountReset[0] != "0" {
+		var err error
+		resetIn, err = time.ParseDuration(accountReset[0] + "s")
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse rate limit reset header: %s", accountReset[0])
+		}
+	}
+
+	c.logger.WarnContext(
+		ctx,
+		"too many requests",
+		"path", path,
+		"status", response.Status,
+		"http_x_ratelimit_limit", response.Header.Get("http_x_ratelimit_limit"),
+		"http_x_ratelimit_remaining", response.Header.Get("http_x_ratelimit_remaining"),
+		"http_x_ratelimit_reset", response.Header.Get("http_x_ratelimit_reset"),
+		rateLimitLimitHeader, response.Header.Get(rateLimitLimitHeader),
+		rateLimitRemainingHeader, response.Header.Get(rateLimitRemainingHeader),
+		rateLimitResetHeader, response.Header.Get(rateLimitResetHeader),
+		"reset_in", resetIn,
+	)
+
+	return &RateLimitError{Status: response.Status, ResetIn: resetIn, Global: len(accountReset) == 0}
+}
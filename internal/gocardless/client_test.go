@@ -0,0 +1,290 @@
+package gocardless
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"psmarcin.github.com/open-ynab-sync/internal/fakegocardless"
+	"psmarcin.github.com/open-ynab-sync/internal/retry"
+)
+
+// roundTripperFunc serves requests of a test client without a network
+type roundTripperFunc func(*http.Request) *http.Response
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r), nil
+}
+
+// newTestClient returns a client whose requests are answered by handler and which uses *now as the current time
+func newTestClient(handler http.HandlerFunc, now *time.Time) *Client {
+	gc := New(Config{SecretID: "id", SecretKey: "secret"})
+	gc.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) *http.Response {
+		recorder := httptest.NewRecorder()
+		handler(recorder, r)
+		return recorder.Result()
+	})}
+	gc.now = func() time.Time { return *now }
+	return gc
+}
+
+func TestClientTokens(t *testing.T) {
+	now := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	calls := map[string]int{}
+	rejectNextToken := false
+	gc := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		switch r.URL.Path {
+		case "/api/v2/token/new/":
+			_, _ = io.WriteString(w, `{"access":"access1","access_expires":86400,"refresh":"refresh1","refresh_expires":2592000}`)
+		case "/api/v2/token/refresh/":
+			_, _ = io.WriteString(w, `{"access":"access2","access_expires":86400}`)
+		case "/api/v2/accounts/aaa/":
+			if rejectNextToken {
+				rejectNextToken = false
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Bearer access"))
+			_, _ = io.WriteString(w, `{"id":"aaa","status":"READY"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}, &now)
+	ctx := context.Background()
+
+	t.Run("reuses the access token", func(t *testing.T) {
+		assert.NoError(t, gc.LogIn(ctx))
+		assert.NoError(t, gc.LogIn(ctx))
+		_, err := gc.GetAccount(ctx, "aaa")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls["/api/v2/token/new/"])
+		assert.Equal(t, 0, calls["/api/v2/token/refresh/"])
+	})
+
+	t.Run("refreshes an expired access token", func(t *testing.T) {
+		now = now.Add(24 * time.Hour)
+		assert.NoError(t, gc.LogIn(ctx))
+		assert.Equal(t, 1, calls["/api/v2/token/new/"])
+		assert.Equal(t, 1, calls["/api/v2/token/refresh/"])
+		assert.Equal(t, "access2", gc.accessToken)
+	})
+
+	t.Run("refreshes a rejected access token and retries", func(t *testing.T) {
+		rejectNextToken = true
+		account, err := gc.GetAccount(ctx, "aaa")
+		assert.NoError(t, err)
+		assert.Equal(t, AccountStatusReady, account.Status)
+		assert.Equal(t, 2, calls["/api/v2/token/refresh/"])
+		assert.Equal(t, 3, calls["/api/v2/accounts/aaa/"])
+	})
+
+	t.Run("logs in again when the refresh token expired", func(t *testing.T) {
+		now = now.Add(30 * 24 * time.Hour)
+		assert.NoError(t, gc.LogIn(ctx))
+		assert.Equal(t, 2, calls["/api/v2/token/new/"])
+		assert.Equal(t, 2, calls["/api/v2/token/refresh/"])
+		assert.Equal(t, "access1", gc.accessToken)
+	})
+}
+
+func TestClientRetries(t *testing.T) {
+	now := time.Now().UTC()
+	calls := map[string]int{}
+	gc := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		if r.URL.Path == "/api/v2/token/new/" {
+			_, _ = io.WriteString(w, `{"access":"access1","access_expires":86400,"refresh":"refresh1","refresh_expires":2592000}`)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, &now)
+	gc.retry = retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	ctx := context.Background()
+
+	t.Run("retries reads", func(t *testing.T) {
+		_, err := gc.GetRequisition(ctx, "req")
+		assert.Error(t, err)
+		assert.Equal(t, 3, calls["/api/v2/requisitions/req/"])
+	})
+
+	t.Run("doesn't retry creating agreements and requisitions", func(t *testing.T) {
+		// The server may have created them before failing, a retry would create duplicates
+		_, err := gc.CreateAgreement(ctx, AgreementRequest{InstitutionID: "bank"})
+		var apiErr *Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 1, calls["/api/v2/agreements/enduser/"])
+
+		_, err = gc.CreateRequisition(ctx, RequisitionRequest{InstitutionID: "bank"})
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls["/api/v2/requisitions/"])
+	})
+}
+
+func TestClientListTransactionsRateLimit(t *testing.T) {
+	now := time.Now().UTC()
+	store := newMemoryStore()
+
+	requests := 0
+	gc := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/token/new/" {
+			_, _ = io.WriteString(w, `{"access":"access1","access_expires":86400,"refresh":"refresh1","refresh_expires":2592000}`)
+			return
+		}
+		requests++
+		w.Header().Set(rateLimitLimitHeader, "4")
+		w.Header().Set(rateLimitRemainingHeader, "0")
+		w.Header().Set(rateLimitResetHeader, "7200")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, &now)
+	gc.rateLimits = newRateLimitTracker(store, gc.logger)
+	ctx := context.Background()
+
+	_, err := gc.ListTransactions(ctx, "aaa", now.AddDate(0, 0, -1), now)
+	var rateLimitErr *RateLimitError
+	assert.ErrorAs(t, err, &rateLimitErr)
+	assert.False(t, rateLimitErr.Global)
+
+	// The used up quota is known, so the next request isn't sent
+	_, err = gc.ListTransactions(ctx, "aaa", now.AddDate(0, 0, -1), now)
+	assert.ErrorAs(t, err, &rateLimitErr)
+	assert.Equal(t, 1, requests)
+
+	limit, err := store.RateLimit("aaa")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, limit.Limit)
+	assert.Equal(t, 0, limit.Remaining)
+}
+
+func TestClientFakeServer(t *testing.T) {
+	now := time.Now().UTC()
+	day := func(daysAgo int) string { return now.AddDate(0, 0, -daysAgo).Format(time.DateOnly) }
+
+	fake := fakegocardless.New("id", "secret")
+	fake.AddInstitution(fakegocardless.Institution{ID: "BANK", Name: "Bank", Countries: []string{"PL"}})
+	fake.AddAccount(fakegocardless.Account{
+		ID:            "aaa",
+		InstitutionID: "BANK",
+		Name:          "Checking",
+		Currency:      "EUR",
+		Booked: []fakegocardless.Transaction{
+			{TransactionID: "old", BookingDate: day(40), ValueDate: day(40), TransactionAmount: fakegocardless.Amount{Amount: "-1.00", Currency: "EUR"}},
+			{TransactionID: "t1", BookingDate: day(3), ValueDate: day(3), TransactionAmount: fakegocardless.Amount{Amount: "-12.34", Currency: "EUR"}, CreditorName: "Shop", CreditorAccount: &fakegocardless.AccountReference{IBAN: "DE89370400440532013000"}, MerchantCategoryCode: "5411"},
+		},
+		Pending: []fakegocardless.Transaction{
+			{ValueDate: day(0), TransactionAmount: fakegocardless.Amount{Amount: "5.00", Currency: "EUR"}, DebtorName: "Friend"},
+		},
+		Balances: []fakegocardless.Balance{
+			{BalanceAmount: fakegocardless.Amount{Amount: "100.00", Currency: "EUR"}, BalanceType: "expected"},
+		},
+		DailyLimit: 2,
+	})
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store := newMemoryStore()
+	gc := New(Config{SecretID: "id", SecretKey: "secret", BaseURL: server.URL + fakegocardless.APIPath, RateLimits: store})
+	ctx := context.Background()
+
+	t.Run("links accounts of an institution", func(t *testing.T) {
+		institutions, err := gc.ListInstitutions(ctx, "pl")
+		assert.NoError(t, err)
+		assert.Equal(t, []Institution{{ID: "BANK", Name: "Bank", Countries: []string{"PL"}}}, institutions)
+
+		agreement, err := gc.CreateAgreement(ctx, AgreementRequest{InstitutionID: "BANK", MaxHistoricalDays: 90, AccessValidForDays: 180, AccessScope: []string{"transactions"}})
+		assert.NoError(t, err)
+		assert.Equal(t, 90, agreement.MaxHistoricalDays)
+
+		requisition, err := gc.CreateRequisition(ctx, RequisitionRequest{Redirect: "http://localhost/callback", InstitutionID: "BANK", Agreement: agreement.ID})
+		assert.NoError(t, err)
+		// The end user authorizes the requisition, the redirect to the callback isn't followed
+		browser := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+		response, err := browser.Get(requisition.Link)
+		if assert.NoError(t, err) {
+			_ = response.Body.Close()
+		}
+
+		requisition, err = gc.GetRequisition(ctx, requisition.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, RequisitionStatusLinked, requisition.Status)
+		assert.Equal(t, []string{"aaa"}, requisition.Accounts)
+
+		requisitions, err := gc.ListRequisitions(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, requisitions, 1)
+	})
+
+	t.Run("reports errors of the API", func(t *testing.T) {
+		_, err := gc.GetInstitution(ctx, "UNKNOWN")
+		var apiErr *Error
+		if assert.ErrorAs(t, err, &apiErr) {
+			assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+			assert.Equal(t, "Institution not found", apiErr.Detail)
+		}
+	})
+
+	t.Run("gets the data of an account", func(t *testing.T) {
+		transactions, err := gc.ListTransactions(ctx, "aaa", now.AddDate(0, 0, -20), now)
+		assert.NoError(t, err)
+		if assert.Len(t, transactions.Booked, 1) {
+			assert.Equal(t, "t1", transactions.Booked[0].TransactionID)
+			assert.Equal(t, "Shop", transactions.Booked[0].CreditorName)
+			assert.Equal(t, "DE89370400440532013000", transactions.Booked[0].CreditorAccount.IBAN)
+			assert.Equal(t, "5411", transactions.Booked[0].MerchantCategoryCode)
+		}
+		if assert.Len(t, transactions.Pending, 1) {
+			assert.Equal(t, Amount{Amount: "5.00", Currency: "EUR"}, transactions.Pending[0].TransactionAmount)
+		}
+
+		details, err := gc.GetDetails(ctx, "aaa")
+		assert.NoError(t, err)
+		assert.Equal(t, "EUR", details.Currency)
+		assert.Equal(t, "Checking", details.Name)
+
+		balances, err := gc.ListBalances(ctx, "aaa")
+		assert.NoError(t, err)
+		assert.Len(t, balances, 1)
+
+		// Every endpoint has a quota of its own
+		limit, err := store.RateLimit("aaa")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, limit.Remaining)
+		limit, err = store.RateLimit("aaa:details")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, limit.Remaining)
+	})
+
+	t.Run("renews a revoked access token", func(t *testing.T) {
+		fake.ExpireAccessTokens()
+		account, err := gc.GetAccount(ctx, "aaa")
+		assert.NoError(t, err)
+		assert.Equal(t, AccountStatusReady, account.Status)
+		assert.Equal(t, "BANK", account.InstitutionID)
+	})
+
+	t.Run("stops at the daily quota of the account", func(t *testing.T) {
+		_, err := gc.ListTransactions(ctx, "aaa", now.AddDate(0, 0, -20), now)
+		assert.NoError(t, err)
+
+		requests := len(fake.Requests())
+		_, err = gc.ListTransactions(ctx, "aaa", now.AddDate(0, 0, -20), now)
+		var rateLimitErr *RateLimitError
+		assert.ErrorAs(t, err, &rateLimitErr)
+		assert.Equal(t, requests, len(fake.Requests()), "the request over the known quota isn't sent")
+	})
+
+	t.Run("reports the rate limit of all requests", func(t *testing.T) {
+		fake.Throttle(1)
+		_, err := gc.ListTransactions(ctx, "bbb", now.AddDate(0, 0, -20), now)
+		var rateLimitErr *RateLimitError
+		if assert.ErrorAs(t, err, &rateLimitErr) {
+			assert.True(t, rateLimitErr.Global)
+		}
+	})
+}
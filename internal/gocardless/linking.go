@@ -0,0 +1,143 @@
+package gocardless
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// RequisitionStatusLinked is the status of a requisition the end user authorized, its accounts are linked
+const RequisitionStatusLinked = "LN"
+
+// Institution is a bank accounts can be linked from
+type Institution struct {
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	BIC                  string   `json:"bic"`
+	TransactionTotalDays string   `json:"transaction_total_days"`
+	Countries            []string `json:"countries"`
+	Logo                 string   `json:"logo"`
+}
+
+// AgreementRequest describes the access to an institution's accounts an end user agreement grants
+type AgreementRequest struct {
+	InstitutionID      string   `json:"institution_id"`
+	MaxHistoricalDays  int      `json:"max_historical_days"`
+	AccessValidForDays int      `json:"access_valid_for_days"`
+	AccessScope        []string `json:"access_scope"`
+}
+
+// Agreement is an end user agreement
+type Agreement struct {
+	ID                 string   `json:"id"`
+	InstitutionID      string   `json:"institution_id"`
+	MaxHistoricalDays  int      `json:"max_historical_days"`
+	AccessValidForDays int      `json:"access_valid_for_days"`
+	AccessScope        []string `json:"access_scope"`
+}
+
+// RequisitionRequest describes a requisition, the end user is sent back to Redirect after authorizing it
+type RequisitionRequest struct {
+	Redirect      string `json:"redirect"`
+	InstitutionID string `json:"institution_id"`
+	Reference     string `json:"reference,omitempty"`
+	Agreement     string `json:"agreement,omitempty"`
+	UserLanguage  string `json:"user_language,omitempty"`
+}
+
+// Requisition links the accounts an end user authorizes at their bank, the end user authorizes it at Link
+type Requisition struct {
+	ID            string   `json:"id"`
+	Status        string   `json:"status"`
+	InstitutionID string   `json:"institution_id"`
+	Agreement     string   `json:"agreement"`
+	Reference     string   `json:"reference"`
+	Link          string   `json:"link"`
+	Accounts      []string `json:"accounts"`
+}
+
+// ListInstitutions lists the institutions of a country, given as an ISO 3166 code
+func (c *Client) ListInstitutions(ctx context.Context, country string) ([]Institution, error) {
+	query := url.Values{}
+	query.Add("country", country)
+
+	var institutions []Institution
+	if _, err := c.do(ctx, http.MethodGet, "/institutions/", query, nil, &institutions); err != nil {
+		return nil, errors.Wrap(err, "failed to list institutions")
+	}
+
+	return institutions, nil
+}
+
+// GetInstitution gets an institution
+func (c *Client) GetInstitution(ctx context.Context, institutionID string) (Institution, error) {
+	var institution Institution
+	if _, err := c.do(ctx, http.MethodGet, "/institutions/"+url.PathEscape(institutionID)+"/", nil, nil, &institution); err != nil {
+		return Institution{}, errors.Wrap(err, "failed to get institution")
+	}
+
+	return institution, nil
+}
+
+// CreateAgreement creates an end user agreement
+func (c *Client) CreateAgreement(ctx context.Context, request AgreementRequest) (Agreement, error) {
+	var agreement Agreement
+	if err := c.create(ctx, "/agreements/enduser/", request, &agreement); err != nil {
+		return Agreement{}, errors.Wrap(err, "failed to create agreement")
+	}
+
+	c.logger.InfoContext(ctx, "created agreement", "id", agreement.ID)
+	return agreement, nil
+}
+
+// CreateRequisition creates a requisition
+func (c *Client) CreateRequisition(ctx context.Context, request RequisitionRequest) (Requisition, error) {
+	var requisition Requisition
+	if err := c.create(ctx, "/requisitions/", request, &requisition); err != nil {
+		return Requisition{}, errors.Wrap(err, "failed to create requisition")
+	}
+
+	c.logger.InfoContext(ctx, "created requisition", "id", requisition.ID)
+	return requisition, nil
+}
+
+// GetRequisition gets a requisition
+func (c *Client) GetRequisition(ctx context.Context, requisitionID string) (Requisition, error) {
+	var requisition Requisition
+	if _, err := c.do(ctx, http.MethodGet, "/requisitions/"+url.PathEscape(requisitionID)+"/", nil, nil, &requisition); err != nil {
+		return Requisition{}, errors.Wrap(err, "failed to get requisition")
+	}
+
+	c.logger.InfoContext(ctx, "requisition status", "id", requisition.ID, "status", requisition.Status, "accounts", len(requisition.Accounts))
+	return requisition, nil
+}
+
+// ListRequisitions lists all requisitions, following the pages of the results
+func (c *Client) ListRequisitions(ctx context.Context) ([]Requisition, error) {
+	var requisitions []Requisition
+	query := url.Values{}
+	for {
+		var page struct {
+			Next    *string       `json:"next"`
+			Results []Requisition `json:"results"`
+		}
+		if _, err := c.do(ctx, http.MethodGet, "/requisitions/", query, nil, &page); err != nil {
+			return nil, errors.Wrap(err, "failed to list requisitions")
+		}
+		requisitions = append(requisitions, page.Results...)
+
+		if page.Next == nil || *page.Next == "" {
+			break
+		}
+		next, err := url.Parse(*page.Next)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse next page: %s", *page.Next)
+		}
+		query = next.Query()
+	}
+
+	c.logger.InfoContext(ctx, "listed requisitions", "count", len(requisitions))
+	return requisitions, nil
+}
@@ -0,0 +1,173 @@
+package gocardless
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// rateLimitMaxWait is the longest a request waits for the account's quota to reset instead of being skipped
+	rateLimitMaxWait = time.Minute
+	// rateLimitLimitHeader, rateLimitRemainingHeader and rateLimitResetHeader describe the daily quota of an account,
+	// the reset is the number of seconds until the quota is restored
+	rateLimitLimitHeader     = "http_x_ratelimit_account_success_limit"
+	rateLimitRemainingHeader = "http_x_ratelimit_account_success_remaining"
+	rateLimitResetHeader     = "http_x_ratelimit_account_success_reset"
+)
+
+// RateLimitStorer persists the daily quotas of accounts between runs, an account has one for each data endpoint
+type RateLimitStorer interface {
+	RateLimit(key string) (RateLimit, error)
+	SaveRateLimit(key string, limit RateLimit) error
+}
+
+// RateLimit is the last known daily quota of a GoCardless account
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Exhausted reports whether the quota is used up at now
+func (r RateLimit) Exhausted(now time.Time) bool {
+	return !r.UpdatedAt.IsZero() && r.Remaining <= 0 && now.Before(r.ResetAt)
+}
+
+// rateLimitTracker records the quota of every account from GoCardless responses, so requests that would be rejected
+// aren't sent and don't count against the quota again. Each data endpoint of an account has a quota of its own,
+// they're kept under the keys quotaKey returns.
+type rateLimitTracker struct {
+	mu     sync.Mutex
+	store  RateLimitStorer
+	logger *slog.Logger
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+func newRateLimitTracker(store RateLimitStorer, logger *slog.Logger) *rateLimitTracker {
+	return &rateLimitTracker{
+		store:  store,
+		logger: logger,
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+}
+
+// wait returns a *RateLimitError when the quota of key is used up. When the quota resets within rateLimitMaxWait
+// it waits for the reset instead.
+func (t *rateLimitTracker) wait(ctx context.Context, key string) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	limit, err := t.store.RateLimit(key)
+	t.mu.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "failed to load rate limit")
+	}
+
+	now := t.now()
+	if !limit.Exhausted(now) {
+		return nil
+	}
+
+	resetIn := limit.ResetAt.Sub(now)
+	if resetIn > rateLimitMaxWait {
+		return &RateLimitError{Status: "daily quota of the account used up", ResetIn: resetIn.Round(time.Second)}
+	}
+
+	t.logger.InfoContext(ctx, "waiting for rate limit reset", "quota", key, "reset_in", resetIn)
+	return t.sleep(ctx, resetIn)
+}
+
+// observe records the quota of key reported by the headers of a response to one of its requests
+func (t *rateLimitTracker) observe(ctx context.Context, key string, header http.Header) {
+	if t == nil {
+		return
+	}
+
+	limit, ok := parseRateLimit(header, t.now())
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.store.SaveRateLimit(key, limit); err != nil {
+		t.logger.WarnContext(ctx, "failed to save rate limit", "quota", key, "error", err)
+	}
+}
+
+// exhaust records that the quota of key is used up until resetIn passes
+func (t *rateLimitTracker) exhaust(ctx context.Context, key string, resetIn time.Duration) {
+	if t == nil || resetIn <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	limit, err := t.store.RateLimit(key)
+	if err != nil {
+		t.logger.WarnContext(ctx, "failed to load rate limit", "quota", key, "error", err)
+	}
+	now := t.now()
+	limit.Remaining = 0
+	limit.ResetAt = now.Add(resetIn)
+	limit.UpdatedAt = now
+
+	if err := t.store.SaveRateLimit(key, limit); err != nil {
+		t.logger.WarnContext(ctx, "failed to save rate limit", "quota", key, "error", err)
+	}
+}
+
+// quotaKey is the key the quota of an account's data endpoint is kept under. Transactions, the only quota
+// tracked before the other endpoints were, keep the plain account ID so quotas stored by earlier runs still apply.
+func quotaKey(accountID, endpoint string) string {
+	if endpoint == "transactions" {
+		return accountID
+	}
+	return accountID + ":" + endpoint
+}
+
+// parseRateLimit reads the account quota from response headers, ok is false when they don't describe one
+func parseRateLimit(header http.Header, now time.Time) (RateLimit, bool) {
+	remaining, err := strconv.Atoi(header.Get(rateLimitRemainingHeader))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	resetSeconds, err := strconv.Atoi(header.Get(rateLimitResetHeader))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	limit, _ := strconv.Atoi(header.Get(rateLimitLimitHeader))
+
+	return RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		ResetAt:   now.Add(time.Duration(resetSeconds) * time.Second),
+		UpdatedAt: now,
+	}, true
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
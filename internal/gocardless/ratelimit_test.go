@@ -0,0 +1,107 @@
+package gocardless
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore keeps quotas in memory, like a state file that outlives the client
+type memoryStore struct {
+	mu     sync.Mutex
+	limits map[string]RateLimit
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{limits: make(map[string]RateLimit)}
+}
+
+func (m *memoryStore) RateLimit(key string) (RateLimit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.limits[key], nil
+}
+
+func (m *memoryStore) SaveRateLimit(key string, limit RateLimit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.limits[key] = limit
+	return nil
+}
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set(rateLimitLimitHeader, "4")
+	header.Set(rateLimitRemainingHeader, "3")
+	header.Set(rateLimitResetHeader, "3600")
+	limit, ok := parseRateLimit(header, now)
+	assert.True(t, ok)
+	assert.Equal(t, RateLimit{Limit: 4, Remaining: 3, ResetAt: now.Add(time.Hour), UpdatedAt: now}, limit)
+
+	_, ok = parseRateLimit(http.Header{}, now)
+	assert.False(t, ok)
+}
+
+func TestRateLimitTracker(t *testing.T) {
+	now := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	store := newMemoryStore()
+	ctx := context.Background()
+
+	newTracker := func(t *testing.T) (*rateLimitTracker, *[]time.Duration) {
+		var slept []time.Duration
+		tracker := newRateLimitTracker(store, slog.Default())
+		tracker.now = func() time.Time { return now }
+		tracker.sleep = func(ctx context.Context, d time.Duration) error {
+			slept = append(slept, d)
+			return nil
+		}
+		return tracker, &slept
+	}
+
+	t.Run("allows requests while quota remains", func(t *testing.T) {
+		tracker, _ := newTracker(t)
+		header := http.Header{}
+		header.Set(rateLimitRemainingHeader, "1")
+		header.Set(rateLimitResetHeader, "3600")
+		tracker.observe(ctx, "aaa", header)
+
+		assert.NoError(t, tracker.wait(ctx, "aaa"))
+	})
+
+	t.Run("skips accounts with used up quota after a restart", func(t *testing.T) {
+		tracker, _ := newTracker(t)
+		tracker.exhaust(ctx, "aaa", 2*time.Hour)
+
+		restarted, slept := newTracker(t)
+		var rateLimitErr *RateLimitError
+		assert.ErrorAs(t, restarted.wait(ctx, "aaa"), &rateLimitErr)
+		assert.Equal(t, 2*time.Hour, rateLimitErr.ResetIn)
+		assert.Empty(t, *slept)
+		assert.NoError(t, restarted.wait(ctx, "bbb"))
+	})
+
+	t.Run("waits for a reset that is close", func(t *testing.T) {
+		tracker, slept := newTracker(t)
+		tracker.exhaust(ctx, "ccc", 30*time.Second)
+
+		assert.NoError(t, tracker.wait(ctx, "ccc"))
+		assert.Equal(t, []time.Duration{30 * time.Second}, *slept)
+	})
+
+	t.Run("allows requests after the reset", func(t *testing.T) {
+		tracker, _ := newTracker(t)
+		tracker.exhaust(ctx, "ddd", time.Hour)
+		now = now.Add(time.Hour)
+
+		assert.NoError(t, tracker.wait(ctx, "ddd"))
+	})
+}
@@ -0,0 +1,160 @@
+// Package retry sends HTTP requests again when they fail for a reason that is likely to go away,
+// like a network error, a timeout or a 5xx response
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy decides how many times and how often a failed request is sent again
+type Policy struct {
+	// MaxAttempts is how many times a request is sent at most, including the first time
+	MaxAttempts int
+	// InitialBackoff is the pause before the second attempt, it doubles with every further attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the pause between attempts, a Retry-After longer than it isn't waited for
+	MaxBackoff time.Duration
+	// Jitter is the fraction, between 0 and 1, by which every pause is randomly shortened or lengthened
+	Jitter float64
+	// Retryable reports whether a response status means the request may succeed when sent again,
+	// RetryableStatus when nil
+	Retryable func(code int) bool
+
+	// sleep waits between attempts, it's replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// DefaultPolicy returns the policy used when none is configured
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Jitter:         0.2,
+	}
+}
+
+// Do sends the request built by newRequest with client until it succeeds, fails for a reason that isn't transient
+// or runs out of attempts. newRequest is called for every attempt, so request bodies can be read again.
+// The response of the last attempt is returned as is, the caller decides whether its status is an error.
+func (p Policy) Do(ctx context.Context, client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		request, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := client.Do(request)
+		if attempt >= p.MaxAttempts || ctx.Err() != nil {
+			return response, err
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			wait = p.Backoff(attempt)
+		case p.retryable(response.StatusCode):
+			wait = p.Backoff(attempt)
+			if retryAfter, ok := RetryAfter(response.Header, time.Now()); ok {
+				if retryAfter > p.MaxBackoff {
+					return response, nil
+				}
+				wait = retryAfter
+			}
+		case response.StatusCode == http.StatusTooManyRequests:
+			// Only worth retrying when the server says the limit resets soon
+			retryAfter, ok := RetryAfter(response.Header, time.Now())
+			if !ok || retryAfter > p.MaxBackoff {
+				return response, nil
+			}
+			wait = retryAfter
+		default:
+			return response, nil
+		}
+
+		if response != nil {
+			_ = response.Body.Close()
+		}
+		if err := p.wait(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Backoff returns the pause after the given failed attempt, starting at 1. Jitter never makes it longer than
+// MaxBackoff.
+func (p Policy) Backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if p.Jitter > 0 {
+		backoff = time.Duration(float64(backoff) * (1 + p.Jitter*(2*rand.Float64()-1)))
+	}
+
+	return min(backoff, p.MaxBackoff)
+}
+
+func (p Policy) retryable(code int) bool {
+	if p.Retryable != nil {
+		return p.Retryable(code)
+	}
+	return RetryableStatus(code)
+}
+
+func (p Policy) wait(ctx context.Context, d time.Duration) error {
+	if p.sleep != nil {
+		return p.sleep(ctx, d)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RetryableStatus reports whether a response status means the request may succeed when sent again, it's the default
+// classification of a Policy. Too Many Requests isn't one of them, since limits like GoCardless' daily quota take
+// hours to reset; it's only retried when its Retry-After is short.
+func RetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RetryAfter returns how long the Retry-After header asks to wait, given either in seconds or as a date
+func RetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
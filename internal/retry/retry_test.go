@@ -0,0 +1,202 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestPolicy returns a policy recording its pauses instead of sleeping
+func newTestPolicy(slept *[]time.Duration) Policy {
+	p := Policy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	p.sleep = func(ctx context.Context, d time.Duration) error {
+		*slept = append(*slept, d)
+		return ctx.Err()
+	}
+	return p
+}
+
+// newTestServer answers requests with the given statuses in order, setting headers on every response
+func newTestServer(t *testing.T, headers http.Header, statuses ...int) (*httptest.Server, *int) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key, values := range headers {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(statuses[calls])
+		calls++
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func get(ctx context.Context, p Policy, url string) (*http.Response, error) {
+	return p.Do(ctx, http.DefaultClient, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	})
+}
+
+func TestPolicyDo(t *testing.T) {
+	t.Run("retries server errors with backoff", func(t *testing.T) {
+		var slept []time.Duration
+		server, calls := newTestServer(t, nil, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK)
+
+		response, err := get(context.Background(), newTestPolicy(&slept), server.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, 3, *calls)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, slept)
+	})
+
+	t.Run("retries the statuses of its classification", func(t *testing.T) {
+		var slept []time.Duration
+		policy := newTestPolicy(&slept)
+		policy.Retryable = func(code int) bool { return code == http.StatusConflict }
+		server, calls := newTestServer(t, nil, http.StatusConflict, http.StatusServiceUnavailable)
+
+		response, err := get(context.Background(), policy, server.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		var slept []time.Duration
+		server, calls := newTestServer(t, nil, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+
+		response, err := get(context.Background(), newTestPolicy(&slept), server.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("doesn't retry client errors", func(t *testing.T) {
+		var slept []time.Duration
+		server, calls := newTestServer(t, nil, http.StatusBadRequest)
+
+		response, err := get(context.Background(), newTestPolicy(&slept), server.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("honors Retry-After of too many requests", func(t *testing.T) {
+		var slept []time.Duration
+		server, calls := newTestServer(t, http.Header{"Retry-After": {"5"}}, http.StatusTooManyRequests, http.StatusOK)
+
+		response, err := get(context.Background(), newTestPolicy(&slept), server.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, 2, *calls)
+		assert.Equal(t, []time.Duration{5 * time.Second}, slept)
+	})
+
+	t.Run("doesn't wait for a Retry-After longer than max backoff", func(t *testing.T) {
+		var slept []time.Duration
+		server, calls := newTestServer(t, http.Header{"Retry-After": {"3600"}}, http.StatusTooManyRequests)
+
+		response, err := get(context.Background(), newTestPolicy(&slept), server.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+		assert.Equal(t, 1, *calls)
+		assert.Empty(t, slept)
+	})
+
+	t.Run("uses every attempt with jittered backoff at its cap", func(t *testing.T) {
+		for range 50 {
+			var slept []time.Duration
+			server, calls := newTestServer(t, nil, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK)
+			p := newTestPolicy(&slept)
+			p.MaxAttempts, p.MaxBackoff, p.Jitter = 5, time.Second, 0.2
+
+			response, err := get(context.Background(), p, server.URL)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+			assert.Equal(t, 5, *calls)
+			for _, d := range slept {
+				assert.LessOrEqual(t, d, time.Second)
+			}
+		}
+	})
+
+	t.Run("doesn't wait for a server error's Retry-After longer than max backoff", func(t *testing.T) {
+		var slept []time.Duration
+		server, calls := newTestServer(t, http.Header{"Retry-After": {"3600"}}, http.StatusServiceUnavailable)
+
+		response, err := get(context.Background(), newTestPolicy(&slept), server.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+		assert.Equal(t, 1, *calls)
+		assert.Empty(t, slept)
+	})
+
+	t.Run("retries network errors", func(t *testing.T) {
+		var slept []time.Duration
+		attempts := 0
+		client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			attempts++
+			return nil, errors.New("connection reset")
+		})}
+
+		_, err := newTestPolicy(&slept).Do(context.Background(), client, func() (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, "http://example.com", nil)
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		var slept []time.Duration
+		server, calls := newTestServer(t, nil, http.StatusBadGateway, http.StatusOK)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := get(ctx, newTestPolicy(&slept), server.URL)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 0, *calls)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	p := Policy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, p.Backoff(1))
+	assert.Equal(t, 2*time.Second, p.Backoff(2))
+	assert.Equal(t, 4*time.Second, p.Backoff(3))
+	assert.Equal(t, 5*time.Second, p.Backoff(4))
+
+	p.Jitter = 0.5
+	for range 100 {
+		backoff := p.Backoff(2)
+		assert.GreaterOrEqual(t, backoff, time.Second)
+		assert.LessOrEqual(t, backoff, 3*time.Second)
+
+		// Jitter doesn't push capped pauses over the cap
+		assert.LessOrEqual(t, p.Backoff(4), 5*time.Second)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+
+	wait, ok := RetryAfter(http.Header{"Retry-After": {"120"}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, wait)
+
+	wait, ok = RetryAfter(http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, wait)
+
+	_, ok = RetryAfter(http.Header{}, now)
+	assert.False(t, ok)
+}
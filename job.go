@@ -2,17 +2,94 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
+const (
+	// defaultLookbackDays is how far back transactions are fetched when a job doesn't set a lookback
+	defaultLookbackDays = 20
+	// maxHistoricalDays is the transaction history GoCardless agreements give access to
+	maxHistoricalDays = 90
+)
+
+// importIDStrategy decides how YNAB import IDs are built for a job's transactions
+type importIDStrategy string
+
+const (
+	// importIDAmountDate builds YNAB:<amount>:<date>:<occurrence>, the format YNAB uses for its own imports
+	importIDAmountDate importIDStrategy = "amount_date"
+	// importIDTransactionID derives import IDs from the bank's transaction ID, falling back to importIDAmountDate
+	// for transactions without one
+	importIDTransactionID importIDStrategy = "transaction_id"
+)
+
+// dateStrategy decides which of the dates the bank gives a transaction is its date in YNAB. When the bank doesn't
+// give the preferred date, the other one is used, so no transaction is skipped for a missing date.
+type dateStrategy string
+
+const (
+	// dateValue uses the value date, when the money moved
+	dateValue dateStrategy = "value"
+	// dateBooking uses the booking date, when the bank booked the transaction
+	dateBooking dateStrategy = "booking"
+	// dateEarliest uses the earlier of the value and booking dates
+	dateEarliest dateStrategy = "earliest"
+	// dateLatest uses the later of the value and booking dates
+	dateLatest dateStrategy = "latest"
+)
+
 type job struct {
+	// Name identifies the job on the command line, it defaults to the GoCardless account ID
+	Name          string
 	GCAccountID   string
 	YNABAccountID string
 	YNABBudgetID  string
+	// LookbackDays is the window fetched when the job has no cursor yet, later runs start at the cursor
+	LookbackDays int
+	// ImportIDStrategy decides how YNAB import IDs are built, changing it for a running job re-creates transactions
+	ImportIDStrategy importIDStrategy
+	// DateStrategy decides which date of a transaction is used, changing it for a running job re-creates transactions
+	// whose import IDs include the date
+	DateStrategy dateStrategy
+	// DryRun prints what would be uploaded to YNAB instead of uploading it
+	DryRun bool
+	// MemoTemplate renders the memo of transactions, nil keeps the remittance information
+	MemoTemplate *memoTemplate
+}
+
+// key returns a stable identifier of the job used to store its state
+func (j job) key() string {
+	return j.GCAccountID + ":" + j.YNABAccountID
+}
+
+// matches reports whether the job is selected by selector, either its name or its GoCardless account ID
+func (j job) matches(selector string) bool {
+	return selector == j.Name || selector == j.GCAccountID
+}
+
+// selectJobs returns the jobs matching selector, or all jobs when selector is empty
+func selectJobs(jobs []job, selector string) ([]job, error) {
+	if selector == "" {
+		return jobs, nil
+	}
+
+	var selected []job
+	for _, j := range jobs {
+		if j.matches(selector) {
+			selected = append(selected, j)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no job matches %q", selector)
+	}
+
+	return selected, nil
 }
 
 // envToJobs parses a delimited string to construct a slice of job structs or returns an error for invalid input format.
-// example source: GCAccountID1,YNABBudgetID1,YNABAccountID1|GCAccountID2,YNABBudgetID2,YNABAccountID2|...
+// Each job may be followed by optional key=value settings.
+// example source: GCAccountID1,YNABBudgetID1,YNABAccountID1|GCAccountID2,YNABBudgetID2,YNABAccountID2,name=savings,lookback=30,import_id=transaction_id,date=booking,memo_template=memo.tmpl|...
 func envToJobs(source string) (jobs []job, err error) {
 	if source == "" {
 		return nil, fmt.Errorf("empty source string")
@@ -23,16 +100,86 @@ func envToJobs(source string) (jobs []job, err error) {
 
 	for _, cfg := range jobConfigs {
 		parts := strings.Split(cfg, ",")
-		if len(parts) != 3 {
+		if len(parts) < 3 {
 			return nil, fmt.Errorf("invalid job configuration: %s", cfg)
 		}
 
-		jobs = append(jobs, job{
-			GCAccountID:   strings.TrimSpace(parts[0]),
-			YNABBudgetID:  strings.TrimSpace(parts[1]),
-			YNABAccountID: strings.TrimSpace(parts[2]),
-		})
+		j := job{
+			GCAccountID:      strings.TrimSpace(parts[0]),
+			YNABBudgetID:     strings.TrimSpace(parts[1]),
+			YNABAccountID:    strings.TrimSpace(parts[2]),
+			LookbackDays:     defaultLookbackDays,
+			ImportIDStrategy: importIDAmountDate,
+			DateStrategy:     dateValue,
+		}
+
+		for _, option := range parts[3:] {
+			if err := parseJobOption(&j, option); err != nil {
+				return nil, fmt.Errorf("invalid job configuration: %s: %w", cfg, err)
+			}
+		}
+		if j.Name == "" {
+			j.Name = j.GCAccountID
+		}
+
+		jobs = append(jobs, j)
 	}
 
 	return jobs, nil
 }
+
+// parseJobOption applies a single key=value job setting to j
+func parseJobOption(j *job, option string) error {
+	key, value, ok := strings.Cut(strings.TrimSpace(option), "=")
+	if !ok {
+		return fmt.Errorf("invalid option %q, expected key=value", option)
+	}
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "name":
+		if value == "" {
+			return fmt.Errorf("name must not be empty")
+		}
+		j.Name = value
+	case "lookback":
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid lookback %q: %w", value, err)
+		}
+		if days < 1 || days > maxHistoricalDays {
+			return fmt.Errorf("lookback must be between 1 and %d days, got %d", maxHistoricalDays, days)
+		}
+		j.LookbackDays = days
+	case "import_id":
+		strategy := importIDStrategy(value)
+		if strategy != importIDAmountDate && strategy != importIDTransactionID {
+			return fmt.Errorf("invalid import_id %q, expected %s or %s", value, importIDAmountDate, importIDTransactionID)
+		}
+		j.ImportIDStrategy = strategy
+	case "date":
+		strategy := dateStrategy(value)
+		if strategy != dateValue && strategy != dateBooking && strategy != dateEarliest && strategy != dateLatest {
+			return fmt.Errorf("invalid date %q, expected %s, %s, %s or %s", value, dateValue, dateBooking, dateEarliest, dateLatest)
+		}
+		j.DateStrategy = strategy
+	case "dry_run":
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid dry_run %q: %w", value, err)
+		}
+		j.DryRun = dryRun
+	case "memo_template":
+		// Templates are read from a file, their pipes and commas would clash with the separators of JOBS
+		memo, err := parseMemoTemplate(value)
+		if err != nil {
+			return err
+		}
+		j.MemoTemplate = memo
+	default:
+		return fmt.Errorf("unknown option %q", key)
+	}
+
+	return nil
+}
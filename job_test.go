@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvToJobs(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		jobs, err := envToJobs("GC1, BUDGET1, ACCOUNT1|GC2,BUDGET2,ACCOUNT2")
+		assert.NoError(t, err)
+		assert.Equal(t, []job{
+			{Name: "GC1", GCAccountID: "GC1", YNABBudgetID: "BUDGET1", YNABAccountID: "ACCOUNT1", LookbackDays: defaultLookbackDays, ImportIDStrategy: importIDAmountDate, DateStrategy: dateValue},
+			{Name: "GC2", GCAccountID: "GC2", YNABBudgetID: "BUDGET2", YNABAccountID: "ACCOUNT2", LookbackDays: defaultLookbackDays, ImportIDStrategy: importIDAmountDate, DateStrategy: dateValue},
+		}, jobs)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		jobs, err := envToJobs("GC1,BUDGET1,ACCOUNT1,name=savings,lookback=45,import_id=transaction_id,date=booking")
+		assert.NoError(t, err)
+		assert.Equal(t, "savings", jobs[0].Name)
+		assert.Equal(t, 45, jobs[0].LookbackDays)
+		assert.Equal(t, importIDTransactionID, jobs[0].ImportIDStrategy)
+		assert.Equal(t, dateBooking, jobs[0].DateStrategy)
+	})
+
+	t.Run("memo template", func(t *testing.T) {
+		dir := t.TempDir()
+		valid := filepath.Join(dir, "memo.tmpl")
+		assert.NoError(t, os.WriteFile(valid, []byte("{{ .AdditionalInformation | trim }}\n"), 0o600))
+		invalid := filepath.Join(dir, "invalid.tmpl")
+		assert.NoError(t, os.WriteFile(invalid, []byte("{{ .AdditionalInformation | trim "), 0o600))
+
+		jobs, err := envToJobs("GC1,BUDGET1,ACCOUNT1,memo_template=" + valid)
+		assert.NoError(t, err)
+		if assert.NotNil(t, jobs[0].MemoTemplate) {
+			assert.Equal(t, valid, jobs[0].MemoTemplate.path)
+		}
+
+		_, err = envToJobs("GC1,BUDGET1,ACCOUNT1,memo_template=" + invalid)
+		assert.ErrorContains(t, err, "invalid memo template")
+		_, err = envToJobs("GC1,BUDGET1,ACCOUNT1,memo_template=" + filepath.Join(dir, "missing.tmpl"))
+		assert.ErrorContains(t, err, "failed to read memo template")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, source := range []string{
+			"",
+			"GC1,BUDGET1",
+			"GC1,BUDGET1,ACCOUNT1,lookback",
+			"GC1,BUDGET1,ACCOUNT1,lookback=91",
+			"GC1,BUDGET1,ACCOUNT1,lookback=abc",
+			"GC1,BUDGET1,ACCOUNT1,import_id=random",
+			"GC1,BUDGET1,ACCOUNT1,date=posted",
+			"GC1,BUDGET1,ACCOUNT1,unknown=1",
+			"GC1,BUDGET1,ACCOUNT1,name=",
+		} {
+			_, err := envToJobs(source)
+			assert.Error(t, err, source)
+		}
+	})
+}
+
+func TestSelectJobs(t *testing.T) {
+	jobs := []job{
+		{Name: "checking", GCAccountID: "GC1"},
+		{Name: "savings", GCAccountID: "GC2"},
+	}
+
+	selected, err := selectJobs(jobs, "")
+	assert.NoError(t, err)
+	assert.Equal(t, jobs, selected)
+
+	selected, err = selectJobs(jobs, "savings")
+	assert.NoError(t, err)
+	assert.Equal(t, jobs[1:], selected)
+
+	selected, err = selectJobs(jobs, "GC1")
+	assert.NoError(t, err)
+	assert.Equal(t, jobs[:1], selected)
+
+	_, err = selectJobs(jobs, "unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+// accountLimiter coordinates jobs running in parallel: jobs of the same GoCardless account run one after another,
+// since they share the account's daily quota, and once a rate limit is hit the jobs it applies to are skipped
+type accountLimiter struct {
+	mu       sync.Mutex
+	accounts map[string]*sync.Mutex
+	// limited holds the rate limit error of every account that ran out of its quota
+	limited map[string]error
+	// global is the rate limit error that applies to every account
+	global error
+}
+
+func newAccountLimiter() *accountLimiter {
+	return &accountLimiter{
+		accounts: make(map[string]*sync.Mutex),
+		limited:  make(map[string]error),
+	}
+}
+
+// lock waits until no other job of the account runs and returns the function releasing the account
+func (l *accountLimiter) lock(accountID string) func() {
+	l.mu.Lock()
+	accountMu, ok := l.accounts[accountID]
+	if !ok {
+		accountMu = &sync.Mutex{}
+		l.accounts[accountID] = accountMu
+	}
+	l.mu.Unlock()
+
+	accountMu.Lock()
+	return accountMu.Unlock
+}
+
+// blocked returns the rate limit error that stops jobs of the account from running, or nil
+func (l *accountLimiter) blocked(accountID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.global != nil {
+		return errors.Wrap(l.global, "skipped after rate limit of all requests")
+	}
+	if err := l.limited[accountID]; err != nil {
+		return errors.Wrap(err, "skipped after rate limit of the account")
+	}
+	return nil
+}
+
+// record remembers a rate limit hit by a job of the account
+func (l *accountLimiter) record(accountID string, err error) {
+	var rateLimitErr *gocardless.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if rateLimitErr.Global {
+		l.global = rateLimitErr
+		return
+	}
+	l.limited[accountID] = rateLimitErr
+}
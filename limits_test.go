@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+func TestAccountLimiter(t *testing.T) {
+	t.Run("account rate limit blocks only the account", func(t *testing.T) {
+		limiter := newAccountLimiter()
+		limiter.record("aaa", &gocardless.RateLimitError{Status: "429", ResetIn: time.Hour})
+
+		var rateLimitErr *gocardless.RateLimitError
+		assert.ErrorAs(t, limiter.blocked("aaa"), &rateLimitErr)
+		assert.NoError(t, limiter.blocked("bbb"))
+	})
+
+	t.Run("global rate limit blocks every account", func(t *testing.T) {
+		limiter := newAccountLimiter()
+		limiter.record("aaa", &gocardless.RateLimitError{Status: "429", Global: true})
+
+		assert.Error(t, limiter.blocked("aaa"))
+		assert.Error(t, limiter.blocked("bbb"))
+	})
+
+	t.Run("other errors don't block", func(t *testing.T) {
+		limiter := newAccountLimiter()
+		limiter.record("aaa", assert.AnError)
+
+		assert.NoError(t, limiter.blocked("aaa"))
+	})
+
+	t.Run("jobs of an account run one after another", func(t *testing.T) {
+		limiter := newAccountLimiter()
+		unlock := limiter.lock("aaa")
+
+		locked := make(chan struct{})
+		go func() {
+			defer limiter.lock("aaa")()
+			close(locked)
+		}()
+
+		// Another account isn't held up
+		limiter.lock("bbb")()
+
+		select {
+		case <-locked:
+			t.Fatal("second job of the account ran while the first one was running")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		unlock()
+		<-locked
+	})
+}
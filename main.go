@@ -2,12 +2,44 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/go-co-op/gocron/v2"
 )
 
+// Exit codes of the one-off commands
+const (
+	exitOK = iota
+	// exitError means the command couldn't run, e.g. because of invalid configuration
+	exitError
+	// exitSomeJobsFailed means at least one job failed and at least one succeeded
+	exitSomeJobsFailed
+	// exitAllJobsFailed means every selected job failed
+	exitAllJobsFailed
+)
+
+// exitCode maps the error of a one-off command to the process exit code
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var syncErr *SyncError
+	if errors.As(err, &syncErr) {
+		if len(syncErr.Failed) == syncErr.Total {
+			return exitAllJobsFailed
+		}
+		return exitSomeJobsFailed
+	}
+
+	return exitError
+}
+
 func main() {
 	l := slog.Default()
 
@@ -15,14 +47,18 @@ func main() {
 	config, err := LoadConfigFromEnv()
 	if err != nil {
 		l.Error("failed to load configuration", "error", err)
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 
+	// Cancelled on shutdown, which stops the scheduler and the requests and retries in flight
+	shutdownCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Create a service container
-	container, err := NewServiceContainer(config)
+	container, err := NewServiceContainer(shutdownCtx, config)
 	if err != nil {
 		l.Error("failed to initialize services", "error", err)
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 
 	// Get monitoring service for startup transaction
@@ -30,24 +66,35 @@ func main() {
 	txn := monitorService.StartTransaction("startup")
 	defer txn.End()
 
-	// Get synchronization service
-	syncService := container.SyncService()
-
 	// Create context with transaction
-	ctx := monitorService.NewContext(context.Background(), txn)
+	ctx := monitorService.NewContext(shutdownCtx, txn)
 
 	// Log configuration
-	l.Info("configuration", "cron", config.CronSchedule)
+	l.Info("configuration", "cron", config.CronSchedule, "state_file", config.StateFile, "dry_run", config.DryRun)
 	for _, job := range config.Jobs {
-		l.Info("job", "gocardless_account_id", job.GCAccountID, "ynab_account_id", job.YNABAccountID, "ynab_budget_id", job.YNABBudgetID)
+		l.Info("job", "name", job.Name, "gocardless_account_id", job.GCAccountID, "ynab_account_id", job.YNABAccountID, "ynab_budget_id", job.YNABBudgetID, "dry_run", job.DryRun)
 	}
 
+	// Run the command, the scheduler when none is given
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"run"}
+	}
+	if err := runCommand(ctx, container, commands(), args); err != nil {
+		monitorService.RecordError(txn, err)
+		l.ErrorContext(ctx, "command failed", "command", args[0], "error", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// runScheduler synchronizes all jobs on the configured cron schedule until ctx is cancelled
+func runScheduler(ctx context.Context, syncService SynchronizationServicer, config Config) error {
+	l := slog.Default()
+
 	// Set up scheduler
 	s, err := gocron.NewScheduler()
 	if err != nil {
-		monitorService.RecordError(txn, err)
-		l.ErrorContext(ctx, "failed to create scheduler", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to create scheduler: %w", err)
 	}
 	defer func() { _ = s.Shutdown() }()
 
@@ -55,20 +102,23 @@ func main() {
 	_, err = s.NewJob(
 		gocron.CronJob(config.CronSchedule, false),
 		gocron.NewTask(func() {
-			if err := syncService.SynchronizeTransactions(context.Background()); err != nil {
-				l.Error("synchronization failed", "error", err)
+			report, err := syncService.SynchronizeTransactions(ctx, config.Jobs)
+			if err != nil {
+				l.Error("synchronization failed", "jobs", len(report.Results), "failed", len(report.Failed()), "error", err)
+				return
 			}
+			l.Info("synchronization finished", "jobs", len(report.Results), "skipped", report.Skipped())
 		}),
 	)
 	if err != nil {
-		monitorService.RecordError(txn, err)
-		l.ErrorContext(ctx, "failed to create job", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to create job: %w", err)
 	}
 
 	// Start scheduler
 	s.Start()
 
-	// Block until shutdown
-	select {}
+	// Block until shutdown, a synchronization in progress is stopped by the cancelled context
+	<-ctx.Done()
+	l.Info("shutting down")
+	return nil
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -10,6 +11,8 @@ import (
 	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
 )
 
 func TestSynchronizeTransactions(t *testing.T) {
@@ -36,6 +39,7 @@ func TestSynchronizeTransactions(t *testing.T) {
 		goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil)
 		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", from, nowTS).Return([]Transaction{trans1}, nil)
 
+		ynabMock.EXPECT().GetTransactionsByAccount("ccc", "bbb", mock.Anything).Return(nil, nil)
 		importID := toImportIDWithOccurrence(trans1, 1)
 		ynabMock.EXPECT().CreateTransactions("ccc", []transaction.PayloadTransaction{
 			{
@@ -53,6 +57,7 @@ func TestSynchronizeTransactions(t *testing.T) {
 
 		// Mock monitoring service
 		mockTxn := &newrelic.Transaction{}
+		monitorMock.On("StartTransaction", "synchronizeAll").Return(mockTxn)
 		monitorMock.On("StartTransaction", "synchronization").Return(mockTxn)
 		monitorMock.On("AddAttribute", mockTxn, mock.Anything, mock.Anything).Return()
 		monitorMock.On("NewContext", mock.Anything, mockTxn).Return(context.Background())
@@ -62,13 +67,287 @@ func TestSynchronizeTransactions(t *testing.T) {
 			GCAccountID:   "aaa",
 			YNABAccountID: "bbb",
 			YNABBudgetID:  "ccc",
+			LookbackDays:  defaultLookbackDays,
 		}
 
+		// Create state store
+		stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+
 		// Create sync service with mocks
-		syncService := NewSyncService(goCardlessMock, ynabMock, monitorMock, []job{testJob})
+		syncService := NewSyncService(goCardlessMock, ynabMock, monitorMock, stateStore, nil, []job{testJob}, 1)
 
 		// Test synchronization
-		err := syncService.SynchronizeTransactions(context.Background())
+		_, err = syncService.SynchronizeTransactions(context.Background(), []job{testJob})
+		assert.NoError(t, err)
+
+		// Check the state was recorded
+		state, err := stateStore.JobState(testJob.key())
+		assert.NoError(t, err)
+		assert.Equal(t, nowTS, state.LastSyncedAt)
+		assert.True(t, state.IsUploaded("123"))
+	})
+
+	t.Run("skips already uploaded transactions", func(t *testing.T) {
+		// Create mock services
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		monitorMock := NewMockMonitoringServicer(t)
+
+		// Set up transaction time, the window starts before the last cursor
+		nowTS := time.Now().UTC().Truncate(time.Hour)
+		lastSyncedAt := nowTS.AddDate(0, 0, -1)
+		from := lastSyncedAt.AddDate(0, 0, -cursorOverlapDays).Truncate(24 * time.Hour)
+
+		// Create test transaction
+		trans1 := Transaction{
+			ID:         "123",
+			Date:       nowTS.AddDate(0, 0, -1),
+			AmountMili: 98765,
+			Memo:       "memo",
+			Name:       "John Doe",
+		}
+
+		// Create test job
+		testJob := job{
+			GCAccountID:   "aaa",
+			YNABAccountID: "bbb",
+			YNABBudgetID:  "ccc",
+			LookbackDays:  defaultLookbackDays,
+		}
+
+		// Create state store with the transaction already uploaded
+		stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+		state := JobState{LastSyncedAt: lastSyncedAt}
+		state.MarkUploaded("123", UploadedTransaction{ImportID: toImportIDWithOccurrence(trans1, 1), Date: trans1.Date})
+		assert.NoError(t, stateStore.SaveJobState(testJob.key(), state))
+
+		// Set up mock expectations, nothing is uploaded
+		goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil)
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", from, nowTS).Return([]Transaction{trans1}, nil)
+
+		// Mock monitoring service
+		mockTxn := &newrelic.Transaction{}
+		monitorMock.On("StartTransaction", "synchronizeAll").Return(mockTxn)
+		monitorMock.On("StartTransaction", "synchronization").Return(mockTxn)
+		monitorMock.On("AddAttribute", mockTxn, mock.Anything, mock.Anything).Return()
+		monitorMock.On("NewContext", mock.Anything, mockTxn).Return(context.Background())
+
+		// Create sync service with mocks
+		syncService := NewSyncService(goCardlessMock, ynabMock, monitorMock, stateStore, nil, []job{testJob}, 1)
+
+		// Test synchronization
+		_, err = syncService.SynchronizeTransactions(context.Background(), []job{testJob})
+		assert.NoError(t, err)
+	})
+	t.Run("continues after a failing job", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		monitorMock := NewMockMonitoringServicer(t)
+
+		nowTS := time.Now().UTC().Truncate(time.Hour)
+		from := nowTS.AddDate(0, 0, -20).Truncate(24 * time.Hour)
+
+		expiredJob := job{Name: "expired", GCAccountID: "aaa", YNABAccountID: "bbb", YNABBudgetID: "ccc", LookbackDays: defaultLookbackDays}
+		workingJob := job{Name: "working", GCAccountID: "ddd", YNABAccountID: "eee", YNABBudgetID: "ccc", LookbackDays: defaultLookbackDays}
+
+		// Both jobs share one session
+		goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil).Once()
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", from, nowTS).Return(nil, assert.AnError)
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "ddd", from, nowTS).Return(nil, nil)
+
+		mockTxn := &newrelic.Transaction{}
+		monitorMock.On("StartTransaction", "synchronizeAll").Return(mockTxn)
+		monitorMock.On("StartTransaction", "synchronization").Return(mockTxn)
+		monitorMock.On("AddAttribute", mockTxn, mock.Anything, mock.Anything).Return()
+		monitorMock.On("NewContext", mock.Anything, mockTxn).Return(context.Background())
+		monitorMock.On("RecordError", mockTxn, mock.Anything).Return().Twice()
+
+		stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+
+		syncService := NewSyncService(goCardlessMock, ynabMock, monitorMock, stateStore, nil, []job{expiredJob, workingJob}, 2)
+
+		report, err := syncService.SynchronizeTransactions(context.Background(), []job{expiredJob, workingJob})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "1 of 2 jobs failed: expired: ")
+		assert.Len(t, report.Results, 2)
+		assert.Equal(t, "expired", report.Failed()[0].Job)
+		assert.NoError(t, report.Results[1].Err)
+
+		// The working job still moved its cursor
+		state, err := stateStore.JobState(workingJob.key())
+		assert.NoError(t, err)
+		assert.Equal(t, nowTS, state.LastSyncedAt)
+	})
+
+	t.Run("synchronizes only the given jobs", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		monitorMock := NewMockMonitoringServicer(t)
+
+		nowTS := time.Now().UTC().Truncate(time.Hour)
+		from := nowTS.AddDate(0, 0, -20).Truncate(24 * time.Hour)
+
+		skippedJob := job{Name: "skipped", GCAccountID: "aaa", YNABAccountID: "bbb", YNABBudgetID: "ccc", LookbackDays: defaultLookbackDays}
+		selectedJob := job{Name: "selected", GCAccountID: "ddd", YNABAccountID: "eee", YNABBudgetID: "ccc", LookbackDays: defaultLookbackDays}
+
+		goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil).Once()
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "ddd", from, nowTS).Return(nil, nil).Once()
+
+		mockTxn := &newrelic.Transaction{}
+		monitorMock.On("StartTransaction", "synchronizeAll").Return(mockTxn)
+		monitorMock.On("StartTransaction", "synchronization").Return(mockTxn)
+		monitorMock.On("AddAttribute", mockTxn, mock.Anything, mock.Anything).Return()
+		monitorMock.On("NewContext", mock.Anything, mockTxn).Return(context.Background())
+
+		stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+
+		syncService := NewSyncService(goCardlessMock, ynabMock, monitorMock, stateStore, nil, []job{skippedJob, selectedJob}, 2)
+
+		report, err := syncService.SynchronizeTransactions(context.Background(), []job{selectedJob})
+		assert.NoError(t, err)
+		if assert.Len(t, report.Results, 1) {
+			assert.Equal(t, "selected", report.Results[0].Job)
+		}
+	})
+
+	t.Run("skips jobs of a rate limited account", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		monitorMock := NewMockMonitoringServicer(t)
+
+		nowTS := time.Now().UTC().Truncate(time.Hour)
+		from := nowTS.AddDate(0, 0, -20).Truncate(24 * time.Hour)
+
+		// Both jobs use the same GoCardless account, the second one must not use its quota again
+		firstJob := job{Name: "first", GCAccountID: "aaa", YNABAccountID: "bbb", YNABBudgetID: "ccc", LookbackDays: defaultLookbackDays}
+		secondJob := job{Name: "second", GCAccountID: "aaa", YNABAccountID: "eee", YNABBudgetID: "ccc", LookbackDays: defaultLookbackDays}
+
+		goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil).Once()
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", from, nowTS).Return(nil, &gocardless.RateLimitError{Status: "429 Too Many Requests", ResetIn: time.Hour}).Once()
+
+		mockTxn := &newrelic.Transaction{}
+		monitorMock.On("StartTransaction", "synchronizeAll").Return(mockTxn)
+		monitorMock.On("StartTransaction", "synchronization").Return(mockTxn)
+		monitorMock.On("AddAttribute", mockTxn, mock.Anything, mock.Anything).Return()
+		monitorMock.On("NewContext", mock.Anything, mockTxn).Return(context.Background())
+		monitorMock.On("RecordError", mockTxn, mock.Anything).Return()
+
+		stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+
+		syncService := NewSyncService(goCardlessMock, ynabMock, monitorMock, stateStore, nil, []job{firstJob, secondJob}, 2)
+
+		report, err := syncService.SynchronizeTransactions(context.Background(), []job{firstJob, secondJob})
+		assert.ErrorContains(t, err, "2 of 2 jobs failed")
+		for _, result := range report.Results {
+			var rateLimitErr *gocardless.RateLimitError
+			assert.ErrorAs(t, result.Err, &rateLimitErr, result.Job)
+		}
+	})
+}
+
+func TestBackfill(t *testing.T) {
+	testJob := job{
+		GCAccountID:   "aaa",
+		YNABAccountID: "bbb",
+		YNABBudgetID:  "ccc",
+		LookbackDays:  defaultLookbackDays,
+	}
+
+	nowTS := time.Now().UTC().Truncate(time.Hour)
+	from := nowTS.AddDate(0, 0, -10).Truncate(24 * time.Hour)
+	secondChunkFrom := from.AddDate(0, 0, 6)
+
+	trans1 := Transaction{ID: "1", Date: from, AmountMili: -1000, Memo: "memo", Name: "Shop"}
+	trans2 := Transaction{ID: "2", Date: secondChunkFrom, AmountMili: -2000, Memo: "memo", Name: "Shop"}
+
+	newMonitorMock := func(t *testing.T) *MockMonitoringServicer {
+		monitorMock := NewMockMonitoringServicer(t)
+		mockTxn := &newrelic.Transaction{}
+		monitorMock.On("StartTransaction", "backfill").Return(mockTxn)
+		monitorMock.On("AddAttribute", mockTxn, mock.Anything, mock.Anything).Return()
+		monitorMock.On("NewContext", mock.Anything, mockTxn).Return(context.Background())
+		monitorMock.On("RecordError", mockTxn, mock.Anything).Return().Maybe()
+		return monitorMock
+	}
+
+	t.Run("uploads history in chunks", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+
+		goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil)
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", from, from.AddDate(0, 0, 5)).Return([]Transaction{trans1}, nil)
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", secondChunkFrom, nowTS).Return([]Transaction{trans2}, nil)
+		ynabMock.EXPECT().GetTransactionsByAccount("ccc", "bbb", mock.Anything).Return(nil, nil)
+		ynabMock.EXPECT().CreateTransactions("ccc", toYNABTransaction(testJob, JobState{}, []Transaction{trans1})).Return(&transaction.OperationSummary{}, nil)
+		ynabMock.EXPECT().CreateTransactions("ccc", toYNABTransaction(testJob, JobState{}, []Transaction{trans2})).Return(&transaction.OperationSummary{}, nil)
+
+		syncService := NewSyncService(goCardlessMock, ynabMock, newMonitorMock(t), stateStore, nil, []job{testJob}, 1)
+		err = syncService.Backfill(context.Background(), testJob, 10, 6)
+		assert.NoError(t, err)
+
+		state, err := stateStore.JobState(testJob.key())
+		assert.NoError(t, err)
+		assert.True(t, state.IsUploaded("1"))
+		assert.True(t, state.IsUploaded("2"))
+		assert.True(t, state.BackfilledTo.IsZero())
+		assert.Equal(t, nowTS, state.LastSyncedAt)
+	})
+
+	t.Run("stores progress when rate limited", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+
+		goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil)
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", from, from.AddDate(0, 0, 5)).Return([]Transaction{trans1}, nil)
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", secondChunkFrom, nowTS).Return(nil, &gocardless.RateLimitError{Status: "429 Too Many Requests", ResetIn: time.Hour})
+		ynabMock.EXPECT().GetTransactionsByAccount("ccc", "bbb", mock.Anything).Return(nil, nil)
+		ynabMock.EXPECT().CreateTransactions("ccc", toYNABTransaction(testJob, JobState{}, []Transaction{trans1})).Return(&transaction.OperationSummary{}, nil)
+
+		syncService := NewSyncService(goCardlessMock, ynabMock, newMonitorMock(t), stateStore, nil, []job{testJob}, 1)
+		err = syncService.Backfill(context.Background(), testJob, 10, 6)
+		var rateLimitErr *gocardless.RateLimitError
+		assert.ErrorAs(t, err, &rateLimitErr)
+
+		state, err := stateStore.JobState(testJob.key())
+		assert.NoError(t, err)
+		assert.True(t, state.IsUploaded("1"))
+		assert.Equal(t, secondChunkFrom, state.BackfilledTo)
+	})
+
+	t.Run("doesn't store a chunk whose YNAB update failed", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+		pendingImportID := "PENDING:-2000:" + secondChunkFrom.Format("2006-01-02") + ":1"
+		stored := JobState{}
+		stored.MarkPending(pendingImportID, PendingTransaction{YNABID: "y2", Date: secondChunkFrom, AmountMili: -2000, Name: "Shop"})
+		assert.NoError(t, stateStore.SaveJobState(testJob.key(), stored))
+
+		goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil)
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", from, nowTS).Return([]Transaction{trans2}, nil)
+		ynabMock.EXPECT().GetTransactionsByAccount("ccc", "bbb", mock.Anything).Return([]*transaction.Transaction{
+			{ID: "y2", Amount: -2000, Cleared: transaction.ClearingStatusUncleared},
+		}, nil)
+		ynabMock.EXPECT().UpdateTransactions("ccc", mock.Anything).Return(nil, assert.AnError)
+
+		syncService := NewSyncService(goCardlessMock, ynabMock, newMonitorMock(t), stateStore, nil, []job{testJob}, 1)
+		err = syncService.Backfill(context.Background(), testJob, 10, 12)
+		assert.ErrorIs(t, err, assert.AnError)
+
+		// The pending transaction is still uncleared in YNAB, so it's reconciled again on the next run
+		state, err := stateStore.JobState(testJob.key())
 		assert.NoError(t, err)
+		assert.True(t, state.IsPending(pendingImportID))
+		assert.False(t, state.IsUploaded("2"))
 	})
 }
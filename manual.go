@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/pkg/errors"
+)
+
+// manualMatchDays is how many days the bank may book a transaction apart from the date it was entered in YNAB with
+const manualMatchDays = 5
+
+// matchManual looks in YNAB for the transactions the user entered before the bank reported them, by hand or through
+// a scheduled transaction, so they aren't uploaded a second time. The matching YNAB transaction gets the import ID
+// and cleared status of the fetched one, and the amount the bank booked when it was entered with a slightly
+// different one; its date, payee, category and memo are kept. transactions must be aligned with
+// payloadTransactions, and the returned indexes point to the ones that were matched.
+func (s *SyncService) matchManual(ctx context.Context, j job, state *JobState, transactions []Transaction, payloadTransactions []transaction.PayloadTransaction) (map[int]bool, error) {
+	l := slog.Default().With("gocardless_account_id", j.GCAccountID, "ynab_account_id", j.YNABAccountID, "ynab_budget_id", j.YNABBudgetID)
+	matched := make(map[int]bool)
+
+	var since time.Time
+	for _, t := range transactions {
+		if since.IsZero() || t.Date.Before(since) {
+			since = t.Date
+		}
+	}
+	if since.IsZero() {
+		return matched, nil
+	}
+
+	ynabTransactions, err := s.accountTransactions(j.YNABBudgetID, j.YNABAccountID, since.AddDate(0, 0, -manualMatchDays))
+	if err != nil {
+		return nil, err
+	}
+
+	// Transactions recorded in state already stand for a fetched transaction, e.g. the other side of a transfer
+	claimed := make(map[string]bool)
+	for _, uploaded := range state.Uploaded {
+		claimed[uploaded.YNABID] = true
+	}
+	for _, pending := range state.Pending {
+		claimed[pending.YNABID] = true
+	}
+	var candidates []*transaction.Transaction
+	for _, t := range ynabTransactions {
+		if isManual(t) && !claimed[t.ID] {
+			candidates = append(candidates, t)
+		}
+	}
+	if len(candidates) == 0 {
+		return matched, nil
+	}
+
+	var updates []transaction.PayloadTransaction
+	now := time.Now().UTC()
+	for i, t := range transactions {
+		existing := matchManualTransaction(t, candidates, claimed)
+		if existing == nil {
+			continue
+		}
+		claimed[existing.ID] = true
+		matched[i] = true
+
+		update := toUpdatePayload(existing)
+		update.ImportID = payloadTransactions[i].ImportID
+		update.Amount = payloadTransactions[i].Amount
+		update.Cleared = payloadTransactions[i].Cleared
+		updates = append(updates, update)
+
+		importID := *payloadTransactions[i].ImportID
+		l.InfoContext(ctx, "matched transaction entered in YNAB", "id", t.ID, "ynab_id", existing.ID, "import_id", importID, "amount", t.AmountMili, "ynab_amount", existing.Amount, "date", t.Date.Format("2006-01-02"), "ynab_date", existing.Date.Format("2006-01-02"))
+		if t.Pending {
+			// The pending transaction is reconciled with its booked counterpart like an uploaded one
+			state.MarkPending(importID, PendingTransaction{
+				ID:         t.ID,
+				YNABID:     existing.ID,
+				Date:       t.Date,
+				AmountMili: t.AmountMili,
+				Name:       t.Name,
+			})
+			continue
+		}
+		state.MarkUploaded(uploadKey(t, importID), UploadedTransaction{
+			ImportID:   importID,
+			YNABID:     existing.ID,
+			Date:       t.Date,
+			AmountMili: t.AmountMili,
+			UploadedAt: now,
+		})
+	}
+
+	if len(updates) > 0 {
+		if _, err := s.ynabService.UpdateTransactions(j.YNABBudgetID, updates); err != nil {
+			return nil, errors.Wrap(err, "failed to update matched transactions")
+		}
+	}
+
+	return matched, nil
+}
+
+// isManual reports whether t was entered in YNAB rather than imported, and is still waiting for the bank, i.e.
+// uncleared or not approved yet
+func isManual(t *transaction.Transaction) bool {
+	if t.Deleted || t.ImportID != nil || t.Cleared == transaction.ClearingStatusReconciled {
+		return false
+	}
+	return t.Cleared == transaction.ClearingStatusUncleared || !t.Approved
+}
+
+// matchManualTransaction returns the candidate most likely to be t entered in YNAB, within manualMatchDays: one of
+// the same amount, or one of the same payee whose amount is close. Candidates in taken are skipped.
+func matchManualTransaction(t Transaction, candidates []*transaction.Transaction, taken map[string]bool) *transaction.Transaction {
+	var best *transaction.Transaction
+	bestRank := 0
+	var bestDistance time.Duration
+	for _, c := range candidates {
+		if taken[c.ID] {
+			continue
+		}
+
+		distance := c.Date.Sub(t.Date)
+		if distance < 0 {
+			distance = -distance
+		}
+		if distance > manualMatchDays*24*time.Hour {
+			continue
+		}
+
+		rank := manualMatchRank(t, c)
+		if rank == 0 {
+			continue
+		}
+		if rank > bestRank || (rank == bestRank && distance < bestDistance) {
+			best, bestRank, bestDistance = c, rank, distance
+		}
+	}
+
+	return best
+}
+
+// manualMatchRank scores how likely c is t entered in YNAB, 0 means it isn't
+func manualMatchRank(t Transaction, c *transaction.Transaction) int {
+	samePayee := c.PayeeName != nil && strings.EqualFold(strings.TrimSpace(*c.PayeeName), strings.TrimSpace(t.Name))
+	switch {
+	case samePayee && c.Amount == t.AmountMili:
+		return 3
+	case c.Amount == t.AmountMili:
+		return 2
+	case samePayee && amountsClose(c.Amount, t.AmountMili):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api"
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncServiceMatchManual(t *testing.T) {
+	testJob := job{GCAccountID: "aaa", YNABAccountID: "bbb", YNABBudgetID: "ccc"}
+	date := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	bakery := "Bakery"
+	categoryID := "food"
+	memo := "Birthday cake"
+
+	t.Run("updates the transaction entered in YNAB", func(t *testing.T) {
+		ynabMock := newMockynaber(t)
+		syncService := &SyncService{ynabService: ynabMock}
+
+		transactions := []Transaction{
+			{ID: "t1", Date: date, AmountMili: -25500, Name: "BAKERY"},
+			{ID: "t2", Date: date, AmountMili: -1000, Name: "Shop"},
+			{ID: "t3", Date: date, AmountMili: -7000, Name: "Cinema", Pending: true},
+		}
+		payloads := toYNABTransaction(testJob, JobState{}, transactions)
+		ynabMock.EXPECT().GetTransactionsByAccount("ccc", "bbb", mock.Anything).Return([]*transaction.Transaction{
+			{ID: "y1", AccountID: "bbb", Date: api.Date{Time: date.AddDate(0, 0, -2)}, Amount: -25000, PayeeName: &bakery, CategoryID: &categoryID, Memo: &memo, Cleared: transaction.ClearingStatusUncleared, Approved: true},
+			{ID: "y3", AccountID: "bbb", Date: api.Date{Time: date}, Amount: -7000, Cleared: transaction.ClearingStatusUncleared},
+		}, nil).Once()
+		ynabMock.EXPECT().UpdateTransactions("ccc", mock.Anything).RunAndReturn(func(_ string, updates []transaction.PayloadTransaction) (*transaction.OperationSummary, error) {
+			if assert.Len(t, updates, 2) {
+				// The bank's amount, import ID and cleared status, with everything the user entered kept
+				assert.Equal(t, "y1", updates[0].ID)
+				assert.Equal(t, int64(-25500), updates[0].Amount)
+				assert.Equal(t, payloads[0].ImportID, updates[0].ImportID)
+				assert.Equal(t, transaction.ClearingStatusCleared, updates[0].Cleared)
+				assert.Equal(t, date.AddDate(0, 0, -2), updates[0].Date.Time)
+				assert.Equal(t, &categoryID, updates[0].CategoryID)
+				assert.Equal(t, &memo, updates[0].Memo)
+				assert.Equal(t, "y3", updates[1].ID)
+				assert.Equal(t, transaction.ClearingStatusUncleared, updates[1].Cleared)
+			}
+			return &transaction.OperationSummary{}, nil
+		}).Once()
+
+		state := &JobState{}
+		matched, err := syncService.matchManual(context.Background(), testJob, state, transactions, payloads)
+		require.NoError(t, err)
+		assert.Equal(t, map[int]bool{0: true, 2: true}, matched)
+		assert.Equal(t, "y1", state.Uploaded["t1"].YNABID)
+		assert.Equal(t, "y3", state.Pending[*payloads[2].ImportID].YNABID)
+	})
+
+	t.Run("skips imported and claimed transactions", func(t *testing.T) {
+		ynabMock := newMockynaber(t)
+		syncService := &SyncService{ynabService: ynabMock}
+
+		importID := "GC:other"
+		transactions := []Transaction{{ID: "t1", Date: date, AmountMili: -1000, Name: "Shop"}}
+		ynabMock.EXPECT().GetTransactionsByAccount("ccc", "bbb", mock.Anything).Return([]*transaction.Transaction{
+			{ID: "imported", Date: api.Date{Time: date}, Amount: -1000, ImportID: &importID, Cleared: transaction.ClearingStatusUncleared},
+			{ID: "transfer", Date: api.Date{Time: date}, Amount: -1000, Cleared: transaction.ClearingStatusUncleared},
+		}, nil).Once()
+
+		state := &JobState{}
+		state.MarkUploaded("other", UploadedTransaction{YNABID: "transfer"})
+		matched, err := syncService.matchManual(context.Background(), testJob, state, transactions, toYNABTransaction(testJob, JobState{}, transactions))
+		require.NoError(t, err)
+		assert.Empty(t, matched)
+	})
+}
+
+func TestIsManual(t *testing.T) {
+	importID := "GC:1"
+	assert.True(t, isManual(&transaction.Transaction{Cleared: transaction.ClearingStatusUncleared, Approved: true}))
+	assert.True(t, isManual(&transaction.Transaction{Cleared: transaction.ClearingStatusCleared}))
+	assert.False(t, isManual(&transaction.Transaction{Cleared: transaction.ClearingStatusCleared, Approved: true}))
+	assert.False(t, isManual(&transaction.Transaction{Cleared: transaction.ClearingStatusReconciled}))
+	assert.False(t, isManual(&transaction.Transaction{Cleared: transaction.ClearingStatusUncleared, ImportID: &importID}))
+	assert.False(t, isManual(&transaction.Transaction{Cleared: transaction.ClearingStatusUncleared, Deleted: true}))
+}
+
+func TestMatchManualTransaction(t *testing.T) {
+	date := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	shop, other := "Shop", "Other"
+	candidates := []*transaction.Transaction{
+		{ID: "far", Date: api.Date{Time: date.AddDate(0, 0, -6)}, Amount: -10000, PayeeName: &shop},
+		{ID: "close-amount", Date: api.Date{Time: date}, Amount: -11000, PayeeName: &shop},
+		{ID: "same-amount", Date: api.Date{Time: date.AddDate(0, 0, -2)}, Amount: -10000, PayeeName: &other},
+		{ID: "same-payee", Date: api.Date{Time: date.AddDate(0, 0, -3)}, Amount: -10000, PayeeName: &shop},
+	}
+	t1 := Transaction{Date: date, AmountMili: -10000, Name: "shop"}
+
+	match := func(taken map[string]bool) string {
+		if c := matchManualTransaction(t1, candidates, taken); c != nil {
+			return c.ID
+		}
+		return ""
+	}
+	assert.Equal(t, "same-payee", match(nil))
+	assert.Equal(t, "same-amount", match(map[string]bool{"same-payee": true}))
+	assert.Equal(t, "close-amount", match(map[string]bool{"same-payee": true, "same-amount": true}))
+	assert.Equal(t, "", match(map[string]bool{"same-payee": true, "same-amount": true, "close-amount": true}))
+}
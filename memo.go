@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+// maxMemoLength is the number of characters YNAB accepts in a memo
+const maxMemoLength = 200
+
+// memoTemplate renders the memo of a job's transactions from everything GoCardless gave about them
+type memoTemplate struct {
+	// path is the file the template was read from
+	path string
+	tmpl *template.Template
+}
+
+// memoData is what memo templates are executed with: every field of the GoCardless transaction, like
+// .AdditionalInformation or .TransactionAmount.Amount, along with the memo and payee the transaction gets without
+// a template
+type memoData struct {
+	gocardless.Transaction
+	Memo  string
+	Payee string
+}
+
+// memoFuncs are the helpers memo templates can use, the text they work on is their last argument so they can be
+// used in pipelines like {{ .AdditionalInformation | extract "REF (\\w+)" | trim }}
+var memoFuncs = template.FuncMap{
+	// trim removes leading and trailing whitespace
+	"trim": strings.TrimSpace,
+	// squash trims the text and replaces runs of whitespace with a single space
+	"squash": func(text string) string { return strings.Join(strings.Fields(text), " ") },
+	// extract returns the first group the regex captured in the text, or the whole match when it has no groups
+	"extract": func(pattern, text string) (string, error) {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", err
+		}
+		match := regex.FindStringSubmatch(text)
+		switch {
+		case match == nil:
+			return "", nil
+		case len(match) > 1:
+			return match[1], nil
+		default:
+			return match[0], nil
+		}
+	},
+	// replace replaces the matches of the regex in the text, ${1} in the replacement is the first group
+	"replace": func(pattern, replacement, text string) (string, error) {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", err
+		}
+		return regex.ReplaceAllString(text, replacement), nil
+	},
+	// truncate cuts the text to at most n characters
+	"truncate": truncateRunes,
+	// coalesce returns the first of its arguments that isn't blank
+	"coalesce": func(texts ...string) string {
+		for _, text := range texts {
+			if strings.TrimSpace(text) != "" {
+				return text
+			}
+		}
+		return ""
+	},
+	// join joins lines like .RemittanceInformationUnstructuredArray with a separator
+	"join": func(separator string, lines []string) string { return strings.Join(lines, separator) },
+}
+
+// parseMemoTemplate reads and parses the memo template in the file at path
+func parseMemoTemplate(path string) (*memoTemplate, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memo template: %w", err)
+	}
+	return newMemoTemplate(path, string(text))
+}
+
+// newMemoTemplate parses text as the memo template of the file at path
+func newMemoTemplate(path, text string) (*memoTemplate, error) {
+	tmpl, err := template.New(path).Funcs(memoFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memo template: %w", err)
+	}
+	return &memoTemplate{path: path, tmpl: tmpl}, nil
+}
+
+// render executes the template for t, trimming the result. Without a template it returns the memo t already has.
+func (m *memoTemplate) render(t Transaction) (string, error) {
+	if m == nil {
+		return t.Memo, nil
+	}
+
+	data := memoData{Memo: t.Memo, Payee: t.Name}
+	if t.Source != nil {
+		data.Transaction = *t.Source
+	}
+
+	var memo bytes.Buffer
+	if err := m.tmpl.Execute(&memo, data); err != nil {
+		return "", fmt.Errorf("failed to render memo template %s: %w", m.path, err)
+	}
+	return strings.TrimSpace(memo.String()), nil
+}
+
+// withMemoTemplate returns t with the memo the template of the job renders, a memo that fails to render is logged
+// and the transaction keeps its memo
+func withMemoTemplate(ctx context.Context, j job, t Transaction) Transaction {
+	memo, err := j.MemoTemplate.render(t)
+	if err != nil {
+		slog.Default().WarnContext(ctx, "kept the memo of transaction", "gocardless_account_id", j.GCAccountID, "id", t.ID, "error", err)
+		return t
+	}
+	t.Memo = memo
+	return t
+}
+
+// truncateRunes cuts text to at most n characters, keeping multi-byte characters whole
+func truncateRunes(n int, text string) string {
+	if n < 0 || utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	return string([]rune(text)[:n])
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+func TestMemoTemplateRender(t *testing.T) {
+	source := &gocardless.Transaction{
+		TransactionAmount:                      gocardless.Amount{Amount: "-12.34", Currency: "EUR"},
+		RemittanceInformationUnstructured:      "PAYMENT REF",
+		RemittanceInformationUnstructuredArray: []string{"Invoice 7", "Thank you"},
+		AdditionalInformation:                  "  CARD 1234   LIDL   REF:AB12 WARSZAWA ",
+		MerchantCategoryCode:                   "5411",
+	}
+	transaction := Transaction{Name: "Lidl", Memo: "PAYMENT REF", Source: source}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "field of the bank's transaction", template: "{{ .AdditionalInformation }}", want: "CARD 1234   LIDL   REF:AB12 WARSZAWA"},
+		{name: "memo and payee", template: "{{ .Payee }}: {{ .Memo }}", want: "Lidl: PAYMENT REF"},
+		{name: "nested fields", template: "{{ .TransactionAmount.Amount }} {{ .TransactionAmount.Currency }}", want: "-12.34 EUR"},
+		{name: "squash", template: "{{ .AdditionalInformation | squash }}", want: "CARD 1234 LIDL REF:AB12 WARSZAWA"},
+		{name: "extract a group", template: `{{ .AdditionalInformation | extract "REF:(\\w+)" }}`, want: "AB12"},
+		{name: "extract the match", template: `{{ .AdditionalInformation | extract "LIDL|ALDI" }}`, want: "LIDL"},
+		{name: "extract without a match", template: `[{{ .AdditionalInformation | extract "ALDI" }}]`, want: "[]"},
+		{name: "replace", template: `{{ .AdditionalInformation | replace "CARD \\d+" "card" | squash }}`, want: "card LIDL REF:AB12 WARSZAWA"},
+		{name: "truncate", template: "{{ .AdditionalInformation | squash | truncate 9 }}", want: "CARD 1234"},
+		{name: "coalesce", template: "{{ coalesce .RemittanceInformationStructured .RemittanceInformationUnstructured }}", want: "PAYMENT REF"},
+		{name: "join", template: `{{ join " / " .RemittanceInformationUnstructuredArray }}`, want: "Invoice 7 / Thank you"},
+		{name: "conditions", template: `{{ if eq .MerchantCategoryCode "5411" }}groceries{{ else }}other{{ end }}`, want: "groceries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newMemoTemplate("memo.tmpl", tt.template)
+			require.NoError(t, err)
+
+			memo, err := m.render(transaction)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, memo)
+		})
+	}
+
+	t.Run("without a template", func(t *testing.T) {
+		var m *memoTemplate
+		memo, err := m.render(transaction)
+		assert.NoError(t, err)
+		assert.Equal(t, "PAYMENT REF", memo)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := newMemoTemplate("memo.tmpl", "{{ .AdditionalInformation | unknown }}")
+		assert.ErrorContains(t, err, "invalid memo template")
+	})
+
+	t.Run("failing template", func(t *testing.T) {
+		m, err := newMemoTemplate("memo.tmpl", `{{ .AdditionalInformation | extract "(" }}`)
+		require.NoError(t, err)
+
+		_, err = m.render(transaction)
+		assert.ErrorContains(t, err, "failed to render memo template memo.tmpl")
+
+		// The transaction keeps its memo
+		kept := withMemoTemplate(context.Background(), job{MemoTemplate: m}, transaction)
+		assert.Equal(t, "PAYMENT REF", kept.Memo)
+	})
+}
+
+func TestTruncateRunes(t *testing.T) {
+	assert.Equal(t, "Zażółć", truncateRunes(6, "Zażółć gęślą jaźń"))
+	assert.Equal(t, "short", truncateRunes(10, "short"))
+	assert.Len(t, []rune(truncateRunes(maxMemoLength, strings.Repeat("ą", 300))), maxMemoLength)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api"
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/pkg/errors"
+)
+
+// ImportIDMigration summarizes matching the transactions already in YNAB to GoCardless transactions
+type ImportIDMigration struct {
+	// Matched is the number of GoCardless transactions found in YNAB and recorded as uploaded
+	Matched int
+	// AlreadyRecorded is the number of GoCardless transactions that were already recorded as uploaded
+	AlreadyRecorded int
+	// NotInYNAB is the number of GoCardless transactions without a YNAB transaction using their old import ID
+	NotInYNAB int
+	// Pending is the number of pending GoCardless transactions whose state was moved to their new import IDs,
+	// they're reconciled with their booked counterpart like before
+	Pending int
+	// WithoutID is the number of GoCardless transactions the bank didn't give an ID, they keep their import IDs
+	WithoutID int
+	// UnmatchedYNAB is the number of imported YNAB transactions no GoCardless transaction maps to
+	UnmatchedYNAB int
+}
+
+// MigrateImportIDs records which YNAB transaction every GoCardless transaction of the last days was uploaded as,
+// using the import IDs built by the from strategy. Recorded transactions are never uploaded again,
+// so the job can switch to a different import ID strategy without creating duplicates. Pending transactions aren't
+// recorded as uploaded, their state is moved to the import IDs of the job's strategy instead.
+func (s *SyncService) MigrateImportIDs(ctx context.Context, j job, from importIDStrategy, days int, dryRun bool) (ImportIDMigration, error) {
+	txn := s.monitorService.StartTransaction("migrateImportIDs")
+	defer txn.End()
+
+	l := slog.Default().With("gocardless_account_id", j.GCAccountID, "ynab_account_id", j.YNABAccountID, "ynab_budget_id", j.YNABBudgetID, "from", from, "to", j.ImportIDStrategy)
+	migration := ImportIDMigration{}
+
+	if days < 1 || days > maxHistoricalDays {
+		return migration, errors.Errorf("migration days must be between 1 and %d, got %d", maxHistoricalDays, days)
+	}
+
+	state, err := s.stateStore.JobState(j.key())
+	if err != nil {
+		s.monitorService.RecordError(txn, err)
+		return migration, errors.Wrap(err, "failed to load job state")
+	}
+
+	to := time.Now().UTC().Truncate(time.Hour)
+	since := to.AddDate(0, 0, -days).Truncate(24 * time.Hour)
+
+	ctx = s.monitorService.NewContext(ctx, txn)
+	if err := s.gcService.LogIn(ctx); err != nil {
+		s.monitorService.RecordError(txn, err)
+		return migration, errors.Wrap(err, "failed to log in")
+	}
+
+	transactions, _, err := s.listTransactions(ctx, j, since, to)
+	if err != nil {
+		s.monitorService.RecordError(txn, err)
+		return migration, errors.Wrap(err, "failed to list transactions")
+	}
+
+	sinceDate := api.Date{Time: since}
+	ynabTransactions, err := s.ynabService.GetTransactionsByAccount(j.YNABBudgetID, j.YNABAccountID, &transaction.Filter{Since: &sinceDate})
+	if err != nil {
+		s.monitorService.RecordError(txn, err)
+		return migration, errors.Wrap(err, "failed to list YNAB transactions")
+	}
+
+	ynabByImportID := make(map[string]*transaction.Transaction, len(ynabTransactions))
+	for _, t := range ynabTransactions {
+		if t.ImportID != nil && !t.Deleted {
+			ynabByImportID[*t.ImportID] = t
+		}
+	}
+
+	oldJob := j
+	oldJob.ImportIDStrategy = from
+	oldPayloadTransactions := toYNABTransaction(oldJob, state, transactions)
+	payloadTransactions := toYNABTransaction(j, state, transactions)
+
+	matchedImportIDs := make(map[string]bool)
+	// Pending state is moved once every transaction is seen, a new import ID may be the old one of another transaction
+	movedPending := make(map[string]PendingTransaction)
+	now := time.Now().UTC()
+	for i, t := range transactions {
+		importID := *oldPayloadTransactions[i].ImportID
+		ynabTransaction, inYNAB := ynabByImportID[importID]
+		if inYNAB {
+			matchedImportIDs[importID] = true
+		}
+
+		if t.Pending {
+			pending, ok := state.Pending[importID]
+			if !ok && !inYNAB {
+				migration.NotInYNAB++
+				l.InfoContext(ctx, "pending transaction not found in YNAB", "id", t.ID, "import_id", importID, "date", t.Date.Format("2006-01-02"), "amount", t.AmountMili, "payee", t.Name)
+				continue
+			}
+			if !ok {
+				pending = PendingTransaction{ID: t.ID, Date: t.Date, AmountMili: t.AmountMili, Name: t.Name}
+			}
+			if pending.YNABID == "" && inYNAB {
+				pending.YNABID = ynabTransaction.ID
+			}
+			delete(state.Pending, importID)
+			movedPending[*payloadTransactions[i].ImportID] = pending
+			migration.Pending++
+			continue
+		}
+
+		switch {
+		case t.ID == "":
+			migration.WithoutID++
+		case state.IsUploaded(t.ID):
+			migration.AlreadyRecorded++
+		case !inYNAB:
+			migration.NotInYNAB++
+			l.InfoContext(ctx, "transaction not found in YNAB", "id", t.ID, "import_id", importID, "date", t.Date.Format("2006-01-02"), "amount", t.AmountMili, "payee", t.Name)
+		default:
+			migration.Matched++
+			state.MarkUploaded(t.ID, UploadedTransaction{
+				ImportID:   importID,
+				YNABID:     ynabTransaction.ID,
+				Date:       t.Date,
+				AmountMili: t.AmountMili,
+				UploadedAt: now,
+			})
+		}
+	}
+	for importID, pending := range movedPending {
+		state.MarkPending(importID, pending)
+	}
+
+	for importID := range ynabByImportID {
+		if !matchedImportIDs[importID] {
+			migration.UnmatchedYNAB++
+		}
+	}
+
+	l.InfoContext(ctx, "matched import IDs", "matched", migration.Matched, "already_recorded", migration.AlreadyRecorded, "pending", migration.Pending, "not_in_ynab", migration.NotInYNAB, "without_id", migration.WithoutID, "unmatched_ynab", migration.UnmatchedYNAB, "dry_run", dryRun)
+	if dryRun {
+		return migration, nil
+	}
+
+	if err := s.stateStore.SaveJobState(j.key(), state); err != nil {
+		s.monitorService.RecordError(txn, err)
+		return migration, errors.Wrap(err, "failed to save job state")
+	}
+
+	return migration, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMigrateImportIDs(t *testing.T) {
+	// Setup
+	goCardlessMock := newMockgoCardlesser(t)
+	ynabMock := newMockynaber(t)
+	monitorMock := NewMockMonitoringServicer(t)
+
+	nowTS := time.Now().UTC().Truncate(time.Hour)
+	since := nowTS.AddDate(0, 0, -30).Truncate(24 * time.Hour)
+	date := nowTS.AddDate(0, 0, -2).Truncate(24 * time.Hour)
+
+	transactions := []Transaction{
+		{ID: "coffee1", Date: date, AmountMili: -4500, Name: "Coffee"},
+		{ID: "coffee2", Date: date, AmountMili: -4500, Name: "Coffee"},
+		{ID: "", Date: date, AmountMili: -100, Name: "Fee"},
+		{ID: "rent", Date: date, AmountMili: -900000, Name: "Landlord"},
+	}
+	importID1 := "YNAB:-4500:" + date.Format("2006-01-02") + ":1"
+	otherImportID := "YNAB:-1:2020-01-01:1"
+
+	testJob := job{
+		GCAccountID:      "aaa",
+		YNABAccountID:    "bbb",
+		YNABBudgetID:     "ccc",
+		LookbackDays:     defaultLookbackDays,
+		ImportIDStrategy: importIDTransactionID,
+	}
+
+	stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	assert.NoError(t, err)
+	state := JobState{}
+	state.MarkUploaded("rent", UploadedTransaction{ImportID: "YNAB:-900000:" + date.Format("2006-01-02") + ":1", Date: date})
+	assert.NoError(t, stateStore.SaveJobState(testJob.key(), state))
+
+	goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil)
+	goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", since, nowTS).Return(transactions, nil)
+	ynabMock.EXPECT().GetTransactionsByAccount("ccc", "bbb", mock.Anything).Return([]*transaction.Transaction{
+		{ID: "ynab-coffee1", ImportID: &importID1},
+		{ID: "ynab-other", ImportID: &otherImportID},
+		{ID: "ynab-manual"},
+	}, nil)
+
+	mockTxn := &newrelic.Transaction{}
+	monitorMock.On("StartTransaction", "migrateImportIDs").Return(mockTxn)
+	monitorMock.On("NewContext", mock.Anything, mockTxn).Return(context.Background())
+
+	syncService := NewSyncService(goCardlessMock, ynabMock, monitorMock, stateStore, nil, []job{testJob}, 1)
+
+	// Test
+	migration, err := syncService.MigrateImportIDs(context.Background(), testJob, importIDAmountDate, 30, false)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, ImportIDMigration{Matched: 1, AlreadyRecorded: 1, NotInYNAB: 1, WithoutID: 1, UnmatchedYNAB: 1}, migration)
+
+	state, err = stateStore.JobState(testJob.key())
+	assert.NoError(t, err)
+	assert.True(t, state.IsUploaded("coffee1"))
+	assert.Equal(t, "ynab-coffee1", state.Uploaded["coffee1"].YNABID)
+	assert.False(t, state.IsUploaded("coffee2"))
+}
+
+func TestMigrateImportIDsPending(t *testing.T) {
+	// Setup
+	goCardlessMock := newMockgoCardlesser(t)
+	ynabMock := newMockynaber(t)
+	monitorMock := NewMockMonitoringServicer(t)
+
+	nowTS := time.Now().UTC().Truncate(time.Hour)
+	date := nowTS.AddDate(0, 0, -1).Truncate(24 * time.Hour)
+
+	transactions := []Transaction{
+		{ID: "card1", Date: date, AmountMili: -7000, Name: "Cinema", Pending: true},
+		{ID: "card2", Date: date, AmountMili: -3000, Name: "Bakery", Pending: true},
+	}
+	oldImportID1 := "PENDING:-7000:" + date.Format("2006-01-02") + ":1"
+	oldImportID2 := "PENDING:-3000:" + date.Format("2006-01-02") + ":1"
+
+	testJob := job{
+		GCAccountID:      "aaa",
+		YNABAccountID:    "bbb",
+		YNABBudgetID:     "ccc",
+		LookbackDays:     defaultLookbackDays,
+		ImportIDStrategy: importIDTransactionID,
+	}
+
+	stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	assert.NoError(t, err)
+	state := JobState{}
+	state.MarkPending(oldImportID1, PendingTransaction{YNABID: "ynab-card1", Date: date, AmountMili: -7000, Name: "Cinema"})
+	assert.NoError(t, stateStore.SaveJobState(testJob.key(), state))
+
+	goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil)
+	goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", mock.Anything, mock.Anything).Return(transactions, nil)
+	ynabMock.EXPECT().GetTransactionsByAccount("ccc", "bbb", mock.Anything).Return([]*transaction.Transaction{
+		{ID: "ynab-card1", ImportID: &oldImportID1},
+		{ID: "ynab-card2", ImportID: &oldImportID2},
+	}, nil)
+
+	mockTxn := &newrelic.Transaction{}
+	monitorMock.On("StartTransaction", "migrateImportIDs").Return(mockTxn)
+	monitorMock.On("NewContext", mock.Anything, mockTxn).Return(context.Background())
+
+	syncService := NewSyncService(goCardlessMock, ynabMock, monitorMock, stateStore, nil, []job{testJob}, 1)
+
+	// Test
+	migration, err := syncService.MigrateImportIDs(context.Background(), testJob, importIDAmountDate, 30, false)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, ImportIDMigration{Pending: 2}, migration)
+
+	state, err = stateStore.JobState(testJob.key())
+	assert.NoError(t, err)
+	// Still pending at the bank, so the booked transactions must not be skipped as uploaded
+	assert.False(t, state.IsUploaded("card1"))
+	assert.False(t, state.IsUploaded("card2"))
+
+	// The next synchronization finds them still pending under the job's import IDs
+	stillPending := pendingImportIDs(toYNABTransaction(testJob, JobState{}, transactions), transactions)
+	assert.Len(t, state.Pending, 2)
+	for importID, pending := range state.Pending {
+		assert.True(t, stillPending[importID], importID)
+		assert.Contains(t, []string{"ynab-card1", "ynab-card2"}, pending.YNABID)
+	}
+	assert.Equal(t, "card2", state.Pending[*toYNABTransaction(testJob, JobState{}, transactions[1:])[0].ImportID].ID)
+}
@@ -8,9 +8,13 @@ import (
 	"context"
 	"time"
 
+	"github.com/brunomvsouza/ynab.go/api/account"
+	"github.com/brunomvsouza/ynab.go/api/budget"
+	"github.com/brunomvsouza/ynab.go/api/category"
 	"github.com/brunomvsouza/ynab.go/api/transaction"
 	"github.com/newrelic/go-agent/v3/newrelic"
 	mock "github.com/stretchr/testify/mock"
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
 )
 
 // newMockgoCardlesser creates a new instance of mockgoCardlesser. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
@@ -40,6 +44,72 @@ func (_m *mockgoCardlesser) EXPECT() *mockgoCardlesser_Expecter {
 	return &mockgoCardlesser_Expecter{mock: &_m.Mock}
 }
 
+// GetAccount provides a mock function for the type mockgoCardlesser
+func (_mock *mockgoCardlesser) GetAccount(ctx context.Context, accountID string) (gocardless.Account, error) {
+	ret := _mock.Called(ctx, accountID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAccount")
+	}
+
+	var r0 gocardless.Account
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (gocardless.Account, error)); ok {
+		return returnFunc(ctx, accountID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) gocardless.Account); ok {
+		r0 = returnFunc(ctx, accountID)
+	} else {
+		r0 = ret.Get(0).(gocardless.Account)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, accountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockgoCardlesser_GetAccount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAccount'
+type mockgoCardlesser_GetAccount_Call struct {
+	*mock.Call
+}
+
+// GetAccount is a helper method to define mock.On call
+//   - ctx context.Context
+//   - accountID string
+func (_e *mockgoCardlesser_Expecter) GetAccount(ctx interface{}, accountID interface{}) *mockgoCardlesser_GetAccount_Call {
+	return &mockgoCardlesser_GetAccount_Call{Call: _e.mock.On("GetAccount", ctx, accountID)}
+}
+
+func (_c *mockgoCardlesser_GetAccount_Call) Run(run func(ctx context.Context, accountID string)) *mockgoCardlesser_GetAccount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgoCardlesser_GetAccount_Call) Return(account gocardless.Account, err error) *mockgoCardlesser_GetAccount_Call {
+	_c.Call.Return(account, err)
+	return _c
+}
+
+func (_c *mockgoCardlesser_GetAccount_Call) RunAndReturn(run func(ctx context.Context, accountID string) (gocardless.Account, error)) *mockgoCardlesser_GetAccount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListTransactions provides a mock function for the type mockgoCardlesser
 func (_mock *mockgoCardlesser) ListTransactions(ctx context.Context, accountID string, from time.Time, to time.Time) ([]Transaction, error) {
 	ret := _mock.Called(ctx, accountID, from, to)
@@ -249,6 +319,72 @@ func (_m *MockGoCardlessServicer) EXPECT() *MockGoCardlessServicer_Expecter {
 	return &MockGoCardlessServicer_Expecter{mock: &_m.Mock}
 }
 
+// GetAccount provides a mock function for the type MockGoCardlessServicer
+func (_mock *MockGoCardlessServicer) GetAccount(ctx context.Context, accountID string) (gocardless.Account, error) {
+	ret := _mock.Called(ctx, accountID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAccount")
+	}
+
+	var r0 gocardless.Account
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (gocardless.Account, error)); ok {
+		return returnFunc(ctx, accountID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) gocardless.Account); ok {
+		r0 = returnFunc(ctx, accountID)
+	} else {
+		r0 = ret.Get(0).(gocardless.Account)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, accountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockGoCardlessServicer_GetAccount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAccount'
+type MockGoCardlessServicer_GetAccount_Call struct {
+	*mock.Call
+}
+
+// GetAccount is a helper method to define mock.On call
+//   - ctx context.Context
+//   - accountID string
+func (_e *MockGoCardlessServicer_Expecter) GetAccount(ctx interface{}, accountID interface{}) *MockGoCardlessServicer_GetAccount_Call {
+	return &MockGoCardlessServicer_GetAccount_Call{Call: _e.mock.On("GetAccount", ctx, accountID)}
+}
+
+func (_c *MockGoCardlessServicer_GetAccount_Call) Run(run func(ctx context.Context, accountID string)) *MockGoCardlessServicer_GetAccount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockGoCardlessServicer_GetAccount_Call) Return(account gocardless.Account, err error) *MockGoCardlessServicer_GetAccount_Call {
+	_c.Call.Return(account, err)
+	return _c
+}
+
+func (_c *MockGoCardlessServicer_GetAccount_Call) RunAndReturn(run func(ctx context.Context, accountID string) (gocardless.Account, error)) *MockGoCardlessServicer_GetAccount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListTransactions provides a mock function for the type MockGoCardlessServicer
 func (_mock *MockGoCardlessServicer) ListTransactions(ctx context.Context, accountID string, from time.Time, to time.Time) ([]Transaction, error) {
 	ret := _mock.Called(ctx, accountID, from, to)
@@ -526,71 +662,55 @@ func (_c *MockYNABServicer_CreateTransactions_Call) RunAndReturn(run func(budget
 	return _c
 }
 
-// NewMockSynchronizationServicer creates a new instance of MockSynchronizationServicer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
-// The first argument is typically a *testing.T value.
-func NewMockSynchronizationServicer(t interface {
-	mock.TestingT
-	Cleanup(func())
-}) *MockSynchronizationServicer {
-	mock := &MockSynchronizationServicer{}
-	mock.Mock.Test(t)
-
-	t.Cleanup(func() { mock.AssertExpectations(t) })
-
-	return mock
-}
-
-// MockSynchronizationServicer is an autogenerated mock type for the SynchronizationServicer type
-type MockSynchronizationServicer struct {
-	mock.Mock
-}
-
-type MockSynchronizationServicer_Expecter struct {
-	mock *mock.Mock
-}
-
-func (_m *MockSynchronizationServicer) EXPECT() *MockSynchronizationServicer_Expecter {
-	return &MockSynchronizationServicer_Expecter{mock: &_m.Mock}
-}
-
-// SynchronizeTransaction provides a mock function for the type MockSynchronizationServicer
-func (_mock *MockSynchronizationServicer) SynchronizeTransaction(ctx context.Context, j job) error {
-	ret := _mock.Called(ctx, j)
+// DeleteTransaction provides a mock function for the type MockYNABServicer
+func (_mock *MockYNABServicer) DeleteTransaction(budgetID string, transactionID string) (*transaction.Transaction, error) {
+	ret := _mock.Called(budgetID, transactionID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SynchronizeTransaction")
+		panic("no return value specified for DeleteTransaction")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, job) error); ok {
-		r0 = returnFunc(ctx, j)
+	var r0 *transaction.Transaction
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, string) (*transaction.Transaction, error)); ok {
+		return returnFunc(budgetID, transactionID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, string) *transaction.Transaction); ok {
+		r0 = returnFunc(budgetID, transactionID)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*transaction.Transaction)
+		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = returnFunc(budgetID, transactionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockSynchronizationServicer_SynchronizeTransaction_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SynchronizeTransaction'
-type MockSynchronizationServicer_SynchronizeTransaction_Call struct {
+// MockYNABServicer_DeleteTransaction_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteTransaction'
+type MockYNABServicer_DeleteTransaction_Call struct {
 	*mock.Call
 }
 
-// SynchronizeTransaction is a helper method to define mock.On call
-//   - ctx context.Context
-//   - j job
-func (_e *MockSynchronizationServicer_Expecter) SynchronizeTransaction(ctx interface{}, j interface{}) *MockSynchronizationServicer_SynchronizeTransaction_Call {
-	return &MockSynchronizationServicer_SynchronizeTransaction_Call{Call: _e.mock.On("SynchronizeTransaction", ctx, j)}
+// DeleteTransaction is a helper method to define mock.On call
+//   - budgetID string
+//   - transactionID string
+func (_e *MockYNABServicer_Expecter) DeleteTransaction(budgetID interface{}, transactionID interface{}) *MockYNABServicer_DeleteTransaction_Call {
+	return &MockYNABServicer_DeleteTransaction_Call{Call: _e.mock.On("DeleteTransaction", budgetID, transactionID)}
 }
 
-func (_c *MockSynchronizationServicer_SynchronizeTransaction_Call) Run(run func(ctx context.Context, j job)) *MockSynchronizationServicer_SynchronizeTransaction_Call {
+func (_c *MockYNABServicer_DeleteTransaction_Call) Run(run func(budgetID string, transactionID string)) *MockYNABServicer_DeleteTransaction_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 context.Context
+		var arg0 string
 		if args[0] != nil {
-			arg0 = args[0].(context.Context)
+			arg0 = args[0].(string)
 		}
-		var arg1 job
+		var arg1 string
 		if args[1] != nil {
-			arg1 = args[1].(job)
+			arg1 = args[1].(string)
 		}
 		run(
 			arg0,
@@ -600,49 +720,60 @@ func (_c *MockSynchronizationServicer_SynchronizeTransaction_Call) Run(run func(
 	return _c
 }
 
-func (_c *MockSynchronizationServicer_SynchronizeTransaction_Call) Return(err error) *MockSynchronizationServicer_SynchronizeTransaction_Call {
-	_c.Call.Return(err)
+func (_c *MockYNABServicer_DeleteTransaction_Call) Return(transaction1 *transaction.Transaction, err error) *MockYNABServicer_DeleteTransaction_Call {
+	_c.Call.Return(transaction1, err)
 	return _c
 }
 
-func (_c *MockSynchronizationServicer_SynchronizeTransaction_Call) RunAndReturn(run func(ctx context.Context, j job) error) *MockSynchronizationServicer_SynchronizeTransaction_Call {
+func (_c *MockYNABServicer_DeleteTransaction_Call) RunAndReturn(run func(budgetID string, transactionID string) (*transaction.Transaction, error)) *MockYNABServicer_DeleteTransaction_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SynchronizeTransactions provides a mock function for the type MockSynchronizationServicer
-func (_mock *MockSynchronizationServicer) SynchronizeTransactions(ctx context.Context) error {
-	ret := _mock.Called(ctx)
+// GetAccounts provides a mock function for the type MockYNABServicer
+func (_mock *MockYNABServicer) GetAccounts(budgetID string) ([]*account.Account, error) {
+	ret := _mock.Called(budgetID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SynchronizeTransactions")
+		panic("no return value specified for GetAccounts")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context) error); ok {
-		r0 = returnFunc(ctx)
+	var r0 []*account.Account
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) ([]*account.Account, error)); ok {
+		return returnFunc(budgetID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) []*account.Account); ok {
+		r0 = returnFunc(budgetID)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*account.Account)
+		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(budgetID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockSynchronizationServicer_SynchronizeTransactions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SynchronizeTransactions'
-type MockSynchronizationServicer_SynchronizeTransactions_Call struct {
+// MockYNABServicer_GetAccounts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAccounts'
+type MockYNABServicer_GetAccounts_Call struct {
 	*mock.Call
 }
 
-// SynchronizeTransactions is a helper method to define mock.On call
-//   - ctx context.Context
-func (_e *MockSynchronizationServicer_Expecter) SynchronizeTransactions(ctx interface{}) *MockSynchronizationServicer_SynchronizeTransactions_Call {
-	return &MockSynchronizationServicer_SynchronizeTransactions_Call{Call: _e.mock.On("SynchronizeTransactions", ctx)}
+// GetAccounts is a helper method to define mock.On call
+//   - budgetID string
+func (_e *MockYNABServicer_Expecter) GetAccounts(budgetID interface{}) *MockYNABServicer_GetAccounts_Call {
+	return &MockYNABServicer_GetAccounts_Call{Call: _e.mock.On("GetAccounts", budgetID)}
 }
 
-func (_c *MockSynchronizationServicer_SynchronizeTransactions_Call) Run(run func(ctx context.Context)) *MockSynchronizationServicer_SynchronizeTransactions_Call {
+func (_c *MockYNABServicer_GetAccounts_Call) Run(run func(budgetID string)) *MockYNABServicer_GetAccounts_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 context.Context
+		var arg0 string
 		if args[0] != nil {
-			arg0 = args[0].(context.Context)
+			arg0 = args[0].(string)
 		}
 		run(
 			arg0,
@@ -651,130 +782,1654 @@ func (_c *MockSynchronizationServicer_SynchronizeTransactions_Call) Run(run func
 	return _c
 }
 
-func (_c *MockSynchronizationServicer_SynchronizeTransactions_Call) Return(err error) *MockSynchronizationServicer_SynchronizeTransactions_Call {
-	_c.Call.Return(err)
+func (_c *MockYNABServicer_GetAccounts_Call) Return(accounts []*account.Account, err error) *MockYNABServicer_GetAccounts_Call {
+	_c.Call.Return(accounts, err)
 	return _c
 }
 
-func (_c *MockSynchronizationServicer_SynchronizeTransactions_Call) RunAndReturn(run func(ctx context.Context) error) *MockSynchronizationServicer_SynchronizeTransactions_Call {
+func (_c *MockYNABServicer_GetAccounts_Call) RunAndReturn(run func(budgetID string) ([]*account.Account, error)) *MockYNABServicer_GetAccounts_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// NewMockMonitoringServicer creates a new instance of MockMonitoringServicer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
-// The first argument is typically a *testing.T value.
-func NewMockMonitoringServicer(t interface {
-	mock.TestingT
-	Cleanup(func())
-}) *MockMonitoringServicer {
-	mock := &MockMonitoringServicer{}
-	mock.Mock.Test(t)
-
-	t.Cleanup(func() { mock.AssertExpectations(t) })
-
-	return mock
-}
-
-// MockMonitoringServicer is an autogenerated mock type for the MonitoringServicer type
-type MockMonitoringServicer struct {
-	mock.Mock
-}
-
-type MockMonitoringServicer_Expecter struct {
-	mock *mock.Mock
-}
+// GetBudgetSettings provides a mock function for the type MockYNABServicer
+func (_mock *MockYNABServicer) GetBudgetSettings(budgetID string) (*budget.Settings, error) {
+	ret := _mock.Called(budgetID)
 
-func (_m *MockMonitoringServicer) EXPECT() *MockMonitoringServicer_Expecter {
-	return &MockMonitoringServicer_Expecter{mock: &_m.Mock}
-}
+	if len(ret) == 0 {
+		panic("no return value specified for GetBudgetSettings")
+	}
 
-// AddAttribute provides a mock function for the type MockMonitoringServicer
-func (_mock *MockMonitoringServicer) AddAttribute(txn *newrelic.Transaction, key string, value interface{}) {
-	_mock.Called(txn, key, value)
-	return
+	var r0 *budget.Settings
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (*budget.Settings, error)); ok {
+		return returnFunc(budgetID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) *budget.Settings); ok {
+		r0 = returnFunc(budgetID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*budget.Settings)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(budgetID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockMonitoringServicer_AddAttribute_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddAttribute'
-type MockMonitoringServicer_AddAttribute_Call struct {
+// MockYNABServicer_GetBudgetSettings_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBudgetSettings'
+type MockYNABServicer_GetBudgetSettings_Call struct {
 	*mock.Call
 }
 
-// AddAttribute is a helper method to define mock.On call
-//   - txn *newrelic.Transaction
-//   - key string
-//   - value interface{}
-func (_e *MockMonitoringServicer_Expecter) AddAttribute(txn interface{}, key interface{}, value interface{}) *MockMonitoringServicer_AddAttribute_Call {
-	return &MockMonitoringServicer_AddAttribute_Call{Call: _e.mock.On("AddAttribute", txn, key, value)}
+// GetBudgetSettings is a helper method to define mock.On call
+//   - budgetID string
+func (_e *MockYNABServicer_Expecter) GetBudgetSettings(budgetID interface{}) *MockYNABServicer_GetBudgetSettings_Call {
+	return &MockYNABServicer_GetBudgetSettings_Call{Call: _e.mock.On("GetBudgetSettings", budgetID)}
 }
 
-func (_c *MockMonitoringServicer_AddAttribute_Call) Run(run func(txn *newrelic.Transaction, key string, value interface{})) *MockMonitoringServicer_AddAttribute_Call {
+func (_c *MockYNABServicer_GetBudgetSettings_Call) Run(run func(budgetID string)) *MockYNABServicer_GetBudgetSettings_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 *newrelic.Transaction
+		var arg0 string
 		if args[0] != nil {
-			arg0 = args[0].(*newrelic.Transaction)
-		}
-		var arg1 string
-		if args[1] != nil {
-			arg1 = args[1].(string)
-		}
-		var arg2 interface{}
-		if args[2] != nil {
-			arg2 = args[2].(interface{})
+			arg0 = args[0].(string)
 		}
 		run(
 			arg0,
-			arg1,
-			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *MockMonitoringServicer_AddAttribute_Call) Return() *MockMonitoringServicer_AddAttribute_Call {
-	_c.Call.Return()
+func (_c *MockYNABServicer_GetBudgetSettings_Call) Return(settings *budget.Settings, err error) *MockYNABServicer_GetBudgetSettings_Call {
+	_c.Call.Return(settings, err)
 	return _c
 }
 
-func (_c *MockMonitoringServicer_AddAttribute_Call) RunAndReturn(run func(txn *newrelic.Transaction, key string, value interface{})) *MockMonitoringServicer_AddAttribute_Call {
-	_c.Run(run)
+func (_c *MockYNABServicer_GetBudgetSettings_Call) RunAndReturn(run func(budgetID string) (*budget.Settings, error)) *MockYNABServicer_GetBudgetSettings_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// FromContext provides a mock function for the type MockMonitoringServicer
-func (_mock *MockMonitoringServicer) FromContext(ctx context.Context) *newrelic.Transaction {
-	ret := _mock.Called(ctx)
+// GetBudgets provides a mock function for the type MockYNABServicer
+func (_mock *MockYNABServicer) GetBudgets() ([]*budget.Summary, error) {
+	ret := _mock.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for FromContext")
+		panic("no return value specified for GetBudgets")
 	}
 
-	var r0 *newrelic.Transaction
-	if returnFunc, ok := ret.Get(0).(func(context.Context) *newrelic.Transaction); ok {
-		r0 = returnFunc(ctx)
+	var r0 []*budget.Summary
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func() ([]*budget.Summary, error)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() []*budget.Summary); ok {
+		r0 = returnFunc()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*newrelic.Transaction)
+			r0 = ret.Get(0).([]*budget.Summary)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func() error); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockYNABServicer_GetBudgets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBudgets'
+type MockYNABServicer_GetBudgets_Call struct {
+	*mock.Call
+}
+
+// GetBudgets is a helper method to define mock.On call
+func (_e *MockYNABServicer_Expecter) GetBudgets() *MockYNABServicer_GetBudgets_Call {
+	return &MockYNABServicer_GetBudgets_Call{Call: _e.mock.On("GetBudgets")}
+}
+
+func (_c *MockYNABServicer_GetBudgets_Call) Run(run func()) *MockYNABServicer_GetBudgets_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockYNABServicer_GetBudgets_Call) Return(summarys []*budget.Summary, err error) *MockYNABServicer_GetBudgets_Call {
+	_c.Call.Return(summarys, err)
+	return _c
+}
+
+func (_c *MockYNABServicer_GetBudgets_Call) RunAndReturn(run func() ([]*budget.Summary, error)) *MockYNABServicer_GetBudgets_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCategories provides a mock function for the type MockYNABServicer
+func (_mock *MockYNABServicer) GetCategories(budgetID string) ([]*category.GroupWithCategories, error) {
+	ret := _mock.Called(budgetID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCategories")
+	}
+
+	var r0 []*category.GroupWithCategories
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) ([]*category.GroupWithCategories, error)); ok {
+		return returnFunc(budgetID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) []*category.GroupWithCategories); ok {
+		r0 = returnFunc(budgetID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*category.GroupWithCategories)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(budgetID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockYNABServicer_GetCategories_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCategories'
+type MockYNABServicer_GetCategories_Call struct {
+	*mock.Call
+}
+
+// GetCategories is a helper method to define mock.On call
+//   - budgetID string
+func (_e *MockYNABServicer_Expecter) GetCategories(budgetID interface{}) *MockYNABServicer_GetCategories_Call {
+	return &MockYNABServicer_GetCategories_Call{Call: _e.mock.On("GetCategories", budgetID)}
+}
+
+func (_c *MockYNABServicer_GetCategories_Call) Run(run func(budgetID string)) *MockYNABServicer_GetCategories_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockYNABServicer_GetCategories_Call) Return(groupWithCategoriess []*category.GroupWithCategories, err error) *MockYNABServicer_GetCategories_Call {
+	_c.Call.Return(groupWithCategoriess, err)
+	return _c
+}
+
+func (_c *MockYNABServicer_GetCategories_Call) RunAndReturn(run func(budgetID string) ([]*category.GroupWithCategories, error)) *MockYNABServicer_GetCategories_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTransactionsByAccount provides a mock function for the type MockYNABServicer
+func (_mock *MockYNABServicer) GetTransactionsByAccount(budgetID string, accountID string, f *transaction.Filter) ([]*transaction.Transaction, error) {
+	ret := _mock.Called(budgetID, accountID, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTransactionsByAccount")
+	}
+
+	var r0 []*transaction.Transaction
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, string, *transaction.Filter) ([]*transaction.Transaction, error)); ok {
+		return returnFunc(budgetID, accountID, f)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, string, *transaction.Filter) []*transaction.Transaction); ok {
+		r0 = returnFunc(budgetID, accountID, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*transaction.Transaction)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, string, *transaction.Filter) error); ok {
+		r1 = returnFunc(budgetID, accountID, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockYNABServicer_GetTransactionsByAccount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTransactionsByAccount'
+type MockYNABServicer_GetTransactionsByAccount_Call struct {
+	*mock.Call
+}
+
+// GetTransactionsByAccount is a helper method to define mock.On call
+//   - budgetID string
+//   - accountID string
+//   - f *transaction.Filter
+func (_e *MockYNABServicer_Expecter) GetTransactionsByAccount(budgetID interface{}, accountID interface{}, f interface{}) *MockYNABServicer_GetTransactionsByAccount_Call {
+	return &MockYNABServicer_GetTransactionsByAccount_Call{Call: _e.mock.On("GetTransactionsByAccount", budgetID, accountID, f)}
+}
+
+func (_c *MockYNABServicer_GetTransactionsByAccount_Call) Run(run func(budgetID string, accountID string, f *transaction.Filter)) *MockYNABServicer_GetTransactionsByAccount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 *transaction.Filter
+		if args[2] != nil {
+			arg2 = args[2].(*transaction.Filter)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockYNABServicer_GetTransactionsByAccount_Call) Return(transactions []*transaction.Transaction, err error) *MockYNABServicer_GetTransactionsByAccount_Call {
+	_c.Call.Return(transactions, err)
+	return _c
+}
+
+func (_c *MockYNABServicer_GetTransactionsByAccount_Call) RunAndReturn(run func(budgetID string, accountID string, f *transaction.Filter) ([]*transaction.Transaction, error)) *MockYNABServicer_GetTransactionsByAccount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTransferPayeeIDs provides a mock function for the type MockYNABServicer
+func (_mock *MockYNABServicer) GetTransferPayeeIDs(budgetID string) (map[string]string, error) {
+	ret := _mock.Called(budgetID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTransferPayeeIDs")
+	}
+
+	var r0 map[string]string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (map[string]string, error)); ok {
+		return returnFunc(budgetID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) map[string]string); ok {
+		r0 = returnFunc(budgetID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(budgetID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockYNABServicer_GetTransferPayeeIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTransferPayeeIDs'
+type MockYNABServicer_GetTransferPayeeIDs_Call struct {
+	*mock.Call
+}
+
+// GetTransferPayeeIDs is a helper method to define mock.On call
+//   - budgetID string
+func (_e *MockYNABServicer_Expecter) GetTransferPayeeIDs(budgetID interface{}) *MockYNABServicer_GetTransferPayeeIDs_Call {
+	return &MockYNABServicer_GetTransferPayeeIDs_Call{Call: _e.mock.On("GetTransferPayeeIDs", budgetID)}
+}
+
+func (_c *MockYNABServicer_GetTransferPayeeIDs_Call) Run(run func(budgetID string)) *MockYNABServicer_GetTransferPayeeIDs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockYNABServicer_GetTransferPayeeIDs_Call) Return(stringToString map[string]string, err error) *MockYNABServicer_GetTransferPayeeIDs_Call {
+	_c.Call.Return(stringToString, err)
+	return _c
+}
+
+func (_c *MockYNABServicer_GetTransferPayeeIDs_Call) RunAndReturn(run func(budgetID string) (map[string]string, error)) *MockYNABServicer_GetTransferPayeeIDs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateTransactions provides a mock function for the type MockYNABServicer
+func (_mock *MockYNABServicer) UpdateTransactions(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error) {
+	ret := _mock.Called(budgetID, p)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTransactions")
+	}
+
+	var r0 *transaction.OperationSummary
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, []transaction.PayloadTransaction) (*transaction.OperationSummary, error)); ok {
+		return returnFunc(budgetID, p)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, []transaction.PayloadTransaction) *transaction.OperationSummary); ok {
+		r0 = returnFunc(budgetID, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*transaction.OperationSummary)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, []transaction.PayloadTransaction) error); ok {
+		r1 = returnFunc(budgetID, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockYNABServicer_UpdateTransactions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateTransactions'
+type MockYNABServicer_UpdateTransactions_Call struct {
+	*mock.Call
+}
+
+// UpdateTransactions is a helper method to define mock.On call
+//   - budgetID string
+//   - p []transaction.PayloadTransaction
+func (_e *MockYNABServicer_Expecter) UpdateTransactions(budgetID interface{}, p interface{}) *MockYNABServicer_UpdateTransactions_Call {
+	return &MockYNABServicer_UpdateTransactions_Call{Call: _e.mock.On("UpdateTransactions", budgetID, p)}
+}
+
+func (_c *MockYNABServicer_UpdateTransactions_Call) Run(run func(budgetID string, p []transaction.PayloadTransaction)) *MockYNABServicer_UpdateTransactions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 []transaction.PayloadTransaction
+		if args[1] != nil {
+			arg1 = args[1].([]transaction.PayloadTransaction)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockYNABServicer_UpdateTransactions_Call) Return(operationSummary *transaction.OperationSummary, err error) *MockYNABServicer_UpdateTransactions_Call {
+	_c.Call.Return(operationSummary, err)
+	return _c
+}
+
+func (_c *MockYNABServicer_UpdateTransactions_Call) RunAndReturn(run func(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error)) *MockYNABServicer_UpdateTransactions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockSynchronizationServicer creates a new instance of MockSynchronizationServicer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockSynchronizationServicer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockSynchronizationServicer {
+	mock := &MockSynchronizationServicer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockSynchronizationServicer is an autogenerated mock type for the SynchronizationServicer type
+type MockSynchronizationServicer struct {
+	mock.Mock
+}
+
+type MockSynchronizationServicer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockSynchronizationServicer) EXPECT() *MockSynchronizationServicer_Expecter {
+	return &MockSynchronizationServicer_Expecter{mock: &_m.Mock}
+}
+
+// Backfill provides a mock function for the type MockSynchronizationServicer
+func (_mock *MockSynchronizationServicer) Backfill(ctx context.Context, j job, days int, chunkDays int) error {
+	ret := _mock.Called(ctx, j, days, chunkDays)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Backfill")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, job, int, int) error); ok {
+		r0 = returnFunc(ctx, j, days, chunkDays)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockSynchronizationServicer_Backfill_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Backfill'
+type MockSynchronizationServicer_Backfill_Call struct {
+	*mock.Call
+}
+
+// Backfill is a helper method to define mock.On call
+//   - ctx context.Context
+//   - j job
+//   - days int
+//   - chunkDays int
+func (_e *MockSynchronizationServicer_Expecter) Backfill(ctx interface{}, j interface{}, days interface{}, chunkDays interface{}) *MockSynchronizationServicer_Backfill_Call {
+	return &MockSynchronizationServicer_Backfill_Call{Call: _e.mock.On("Backfill", ctx, j, days, chunkDays)}
+}
+
+func (_c *MockSynchronizationServicer_Backfill_Call) Run(run func(ctx context.Context, j job, days int, chunkDays int)) *MockSynchronizationServicer_Backfill_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 job
+		if args[1] != nil {
+			arg1 = args[1].(job)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockSynchronizationServicer_Backfill_Call) Return(err error) *MockSynchronizationServicer_Backfill_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockSynchronizationServicer_Backfill_Call) RunAndReturn(run func(ctx context.Context, j job, days int, chunkDays int) error) *MockSynchronizationServicer_Backfill_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListJobTransactions provides a mock function for the type MockSynchronizationServicer
+func (_mock *MockSynchronizationServicer) ListJobTransactions(ctx context.Context, j job, from time.Time, to time.Time) ([]Transaction, int, error) {
+	ret := _mock.Called(ctx, j, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListJobTransactions")
+	}
+
+	var r0 []Transaction
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, job, time.Time, time.Time) ([]Transaction, int, error)); ok {
+		return returnFunc(ctx, j, from, to)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, job, time.Time, time.Time) []Transaction); ok {
+		r0 = returnFunc(ctx, j, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Transaction)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, job, time.Time, time.Time) int); ok {
+		r1 = returnFunc(ctx, j, from, to)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, job, time.Time, time.Time) error); ok {
+		r2 = returnFunc(ctx, j, from, to)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockSynchronizationServicer_ListJobTransactions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListJobTransactions'
+type MockSynchronizationServicer_ListJobTransactions_Call struct {
+	*mock.Call
+}
+
+// ListJobTransactions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - j job
+//   - from time.Time
+//   - to time.Time
+func (_e *MockSynchronizationServicer_Expecter) ListJobTransactions(ctx interface{}, j interface{}, from interface{}, to interface{}) *MockSynchronizationServicer_ListJobTransactions_Call {
+	return &MockSynchronizationServicer_ListJobTransactions_Call{Call: _e.mock.On("ListJobTransactions", ctx, j, from, to)}
+}
+
+func (_c *MockSynchronizationServicer_ListJobTransactions_Call) Run(run func(ctx context.Context, j job, from time.Time, to time.Time)) *MockSynchronizationServicer_ListJobTransactions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 job
+		if args[1] != nil {
+			arg1 = args[1].(job)
+		}
+		var arg2 time.Time
+		if args[2] != nil {
+			arg2 = args[2].(time.Time)
+		}
+		var arg3 time.Time
+		if args[3] != nil {
+			arg3 = args[3].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockSynchronizationServicer_ListJobTransactions_Call) Return(transactions []Transaction, n int, err error) *MockSynchronizationServicer_ListJobTransactions_Call {
+	_c.Call.Return(transactions, n, err)
+	return _c
+}
+
+func (_c *MockSynchronizationServicer_ListJobTransactions_Call) RunAndReturn(run func(ctx context.Context, j job, from time.Time, to time.Time) ([]Transaction, int, error)) *MockSynchronizationServicer_ListJobTransactions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MigrateImportIDs provides a mock function for the type MockSynchronizationServicer
+func (_mock *MockSynchronizationServicer) MigrateImportIDs(ctx context.Context, j job, from importIDStrategy, days int, dryRun bool) (ImportIDMigration, error) {
+	ret := _mock.Called(ctx, j, from, days, dryRun)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MigrateImportIDs")
+	}
+
+	var r0 ImportIDMigration
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, job, importIDStrategy, int, bool) (ImportIDMigration, error)); ok {
+		return returnFunc(ctx, j, from, days, dryRun)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, job, importIDStrategy, int, bool) ImportIDMigration); ok {
+		r0 = returnFunc(ctx, j, from, days, dryRun)
+	} else {
+		r0 = ret.Get(0).(ImportIDMigration)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, job, importIDStrategy, int, bool) error); ok {
+		r1 = returnFunc(ctx, j, from, days, dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSynchronizationServicer_MigrateImportIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MigrateImportIDs'
+type MockSynchronizationServicer_MigrateImportIDs_Call struct {
+	*mock.Call
+}
+
+// MigrateImportIDs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - j job
+//   - from importIDStrategy
+//   - days int
+//   - dryRun bool
+func (_e *MockSynchronizationServicer_Expecter) MigrateImportIDs(ctx interface{}, j interface{}, from interface{}, days interface{}, dryRun interface{}) *MockSynchronizationServicer_MigrateImportIDs_Call {
+	return &MockSynchronizationServicer_MigrateImportIDs_Call{Call: _e.mock.On("MigrateImportIDs", ctx, j, from, days, dryRun)}
+}
+
+func (_c *MockSynchronizationServicer_MigrateImportIDs_Call) Run(run func(ctx context.Context, j job, from importIDStrategy, days int, dryRun bool)) *MockSynchronizationServicer_MigrateImportIDs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 job
+		if args[1] != nil {
+			arg1 = args[1].(job)
+		}
+		var arg2 importIDStrategy
+		if args[2] != nil {
+			arg2 = args[2].(importIDStrategy)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		var arg4 bool
+		if args[4] != nil {
+			arg4 = args[4].(bool)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *MockSynchronizationServicer_MigrateImportIDs_Call) Return(importIDMigration ImportIDMigration, err error) *MockSynchronizationServicer_MigrateImportIDs_Call {
+	_c.Call.Return(importIDMigration, err)
+	return _c
+}
+
+func (_c *MockSynchronizationServicer_MigrateImportIDs_Call) RunAndReturn(run func(ctx context.Context, j job, from importIDStrategy, days int, dryRun bool) (ImportIDMigration, error)) *MockSynchronizationServicer_MigrateImportIDs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SynchronizeTransaction provides a mock function for the type MockSynchronizationServicer
+func (_mock *MockSynchronizationServicer) SynchronizeTransaction(ctx context.Context, j job) (JobResult, error) {
+	ret := _mock.Called(ctx, j)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SynchronizeTransaction")
+	}
+
+	var r0 JobResult
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, job) (JobResult, error)); ok {
+		return returnFunc(ctx, j)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, job) JobResult); ok {
+		r0 = returnFunc(ctx, j)
+	} else {
+		r0 = ret.Get(0).(JobResult)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, job) error); ok {
+		r1 = returnFunc(ctx, j)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSynchronizationServicer_SynchronizeTransaction_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SynchronizeTransaction'
+type MockSynchronizationServicer_SynchronizeTransaction_Call struct {
+	*mock.Call
+}
+
+// SynchronizeTransaction is a helper method to define mock.On call
+//   - ctx context.Context
+//   - j job
+func (_e *MockSynchronizationServicer_Expecter) SynchronizeTransaction(ctx interface{}, j interface{}) *MockSynchronizationServicer_SynchronizeTransaction_Call {
+	return &MockSynchronizationServicer_SynchronizeTransaction_Call{Call: _e.mock.On("SynchronizeTransaction", ctx, j)}
+}
+
+func (_c *MockSynchronizationServicer_SynchronizeTransaction_Call) Run(run func(ctx context.Context, j job)) *MockSynchronizationServicer_SynchronizeTransaction_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 job
+		if args[1] != nil {
+			arg1 = args[1].(job)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockSynchronizationServicer_SynchronizeTransaction_Call) Return(jobResult JobResult, err error) *MockSynchronizationServicer_SynchronizeTransaction_Call {
+	_c.Call.Return(jobResult, err)
+	return _c
+}
+
+func (_c *MockSynchronizationServicer_SynchronizeTransaction_Call) RunAndReturn(run func(ctx context.Context, j job) (JobResult, error)) *MockSynchronizationServicer_SynchronizeTransaction_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SynchronizeTransactions provides a mock function for the type MockSynchronizationServicer
+func (_mock *MockSynchronizationServicer) SynchronizeTransactions(ctx context.Context, jobs []job) (SyncReport, error) {
+	ret := _mock.Called(ctx, jobs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SynchronizeTransactions")
+	}
+
+	var r0 SyncReport
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []job) (SyncReport, error)); ok {
+		return returnFunc(ctx, jobs)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []job) SyncReport); ok {
+		r0 = returnFunc(ctx, jobs)
+	} else {
+		r0 = ret.Get(0).(SyncReport)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []job) error); ok {
+		r1 = returnFunc(ctx, jobs)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSynchronizationServicer_SynchronizeTransactions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SynchronizeTransactions'
+type MockSynchronizationServicer_SynchronizeTransactions_Call struct {
+	*mock.Call
+}
+
+// SynchronizeTransactions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - jobs []job
+func (_e *MockSynchronizationServicer_Expecter) SynchronizeTransactions(ctx interface{}, jobs interface{}) *MockSynchronizationServicer_SynchronizeTransactions_Call {
+	return &MockSynchronizationServicer_SynchronizeTransactions_Call{Call: _e.mock.On("SynchronizeTransactions", ctx, jobs)}
+}
+
+func (_c *MockSynchronizationServicer_SynchronizeTransactions_Call) Run(run func(ctx context.Context, jobs []job)) *MockSynchronizationServicer_SynchronizeTransactions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []job
+		if args[1] != nil {
+			arg1 = args[1].([]job)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockSynchronizationServicer_SynchronizeTransactions_Call) Return(syncReport SyncReport, err error) *MockSynchronizationServicer_SynchronizeTransactions_Call {
+	_c.Call.Return(syncReport, err)
+	return _c
+}
+
+func (_c *MockSynchronizationServicer_SynchronizeTransactions_Call) RunAndReturn(run func(ctx context.Context, jobs []job) (SyncReport, error)) *MockSynchronizationServicer_SynchronizeTransactions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockStateStorer creates a new instance of MockStateStorer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockStateStorer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockStateStorer {
+	mock := &MockStateStorer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockStateStorer is an autogenerated mock type for the StateStorer type
+type MockStateStorer struct {
+	mock.Mock
+}
+
+type MockStateStorer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockStateStorer) EXPECT() *MockStateStorer_Expecter {
+	return &MockStateStorer_Expecter{mock: &_m.Mock}
+}
+
+// JobState provides a mock function for the type MockStateStorer
+func (_mock *MockStateStorer) JobState(key string) (JobState, error) {
+	ret := _mock.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for JobState")
+	}
+
+	var r0 JobState
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (JobState, error)); ok {
+		return returnFunc(key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) JobState); ok {
+		r0 = returnFunc(key)
+	} else {
+		r0 = ret.Get(0).(JobState)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStateStorer_JobState_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'JobState'
+type MockStateStorer_JobState_Call struct {
+	*mock.Call
+}
+
+// JobState is a helper method to define mock.On call
+//   - key string
+func (_e *MockStateStorer_Expecter) JobState(key interface{}) *MockStateStorer_JobState_Call {
+	return &MockStateStorer_JobState_Call{Call: _e.mock.On("JobState", key)}
+}
+
+func (_c *MockStateStorer_JobState_Call) Run(run func(key string)) *MockStateStorer_JobState_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockStateStorer_JobState_Call) Return(jobState JobState, err error) *MockStateStorer_JobState_Call {
+	_c.Call.Return(jobState, err)
+	return _c
+}
+
+func (_c *MockStateStorer_JobState_Call) RunAndReturn(run func(key string) (JobState, error)) *MockStateStorer_JobState_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RateLimit provides a mock function for the type MockStateStorer
+func (_mock *MockStateStorer) RateLimit(key string) (gocardless.RateLimit, error) {
+	ret := _mock.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RateLimit")
+	}
+
+	var r0 gocardless.RateLimit
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (gocardless.RateLimit, error)); ok {
+		return returnFunc(key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) gocardless.RateLimit); ok {
+		r0 = returnFunc(key)
+	} else {
+		r0 = ret.Get(0).(gocardless.RateLimit)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStateStorer_RateLimit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RateLimit'
+type MockStateStorer_RateLimit_Call struct {
+	*mock.Call
+}
+
+// RateLimit is a helper method to define mock.On call
+//   - key string
+func (_e *MockStateStorer_Expecter) RateLimit(key interface{}) *MockStateStorer_RateLimit_Call {
+	return &MockStateStorer_RateLimit_Call{Call: _e.mock.On("RateLimit", key)}
+}
+
+func (_c *MockStateStorer_RateLimit_Call) Run(run func(key string)) *MockStateStorer_RateLimit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockStateStorer_RateLimit_Call) Return(rateLimit gocardless.RateLimit, err error) *MockStateStorer_RateLimit_Call {
+	_c.Call.Return(rateLimit, err)
+	return _c
+}
+
+func (_c *MockStateStorer_RateLimit_Call) RunAndReturn(run func(key string) (gocardless.RateLimit, error)) *MockStateStorer_RateLimit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveJobState provides a mock function for the type MockStateStorer
+func (_mock *MockStateStorer) SaveJobState(key string, state JobState) error {
+	ret := _mock.Called(key, state)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveJobState")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, JobState) error); ok {
+		r0 = returnFunc(key, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockStateStorer_SaveJobState_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveJobState'
+type MockStateStorer_SaveJobState_Call struct {
+	*mock.Call
+}
+
+// SaveJobState is a helper method to define mock.On call
+//   - key string
+//   - state JobState
+func (_e *MockStateStorer_Expecter) SaveJobState(key interface{}, state interface{}) *MockStateStorer_SaveJobState_Call {
+	return &MockStateStorer_SaveJobState_Call{Call: _e.mock.On("SaveJobState", key, state)}
+}
+
+func (_c *MockStateStorer_SaveJobState_Call) Run(run func(key string, state JobState)) *MockStateStorer_SaveJobState_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 JobState
+		if args[1] != nil {
+			arg1 = args[1].(JobState)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockStateStorer_SaveJobState_Call) Return(err error) *MockStateStorer_SaveJobState_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockStateStorer_SaveJobState_Call) RunAndReturn(run func(key string, state JobState) error) *MockStateStorer_SaveJobState_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveRateLimit provides a mock function for the type MockStateStorer
+func (_mock *MockStateStorer) SaveRateLimit(key string, limit gocardless.RateLimit) error {
+	ret := _mock.Called(key, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveRateLimit")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, gocardless.RateLimit) error); ok {
+		r0 = returnFunc(key, limit)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockStateStorer_SaveRateLimit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveRateLimit'
+type MockStateStorer_SaveRateLimit_Call struct {
+	*mock.Call
+}
+
+// SaveRateLimit is a helper method to define mock.On call
+//   - key string
+//   - limit gocardless.RateLimit
+func (_e *MockStateStorer_Expecter) SaveRateLimit(key interface{}, limit interface{}) *MockStateStorer_SaveRateLimit_Call {
+	return &MockStateStorer_SaveRateLimit_Call{Call: _e.mock.On("SaveRateLimit", key, limit)}
+}
+
+func (_c *MockStateStorer_SaveRateLimit_Call) Run(run func(key string, limit gocardless.RateLimit)) *MockStateStorer_SaveRateLimit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 gocardless.RateLimit
+		if args[1] != nil {
+			arg1 = args[1].(gocardless.RateLimit)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockStateStorer_SaveRateLimit_Call) Return(err error) *MockStateStorer_SaveRateLimit_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockStateStorer_SaveRateLimit_Call) RunAndReturn(run func(key string, limit gocardless.RateLimit) error) *MockStateStorer_SaveRateLimit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockMonitoringServicer creates a new instance of MockMonitoringServicer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockMonitoringServicer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockMonitoringServicer {
+	mock := &MockMonitoringServicer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockMonitoringServicer is an autogenerated mock type for the MonitoringServicer type
+type MockMonitoringServicer struct {
+	mock.Mock
+}
+
+type MockMonitoringServicer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockMonitoringServicer) EXPECT() *MockMonitoringServicer_Expecter {
+	return &MockMonitoringServicer_Expecter{mock: &_m.Mock}
+}
+
+// AddAttribute provides a mock function for the type MockMonitoringServicer
+func (_mock *MockMonitoringServicer) AddAttribute(txn *newrelic.Transaction, key string, value interface{}) {
+	_mock.Called(txn, key, value)
+	return
+}
+
+// MockMonitoringServicer_AddAttribute_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddAttribute'
+type MockMonitoringServicer_AddAttribute_Call struct {
+	*mock.Call
+}
+
+// AddAttribute is a helper method to define mock.On call
+//   - txn *newrelic.Transaction
+//   - key string
+//   - value interface{}
+func (_e *MockMonitoringServicer_Expecter) AddAttribute(txn interface{}, key interface{}, value interface{}) *MockMonitoringServicer_AddAttribute_Call {
+	return &MockMonitoringServicer_AddAttribute_Call{Call: _e.mock.On("AddAttribute", txn, key, value)}
+}
+
+func (_c *MockMonitoringServicer_AddAttribute_Call) Run(run func(txn *newrelic.Transaction, key string, value interface{})) *MockMonitoringServicer_AddAttribute_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *newrelic.Transaction
+		if args[0] != nil {
+			arg0 = args[0].(*newrelic.Transaction)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 interface{}
+		if args[2] != nil {
+			arg2 = args[2].(interface{})
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockMonitoringServicer_AddAttribute_Call) Return() *MockMonitoringServicer_AddAttribute_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockMonitoringServicer_AddAttribute_Call) RunAndReturn(run func(txn *newrelic.Transaction, key string, value interface{})) *MockMonitoringServicer_AddAttribute_Call {
+	_c.Run(run)
+	return _c
+}
+
+// FromContext provides a mock function for the type MockMonitoringServicer
+func (_mock *MockMonitoringServicer) FromContext(ctx context.Context) *newrelic.Transaction {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FromContext")
+	}
+
+	var r0 *newrelic.Transaction
+	if returnFunc, ok := ret.Get(0).(func(context.Context) *newrelic.Transaction); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*newrelic.Transaction)
+		}
+	}
+	return r0
+}
+
+// MockMonitoringServicer_FromContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FromContext'
+type MockMonitoringServicer_FromContext_Call struct {
+	*mock.Call
+}
+
+// FromContext is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockMonitoringServicer_Expecter) FromContext(ctx interface{}) *MockMonitoringServicer_FromContext_Call {
+	return &MockMonitoringServicer_FromContext_Call{Call: _e.mock.On("FromContext", ctx)}
+}
+
+func (_c *MockMonitoringServicer_FromContext_Call) Run(run func(ctx context.Context)) *MockMonitoringServicer_FromContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockMonitoringServicer_FromContext_Call) Return(transaction1 *newrelic.Transaction) *MockMonitoringServicer_FromContext_Call {
+	_c.Call.Return(transaction1)
+	return _c
+}
+
+func (_c *MockMonitoringServicer_FromContext_Call) RunAndReturn(run func(ctx context.Context) *newrelic.Transaction) *MockMonitoringServicer_FromContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewContext provides a mock function for the type MockMonitoringServicer
+func (_mock *MockMonitoringServicer) NewContext(ctx context.Context, txn *newrelic.Transaction) context.Context {
+	ret := _mock.Called(ctx, txn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NewContext")
+	}
+
+	var r0 context.Context
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *newrelic.Transaction) context.Context); ok {
+		r0 = returnFunc(ctx, txn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(context.Context)
+		}
+	}
+	return r0
+}
+
+// MockMonitoringServicer_NewContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NewContext'
+type MockMonitoringServicer_NewContext_Call struct {
+	*mock.Call
+}
+
+// NewContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - txn *newrelic.Transaction
+func (_e *MockMonitoringServicer_Expecter) NewContext(ctx interface{}, txn interface{}) *MockMonitoringServicer_NewContext_Call {
+	return &MockMonitoringServicer_NewContext_Call{Call: _e.mock.On("NewContext", ctx, txn)}
+}
+
+func (_c *MockMonitoringServicer_NewContext_Call) Run(run func(ctx context.Context, txn *newrelic.Transaction)) *MockMonitoringServicer_NewContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *newrelic.Transaction
+		if args[1] != nil {
+			arg1 = args[1].(*newrelic.Transaction)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockMonitoringServicer_NewContext_Call) Return(context1 context.Context) *MockMonitoringServicer_NewContext_Call {
+	_c.Call.Return(context1)
+	return _c
+}
+
+func (_c *MockMonitoringServicer_NewContext_Call) RunAndReturn(run func(ctx context.Context, txn *newrelic.Transaction) context.Context) *MockMonitoringServicer_NewContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordError provides a mock function for the type MockMonitoringServicer
+func (_mock *MockMonitoringServicer) RecordError(txn *newrelic.Transaction, err error) {
+	_mock.Called(txn, err)
+	return
+}
+
+// MockMonitoringServicer_RecordError_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordError'
+type MockMonitoringServicer_RecordError_Call struct {
+	*mock.Call
+}
+
+// RecordError is a helper method to define mock.On call
+//   - txn *newrelic.Transaction
+//   - err error
+func (_e *MockMonitoringServicer_Expecter) RecordError(txn interface{}, err interface{}) *MockMonitoringServicer_RecordError_Call {
+	return &MockMonitoringServicer_RecordError_Call{Call: _e.mock.On("RecordError", txn, err)}
+}
+
+func (_c *MockMonitoringServicer_RecordError_Call) Run(run func(txn *newrelic.Transaction, err error)) *MockMonitoringServicer_RecordError_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *newrelic.Transaction
+		if args[0] != nil {
+			arg0 = args[0].(*newrelic.Transaction)
+		}
+		var arg1 error
+		if args[1] != nil {
+			arg1 = args[1].(error)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockMonitoringServicer_RecordError_Call) Return() *MockMonitoringServicer_RecordError_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockMonitoringServicer_RecordError_Call) RunAndReturn(run func(txn *newrelic.Transaction, err error)) *MockMonitoringServicer_RecordError_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Shutdown provides a mock function for the type MockMonitoringServicer
+func (_mock *MockMonitoringServicer) Shutdown(timeout time.Duration) {
+	_mock.Called(timeout)
+	return
+}
+
+// MockMonitoringServicer_Shutdown_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Shutdown'
+type MockMonitoringServicer_Shutdown_Call struct {
+	*mock.Call
+}
+
+// Shutdown is a helper method to define mock.On call
+//   - timeout time.Duration
+func (_e *MockMonitoringServicer_Expecter) Shutdown(timeout interface{}) *MockMonitoringServicer_Shutdown_Call {
+	return &MockMonitoringServicer_Shutdown_Call{Call: _e.mock.On("Shutdown", timeout)}
+}
+
+func (_c *MockMonitoringServicer_Shutdown_Call) Run(run func(timeout time.Duration)) *MockMonitoringServicer_Shutdown_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 time.Duration
+		if args[0] != nil {
+			arg0 = args[0].(time.Duration)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockMonitoringServicer_Shutdown_Call) Return() *MockMonitoringServicer_Shutdown_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockMonitoringServicer_Shutdown_Call) RunAndReturn(run func(timeout time.Duration)) *MockMonitoringServicer_Shutdown_Call {
+	_c.Run(run)
+	return _c
+}
+
+// StartSegment provides a mock function for the type MockMonitoringServicer
+func (_mock *MockMonitoringServicer) StartSegment(txn *newrelic.Transaction, name string) *newrelic.Segment {
+	ret := _mock.Called(txn, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartSegment")
+	}
+
+	var r0 *newrelic.Segment
+	if returnFunc, ok := ret.Get(0).(func(*newrelic.Transaction, string) *newrelic.Segment); ok {
+		r0 = returnFunc(txn, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*newrelic.Segment)
+		}
+	}
+	return r0
+}
+
+// MockMonitoringServicer_StartSegment_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartSegment'
+type MockMonitoringServicer_StartSegment_Call struct {
+	*mock.Call
+}
+
+// StartSegment is a helper method to define mock.On call
+//   - txn *newrelic.Transaction
+//   - name string
+func (_e *MockMonitoringServicer_Expecter) StartSegment(txn interface{}, name interface{}) *MockMonitoringServicer_StartSegment_Call {
+	return &MockMonitoringServicer_StartSegment_Call{Call: _e.mock.On("StartSegment", txn, name)}
+}
+
+func (_c *MockMonitoringServicer_StartSegment_Call) Run(run func(txn *newrelic.Transaction, name string)) *MockMonitoringServicer_StartSegment_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *newrelic.Transaction
+		if args[0] != nil {
+			arg0 = args[0].(*newrelic.Transaction)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockMonitoringServicer_StartSegment_Call) Return(segment *newrelic.Segment) *MockMonitoringServicer_StartSegment_Call {
+	_c.Call.Return(segment)
+	return _c
+}
+
+func (_c *MockMonitoringServicer_StartSegment_Call) RunAndReturn(run func(txn *newrelic.Transaction, name string) *newrelic.Segment) *MockMonitoringServicer_StartSegment_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StartTransaction provides a mock function for the type MockMonitoringServicer
+func (_mock *MockMonitoringServicer) StartTransaction(name string) *newrelic.Transaction {
+	ret := _mock.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartTransaction")
+	}
+
+	var r0 *newrelic.Transaction
+	if returnFunc, ok := ret.Get(0).(func(string) *newrelic.Transaction); ok {
+		r0 = returnFunc(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*newrelic.Transaction)
 		}
 	}
 	return r0
 }
 
-// MockMonitoringServicer_FromContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FromContext'
-type MockMonitoringServicer_FromContext_Call struct {
+// MockMonitoringServicer_StartTransaction_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartTransaction'
+type MockMonitoringServicer_StartTransaction_Call struct {
+	*mock.Call
+}
+
+// StartTransaction is a helper method to define mock.On call
+//   - name string
+func (_e *MockMonitoringServicer_Expecter) StartTransaction(name interface{}) *MockMonitoringServicer_StartTransaction_Call {
+	return &MockMonitoringServicer_StartTransaction_Call{Call: _e.mock.On("StartTransaction", name)}
+}
+
+func (_c *MockMonitoringServicer_StartTransaction_Call) Run(run func(name string)) *MockMonitoringServicer_StartTransaction_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockMonitoringServicer_StartTransaction_Call) Return(transaction1 *newrelic.Transaction) *MockMonitoringServicer_StartTransaction_Call {
+	_c.Call.Return(transaction1)
+	return _c
+}
+
+func (_c *MockMonitoringServicer_StartTransaction_Call) RunAndReturn(run func(name string) *newrelic.Transaction) *MockMonitoringServicer_StartTransaction_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockynaber creates a new instance of mockynaber. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockynaber(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockynaber {
+	mock := &mockynaber{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockynaber is an autogenerated mock type for the ynaber type
+type mockynaber struct {
+	mock.Mock
+}
+
+type mockynaber_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockynaber) EXPECT() *mockynaber_Expecter {
+	return &mockynaber_Expecter{mock: &_m.Mock}
+}
+
+// CreateTransactions provides a mock function for the type mockynaber
+func (_mock *mockynaber) CreateTransactions(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error) {
+	ret := _mock.Called(budgetID, p)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTransactions")
+	}
+
+	var r0 *transaction.OperationSummary
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, []transaction.PayloadTransaction) (*transaction.OperationSummary, error)); ok {
+		return returnFunc(budgetID, p)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, []transaction.PayloadTransaction) *transaction.OperationSummary); ok {
+		r0 = returnFunc(budgetID, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*transaction.OperationSummary)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, []transaction.PayloadTransaction) error); ok {
+		r1 = returnFunc(budgetID, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockynaber_CreateTransactions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateTransactions'
+type mockynaber_CreateTransactions_Call struct {
+	*mock.Call
+}
+
+// CreateTransactions is a helper method to define mock.On call
+//   - budgetID string
+//   - p []transaction.PayloadTransaction
+func (_e *mockynaber_Expecter) CreateTransactions(budgetID interface{}, p interface{}) *mockynaber_CreateTransactions_Call {
+	return &mockynaber_CreateTransactions_Call{Call: _e.mock.On("CreateTransactions", budgetID, p)}
+}
+
+func (_c *mockynaber_CreateTransactions_Call) Run(run func(budgetID string, p []transaction.PayloadTransaction)) *mockynaber_CreateTransactions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 []transaction.PayloadTransaction
+		if args[1] != nil {
+			arg1 = args[1].([]transaction.PayloadTransaction)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockynaber_CreateTransactions_Call) Return(operationSummary *transaction.OperationSummary, err error) *mockynaber_CreateTransactions_Call {
+	_c.Call.Return(operationSummary, err)
+	return _c
+}
+
+func (_c *mockynaber_CreateTransactions_Call) RunAndReturn(run func(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error)) *mockynaber_CreateTransactions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteTransaction provides a mock function for the type mockynaber
+func (_mock *mockynaber) DeleteTransaction(budgetID string, transactionID string) (*transaction.Transaction, error) {
+	ret := _mock.Called(budgetID, transactionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteTransaction")
+	}
+
+	var r0 *transaction.Transaction
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, string) (*transaction.Transaction, error)); ok {
+		return returnFunc(budgetID, transactionID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, string) *transaction.Transaction); ok {
+		r0 = returnFunc(budgetID, transactionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*transaction.Transaction)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = returnFunc(budgetID, transactionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockynaber_DeleteTransaction_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteTransaction'
+type mockynaber_DeleteTransaction_Call struct {
+	*mock.Call
+}
+
+// DeleteTransaction is a helper method to define mock.On call
+//   - budgetID string
+//   - transactionID string
+func (_e *mockynaber_Expecter) DeleteTransaction(budgetID interface{}, transactionID interface{}) *mockynaber_DeleteTransaction_Call {
+	return &mockynaber_DeleteTransaction_Call{Call: _e.mock.On("DeleteTransaction", budgetID, transactionID)}
+}
+
+func (_c *mockynaber_DeleteTransaction_Call) Run(run func(budgetID string, transactionID string)) *mockynaber_DeleteTransaction_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockynaber_DeleteTransaction_Call) Return(transaction1 *transaction.Transaction, err error) *mockynaber_DeleteTransaction_Call {
+	_c.Call.Return(transaction1, err)
+	return _c
+}
+
+func (_c *mockynaber_DeleteTransaction_Call) RunAndReturn(run func(budgetID string, transactionID string) (*transaction.Transaction, error)) *mockynaber_DeleteTransaction_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAccounts provides a mock function for the type mockynaber
+func (_mock *mockynaber) GetAccounts(budgetID string) ([]*account.Account, error) {
+	ret := _mock.Called(budgetID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAccounts")
+	}
+
+	var r0 []*account.Account
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) ([]*account.Account, error)); ok {
+		return returnFunc(budgetID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) []*account.Account); ok {
+		r0 = returnFunc(budgetID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*account.Account)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(budgetID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockynaber_GetAccounts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAccounts'
+type mockynaber_GetAccounts_Call struct {
 	*mock.Call
 }
 
-// FromContext is a helper method to define mock.On call
-//   - ctx context.Context
-func (_e *MockMonitoringServicer_Expecter) FromContext(ctx interface{}) *MockMonitoringServicer_FromContext_Call {
-	return &MockMonitoringServicer_FromContext_Call{Call: _e.mock.On("FromContext", ctx)}
+// GetAccounts is a helper method to define mock.On call
+//   - budgetID string
+func (_e *mockynaber_Expecter) GetAccounts(budgetID interface{}) *mockynaber_GetAccounts_Call {
+	return &mockynaber_GetAccounts_Call{Call: _e.mock.On("GetAccounts", budgetID)}
 }
 
-func (_c *MockMonitoringServicer_FromContext_Call) Run(run func(ctx context.Context)) *MockMonitoringServicer_FromContext_Call {
+func (_c *mockynaber_GetAccounts_Call) Run(run func(budgetID string)) *mockynaber_GetAccounts_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 context.Context
+		var arg0 string
 		if args[0] != nil {
-			arg0 = args[0].(context.Context)
+			arg0 = args[0].(string)
 		}
 		run(
 			arg0,
@@ -783,143 +2438,177 @@ func (_c *MockMonitoringServicer_FromContext_Call) Run(run func(ctx context.Cont
 	return _c
 }
 
-func (_c *MockMonitoringServicer_FromContext_Call) Return(transaction1 *newrelic.Transaction) *MockMonitoringServicer_FromContext_Call {
-	_c.Call.Return(transaction1)
+func (_c *mockynaber_GetAccounts_Call) Return(accounts []*account.Account, err error) *mockynaber_GetAccounts_Call {
+	_c.Call.Return(accounts, err)
 	return _c
 }
 
-func (_c *MockMonitoringServicer_FromContext_Call) RunAndReturn(run func(ctx context.Context) *newrelic.Transaction) *MockMonitoringServicer_FromContext_Call {
+func (_c *mockynaber_GetAccounts_Call) RunAndReturn(run func(budgetID string) ([]*account.Account, error)) *mockynaber_GetAccounts_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// NewContext provides a mock function for the type MockMonitoringServicer
-func (_mock *MockMonitoringServicer) NewContext(ctx context.Context, txn *newrelic.Transaction) context.Context {
-	ret := _mock.Called(ctx, txn)
+// GetBudgetSettings provides a mock function for the type mockynaber
+func (_mock *mockynaber) GetBudgetSettings(budgetID string) (*budget.Settings, error) {
+	ret := _mock.Called(budgetID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for NewContext")
+		panic("no return value specified for GetBudgetSettings")
 	}
 
-	var r0 context.Context
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *newrelic.Transaction) context.Context); ok {
-		r0 = returnFunc(ctx, txn)
+	var r0 *budget.Settings
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (*budget.Settings, error)); ok {
+		return returnFunc(budgetID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) *budget.Settings); ok {
+		r0 = returnFunc(budgetID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(context.Context)
+			r0 = ret.Get(0).(*budget.Settings)
 		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(budgetID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockMonitoringServicer_NewContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NewContext'
-type MockMonitoringServicer_NewContext_Call struct {
+// mockynaber_GetBudgetSettings_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBudgetSettings'
+type mockynaber_GetBudgetSettings_Call struct {
 	*mock.Call
 }
 
-// NewContext is a helper method to define mock.On call
-//   - ctx context.Context
-//   - txn *newrelic.Transaction
-func (_e *MockMonitoringServicer_Expecter) NewContext(ctx interface{}, txn interface{}) *MockMonitoringServicer_NewContext_Call {
-	return &MockMonitoringServicer_NewContext_Call{Call: _e.mock.On("NewContext", ctx, txn)}
+// GetBudgetSettings is a helper method to define mock.On call
+//   - budgetID string
+func (_e *mockynaber_Expecter) GetBudgetSettings(budgetID interface{}) *mockynaber_GetBudgetSettings_Call {
+	return &mockynaber_GetBudgetSettings_Call{Call: _e.mock.On("GetBudgetSettings", budgetID)}
 }
 
-func (_c *MockMonitoringServicer_NewContext_Call) Run(run func(ctx context.Context, txn *newrelic.Transaction)) *MockMonitoringServicer_NewContext_Call {
+func (_c *mockynaber_GetBudgetSettings_Call) Run(run func(budgetID string)) *mockynaber_GetBudgetSettings_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 context.Context
+		var arg0 string
 		if args[0] != nil {
-			arg0 = args[0].(context.Context)
-		}
-		var arg1 *newrelic.Transaction
-		if args[1] != nil {
-			arg1 = args[1].(*newrelic.Transaction)
+			arg0 = args[0].(string)
 		}
 		run(
 			arg0,
-			arg1,
 		)
 	})
 	return _c
 }
 
-func (_c *MockMonitoringServicer_NewContext_Call) Return(context1 context.Context) *MockMonitoringServicer_NewContext_Call {
-	_c.Call.Return(context1)
+func (_c *mockynaber_GetBudgetSettings_Call) Return(settings *budget.Settings, err error) *mockynaber_GetBudgetSettings_Call {
+	_c.Call.Return(settings, err)
 	return _c
 }
 
-func (_c *MockMonitoringServicer_NewContext_Call) RunAndReturn(run func(ctx context.Context, txn *newrelic.Transaction) context.Context) *MockMonitoringServicer_NewContext_Call {
+func (_c *mockynaber_GetBudgetSettings_Call) RunAndReturn(run func(budgetID string) (*budget.Settings, error)) *mockynaber_GetBudgetSettings_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RecordError provides a mock function for the type MockMonitoringServicer
-func (_mock *MockMonitoringServicer) RecordError(txn *newrelic.Transaction, err error) {
-	_mock.Called(txn, err)
-	return
+// GetBudgets provides a mock function for the type mockynaber
+func (_mock *mockynaber) GetBudgets() ([]*budget.Summary, error) {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBudgets")
+	}
+
+	var r0 []*budget.Summary
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func() ([]*budget.Summary, error)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() []*budget.Summary); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*budget.Summary)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func() error); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockMonitoringServicer_RecordError_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordError'
-type MockMonitoringServicer_RecordError_Call struct {
+// mockynaber_GetBudgets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBudgets'
+type mockynaber_GetBudgets_Call struct {
 	*mock.Call
 }
 
-// RecordError is a helper method to define mock.On call
-//   - txn *newrelic.Transaction
-//   - err error
-func (_e *MockMonitoringServicer_Expecter) RecordError(txn interface{}, err interface{}) *MockMonitoringServicer_RecordError_Call {
-	return &MockMonitoringServicer_RecordError_Call{Call: _e.mock.On("RecordError", txn, err)}
+// GetBudgets is a helper method to define mock.On call
+func (_e *mockynaber_Expecter) GetBudgets() *mockynaber_GetBudgets_Call {
+	return &mockynaber_GetBudgets_Call{Call: _e.mock.On("GetBudgets")}
 }
 
-func (_c *MockMonitoringServicer_RecordError_Call) Run(run func(txn *newrelic.Transaction, err error)) *MockMonitoringServicer_RecordError_Call {
+func (_c *mockynaber_GetBudgets_Call) Run(run func()) *mockynaber_GetBudgets_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 *newrelic.Transaction
-		if args[0] != nil {
-			arg0 = args[0].(*newrelic.Transaction)
-		}
-		var arg1 error
-		if args[1] != nil {
-			arg1 = args[1].(error)
-		}
-		run(
-			arg0,
-			arg1,
-		)
+		run()
 	})
 	return _c
 }
 
-func (_c *MockMonitoringServicer_RecordError_Call) Return() *MockMonitoringServicer_RecordError_Call {
-	_c.Call.Return()
+func (_c *mockynaber_GetBudgets_Call) Return(summarys []*budget.Summary, err error) *mockynaber_GetBudgets_Call {
+	_c.Call.Return(summarys, err)
 	return _c
 }
 
-func (_c *MockMonitoringServicer_RecordError_Call) RunAndReturn(run func(txn *newrelic.Transaction, err error)) *MockMonitoringServicer_RecordError_Call {
-	_c.Run(run)
+func (_c *mockynaber_GetBudgets_Call) RunAndReturn(run func() ([]*budget.Summary, error)) *mockynaber_GetBudgets_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// Shutdown provides a mock function for the type MockMonitoringServicer
-func (_mock *MockMonitoringServicer) Shutdown(timeout time.Duration) {
-	_mock.Called(timeout)
-	return
+// GetCategories provides a mock function for the type mockynaber
+func (_mock *mockynaber) GetCategories(budgetID string) ([]*category.GroupWithCategories, error) {
+	ret := _mock.Called(budgetID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCategories")
+	}
+
+	var r0 []*category.GroupWithCategories
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) ([]*category.GroupWithCategories, error)); ok {
+		return returnFunc(budgetID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) []*category.GroupWithCategories); ok {
+		r0 = returnFunc(budgetID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*category.GroupWithCategories)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(budgetID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockMonitoringServicer_Shutdown_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Shutdown'
-type MockMonitoringServicer_Shutdown_Call struct {
+// mockynaber_GetCategories_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCategories'
+type mockynaber_GetCategories_Call struct {
 	*mock.Call
 }
 
-// Shutdown is a helper method to define mock.On call
-//   - timeout time.Duration
-func (_e *MockMonitoringServicer_Expecter) Shutdown(timeout interface{}) *MockMonitoringServicer_Shutdown_Call {
-	return &MockMonitoringServicer_Shutdown_Call{Call: _e.mock.On("Shutdown", timeout)}
+// GetCategories is a helper method to define mock.On call
+//   - budgetID string
+func (_e *mockynaber_Expecter) GetCategories(budgetID interface{}) *mockynaber_GetCategories_Call {
+	return &mockynaber_GetCategories_Call{Call: _e.mock.On("GetCategories", budgetID)}
 }
 
-func (_c *MockMonitoringServicer_Shutdown_Call) Run(run func(timeout time.Duration)) *MockMonitoringServicer_Shutdown_Call {
+func (_c *mockynaber_GetCategories_Call) Run(run func(budgetID string)) *mockynaber_GetCategories_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 time.Duration
+		var arg0 string
 		if args[0] != nil {
-			arg0 = args[0].(time.Duration)
+			arg0 = args[0].(string)
 		}
 		run(
 			arg0,
@@ -928,106 +2617,130 @@ func (_c *MockMonitoringServicer_Shutdown_Call) Run(run func(timeout time.Durati
 	return _c
 }
 
-func (_c *MockMonitoringServicer_Shutdown_Call) Return() *MockMonitoringServicer_Shutdown_Call {
-	_c.Call.Return()
+func (_c *mockynaber_GetCategories_Call) Return(groupWithCategoriess []*category.GroupWithCategories, err error) *mockynaber_GetCategories_Call {
+	_c.Call.Return(groupWithCategoriess, err)
 	return _c
 }
 
-func (_c *MockMonitoringServicer_Shutdown_Call) RunAndReturn(run func(timeout time.Duration)) *MockMonitoringServicer_Shutdown_Call {
-	_c.Run(run)
+func (_c *mockynaber_GetCategories_Call) RunAndReturn(run func(budgetID string) ([]*category.GroupWithCategories, error)) *mockynaber_GetCategories_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// StartSegment provides a mock function for the type MockMonitoringServicer
-func (_mock *MockMonitoringServicer) StartSegment(txn *newrelic.Transaction, name string) *newrelic.Segment {
-	ret := _mock.Called(txn, name)
+// GetTransactionsByAccount provides a mock function for the type mockynaber
+func (_mock *mockynaber) GetTransactionsByAccount(budgetID string, accountID string, f *transaction.Filter) ([]*transaction.Transaction, error) {
+	ret := _mock.Called(budgetID, accountID, f)
 
 	if len(ret) == 0 {
-		panic("no return value specified for StartSegment")
+		panic("no return value specified for GetTransactionsByAccount")
 	}
 
-	var r0 *newrelic.Segment
-	if returnFunc, ok := ret.Get(0).(func(*newrelic.Transaction, string) *newrelic.Segment); ok {
-		r0 = returnFunc(txn, name)
+	var r0 []*transaction.Transaction
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, string, *transaction.Filter) ([]*transaction.Transaction, error)); ok {
+		return returnFunc(budgetID, accountID, f)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, string, *transaction.Filter) []*transaction.Transaction); ok {
+		r0 = returnFunc(budgetID, accountID, f)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*newrelic.Segment)
+			r0 = ret.Get(0).([]*transaction.Transaction)
 		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(string, string, *transaction.Filter) error); ok {
+		r1 = returnFunc(budgetID, accountID, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockMonitoringServicer_StartSegment_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartSegment'
-type MockMonitoringServicer_StartSegment_Call struct {
+// mockynaber_GetTransactionsByAccount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTransactionsByAccount'
+type mockynaber_GetTransactionsByAccount_Call struct {
 	*mock.Call
 }
 
-// StartSegment is a helper method to define mock.On call
-//   - txn *newrelic.Transaction
-//   - name string
-func (_e *MockMonitoringServicer_Expecter) StartSegment(txn interface{}, name interface{}) *MockMonitoringServicer_StartSegment_Call {
-	return &MockMonitoringServicer_StartSegment_Call{Call: _e.mock.On("StartSegment", txn, name)}
+// GetTransactionsByAccount is a helper method to define mock.On call
+//   - budgetID string
+//   - accountID string
+//   - f *transaction.Filter
+func (_e *mockynaber_Expecter) GetTransactionsByAccount(budgetID interface{}, accountID interface{}, f interface{}) *mockynaber_GetTransactionsByAccount_Call {
+	return &mockynaber_GetTransactionsByAccount_Call{Call: _e.mock.On("GetTransactionsByAccount", budgetID, accountID, f)}
 }
 
-func (_c *MockMonitoringServicer_StartSegment_Call) Run(run func(txn *newrelic.Transaction, name string)) *MockMonitoringServicer_StartSegment_Call {
+func (_c *mockynaber_GetTransactionsByAccount_Call) Run(run func(budgetID string, accountID string, f *transaction.Filter)) *mockynaber_GetTransactionsByAccount_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 *newrelic.Transaction
+		var arg0 string
 		if args[0] != nil {
-			arg0 = args[0].(*newrelic.Transaction)
+			arg0 = args[0].(string)
 		}
 		var arg1 string
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 *transaction.Filter
+		if args[2] != nil {
+			arg2 = args[2].(*transaction.Filter)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *MockMonitoringServicer_StartSegment_Call) Return(segment *newrelic.Segment) *MockMonitoringServicer_StartSegment_Call {
-	_c.Call.Return(segment)
+func (_c *mockynaber_GetTransactionsByAccount_Call) Return(transactions []*transaction.Transaction, err error) *mockynaber_GetTransactionsByAccount_Call {
+	_c.Call.Return(transactions, err)
 	return _c
 }
 
-func (_c *MockMonitoringServicer_StartSegment_Call) RunAndReturn(run func(txn *newrelic.Transaction, name string) *newrelic.Segment) *MockMonitoringServicer_StartSegment_Call {
+func (_c *mockynaber_GetTransactionsByAccount_Call) RunAndReturn(run func(budgetID string, accountID string, f *transaction.Filter) ([]*transaction.Transaction, error)) *mockynaber_GetTransactionsByAccount_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// StartTransaction provides a mock function for the type MockMonitoringServicer
-func (_mock *MockMonitoringServicer) StartTransaction(name string) *newrelic.Transaction {
-	ret := _mock.Called(name)
+// GetTransferPayeeIDs provides a mock function for the type mockynaber
+func (_mock *mockynaber) GetTransferPayeeIDs(budgetID string) (map[string]string, error) {
+	ret := _mock.Called(budgetID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for StartTransaction")
+		panic("no return value specified for GetTransferPayeeIDs")
 	}
 
-	var r0 *newrelic.Transaction
-	if returnFunc, ok := ret.Get(0).(func(string) *newrelic.Transaction); ok {
-		r0 = returnFunc(name)
+	var r0 map[string]string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (map[string]string, error)); ok {
+		return returnFunc(budgetID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) map[string]string); ok {
+		r0 = returnFunc(budgetID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*newrelic.Transaction)
+			r0 = ret.Get(0).(map[string]string)
 		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(budgetID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockMonitoringServicer_StartTransaction_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartTransaction'
-type MockMonitoringServicer_StartTransaction_Call struct {
+// mockynaber_GetTransferPayeeIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTransferPayeeIDs'
+type mockynaber_GetTransferPayeeIDs_Call struct {
 	*mock.Call
 }
 
-// StartTransaction is a helper method to define mock.On call
-//   - name string
-func (_e *MockMonitoringServicer_Expecter) StartTransaction(name interface{}) *MockMonitoringServicer_StartTransaction_Call {
-	return &MockMonitoringServicer_StartTransaction_Call{Call: _e.mock.On("StartTransaction", name)}
+// GetTransferPayeeIDs is a helper method to define mock.On call
+//   - budgetID string
+func (_e *mockynaber_Expecter) GetTransferPayeeIDs(budgetID interface{}) *mockynaber_GetTransferPayeeIDs_Call {
+	return &mockynaber_GetTransferPayeeIDs_Call{Call: _e.mock.On("GetTransferPayeeIDs", budgetID)}
 }
 
-func (_c *MockMonitoringServicer_StartTransaction_Call) Run(run func(name string)) *MockMonitoringServicer_StartTransaction_Call {
+func (_c *mockynaber_GetTransferPayeeIDs_Call) Run(run func(budgetID string)) *mockynaber_GetTransferPayeeIDs_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 string
 		if args[0] != nil {
@@ -1040,49 +2753,22 @@ func (_c *MockMonitoringServicer_StartTransaction_Call) Run(run func(name string
 	return _c
 }
 
-func (_c *MockMonitoringServicer_StartTransaction_Call) Return(transaction1 *newrelic.Transaction) *MockMonitoringServicer_StartTransaction_Call {
-	_c.Call.Return(transaction1)
+func (_c *mockynaber_GetTransferPayeeIDs_Call) Return(stringToString map[string]string, err error) *mockynaber_GetTransferPayeeIDs_Call {
+	_c.Call.Return(stringToString, err)
 	return _c
 }
 
-func (_c *MockMonitoringServicer_StartTransaction_Call) RunAndReturn(run func(name string) *newrelic.Transaction) *MockMonitoringServicer_StartTransaction_Call {
+func (_c *mockynaber_GetTransferPayeeIDs_Call) RunAndReturn(run func(budgetID string) (map[string]string, error)) *mockynaber_GetTransferPayeeIDs_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// newMockynaber creates a new instance of mockynaber. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
-// The first argument is typically a *testing.T value.
-func newMockynaber(t interface {
-	mock.TestingT
-	Cleanup(func())
-}) *mockynaber {
-	mock := &mockynaber{}
-	mock.Mock.Test(t)
-
-	t.Cleanup(func() { mock.AssertExpectations(t) })
-
-	return mock
-}
-
-// mockynaber is an autogenerated mock type for the ynaber type
-type mockynaber struct {
-	mock.Mock
-}
-
-type mockynaber_Expecter struct {
-	mock *mock.Mock
-}
-
-func (_m *mockynaber) EXPECT() *mockynaber_Expecter {
-	return &mockynaber_Expecter{mock: &_m.Mock}
-}
-
-// CreateTransactions provides a mock function for the type mockynaber
-func (_mock *mockynaber) CreateTransactions(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error) {
+// UpdateTransactions provides a mock function for the type mockynaber
+func (_mock *mockynaber) UpdateTransactions(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error) {
 	ret := _mock.Called(budgetID, p)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateTransactions")
+		panic("no return value specified for UpdateTransactions")
 	}
 
 	var r0 *transaction.OperationSummary
@@ -1105,19 +2791,19 @@ func (_mock *mockynaber) CreateTransactions(budgetID string, p []transaction.Pay
 	return r0, r1
 }
 
-// mockynaber_CreateTransactions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateTransactions'
-type mockynaber_CreateTransactions_Call struct {
+// mockynaber_UpdateTransactions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateTransactions'
+type mockynaber_UpdateTransactions_Call struct {
 	*mock.Call
 }
 
-// CreateTransactions is a helper method to define mock.On call
+// UpdateTransactions is a helper method to define mock.On call
 //   - budgetID string
 //   - p []transaction.PayloadTransaction
-func (_e *mockynaber_Expecter) CreateTransactions(budgetID interface{}, p interface{}) *mockynaber_CreateTransactions_Call {
-	return &mockynaber_CreateTransactions_Call{Call: _e.mock.On("CreateTransactions", budgetID, p)}
+func (_e *mockynaber_Expecter) UpdateTransactions(budgetID interface{}, p interface{}) *mockynaber_UpdateTransactions_Call {
+	return &mockynaber_UpdateTransactions_Call{Call: _e.mock.On("UpdateTransactions", budgetID, p)}
 }
 
-func (_c *mockynaber_CreateTransactions_Call) Run(run func(budgetID string, p []transaction.PayloadTransaction)) *mockynaber_CreateTransactions_Call {
+func (_c *mockynaber_UpdateTransactions_Call) Run(run func(budgetID string, p []transaction.PayloadTransaction)) *mockynaber_UpdateTransactions_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 string
 		if args[0] != nil {
@@ -1135,12 +2821,12 @@ func (_c *mockynaber_CreateTransactions_Call) Run(run func(budgetID string, p []
 	return _c
 }
 
-func (_c *mockynaber_CreateTransactions_Call) Return(operationSummary *transaction.OperationSummary, err error) *mockynaber_CreateTransactions_Call {
+func (_c *mockynaber_UpdateTransactions_Call) Return(operationSummary *transaction.OperationSummary, err error) *mockynaber_UpdateTransactions_Call {
 	_c.Call.Return(operationSummary, err)
 	return _c
 }
 
-func (_c *mockynaber_CreateTransactions_Call) RunAndReturn(run func(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error)) *mockynaber_CreateTransactions_Call {
+func (_c *mockynaber_UpdateTransactions_Call) RunAndReturn(run func(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error)) *mockynaber_UpdateTransactions_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api"
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/pkg/errors"
+)
+
+const (
+	// pendingMatchDays is how many days the booked date may differ from the pending one
+	pendingMatchDays = 7
+	// pendingAmountTolerance is how much, relatively, the booked amount may differ from the pending one
+	// when the payee is the same, e.g. because of a tip or a currency conversion
+	pendingAmountTolerance = 0.2
+)
+
+// reconcilePending resolves pending transactions sent to YNAB in earlier runs that aren't pending anymore.
+// When one of transactions is their booked counterpart, the YNAB transaction is updated in place with the booked
+// amount, date and cleared status; when they disappeared without being booked, the YNAB transaction is deleted.
+// transactions holds the fetched transactions that weren't uploaded yet, aligned with payloadTransactions,
+// and the returned indexes point to the ones that were used to update a pending transaction.
+func (s *SyncService) reconcilePending(ctx context.Context, j job, state *JobState, from time.Time, stillPending map[string]bool, transactions []Transaction, payloadTransactions []transaction.PayloadTransaction) (map[int]bool, error) {
+	l := slog.Default().With("gocardless_account_id", j.GCAccountID, "ynab_account_id", j.YNABAccountID, "ynab_budget_id", j.YNABBudgetID)
+	booked := make(map[int]bool)
+
+	importIDs := make([]string, 0, len(state.Pending))
+	for importID := range state.Pending {
+		if !stillPending[importID] {
+			importIDs = append(importIDs, importID)
+		}
+	}
+	sort.Strings(importIDs)
+
+	matches := make(map[string]int)
+	var vanished []string
+	since := time.Time{}
+	for _, importID := range importIDs {
+		p := state.Pending[importID]
+		if i := matchBooked(p, transactions, booked); i >= 0 {
+			booked[i] = true
+			matches[importID] = i
+		} else if !p.Date.Before(from) {
+			// Only pending transactions inside the fetched window can be known to be gone
+			vanished = append(vanished, importID)
+		} else {
+			continue
+		}
+
+		if since.IsZero() || p.Date.Before(since) {
+			since = p.Date
+		}
+	}
+
+	if len(matches) == 0 && len(vanished) == 0 {
+		return booked, nil
+	}
+
+	sinceDate := api.Date{Time: since.AddDate(0, 0, -pendingMatchDays)}
+	ynabTransactions, err := s.ynabService.GetTransactionsByAccount(j.YNABBudgetID, j.YNABAccountID, &transaction.Filter{Since: &sinceDate})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list YNAB transactions")
+	}
+
+	byID := make(map[string]*transaction.Transaction, len(ynabTransactions))
+	byImportID := make(map[string]*transaction.Transaction, len(ynabTransactions))
+	for _, t := range ynabTransactions {
+		if t.Deleted {
+			continue
+		}
+		byID[t.ID] = t
+		if t.ImportID != nil {
+			byImportID[*t.ImportID] = t
+		}
+	}
+	findExisting := func(importID string, p PendingTransaction) *transaction.Transaction {
+		if existing, ok := byID[p.YNABID]; ok && p.YNABID != "" {
+			return existing
+		}
+		return byImportID[importID]
+	}
+
+	var updates []transaction.PayloadTransaction
+	now := time.Now().UTC()
+	for _, importID := range importIDs {
+		i, ok := matches[importID]
+		if !ok {
+			continue
+		}
+
+		p := state.Pending[importID]
+		existing := findExisting(importID, p)
+		if existing == nil {
+			// The user removed it from YNAB, so the booked transaction is uploaded as a new one
+			l.InfoContext(ctx, "pending transaction not found in YNAB", "import_id", importID)
+			delete(booked, i)
+			delete(state.Pending, importID)
+			continue
+		}
+
+		if existing.Cleared != transaction.ClearingStatusReconciled {
+			update := toUpdatePayload(existing)
+			update.Date = payloadTransactions[i].Date
+			update.Amount = payloadTransactions[i].Amount
+			update.Cleared = payloadTransactions[i].Cleared
+			updates = append(updates, update)
+		}
+
+		l.InfoContext(ctx, "pending transaction booked", "import_id", importID, "pending_amount", p.AmountMili, "booked_amount", transactions[i].AmountMili, "pending_date", p.Date.Format("2006-01-02"), "booked_date", transactions[i].Date.Format("2006-01-02"))
+		state.MarkUploaded(uploadKey(transactions[i], *payloadTransactions[i].ImportID), UploadedTransaction{
+			ImportID:   importID,
+			YNABID:     existing.ID,
+			Date:       transactions[i].Date,
+			AmountMili: transactions[i].AmountMili,
+			UploadedAt: now,
+		})
+		delete(state.Pending, importID)
+	}
+
+	if len(updates) > 0 {
+		if _, err := s.ynabService.UpdateTransactions(j.YNABBudgetID, updates); err != nil {
+			return nil, errors.Wrap(err, "failed to update booked transactions")
+		}
+	}
+
+	for _, importID := range vanished {
+		p := state.Pending[importID]
+		existing := findExisting(importID, p)
+		if existing != nil && existing.Cleared != transaction.ClearingStatusReconciled {
+			if _, err := s.ynabService.DeleteTransaction(j.YNABBudgetID, existing.ID); err != nil {
+				return nil, errors.Wrapf(err, "failed to delete vanished pending transaction: %s", existing.ID)
+			}
+		}
+
+		l.InfoContext(ctx, "pending transaction vanished", "import_id", importID, "amount", p.AmountMili, "date", p.Date.Format("2006-01-02"))
+		delete(state.Pending, importID)
+	}
+
+	return booked, nil
+}
+
+// pendingImportIDs returns the import IDs of the fetched pending transactions
+func pendingImportIDs(payloadTransactions []transaction.PayloadTransaction, transactions []Transaction) map[string]bool {
+	importIDs := make(map[string]bool)
+	for i, t := range transactions {
+		if t.Pending {
+			importIDs[*payloadTransactions[i].ImportID] = true
+		}
+	}
+	return importIDs
+}
+
+// matchBooked returns the index of the booked transaction most likely to be the counterpart of p, or -1
+func matchBooked(p PendingTransaction, transactions []Transaction, taken map[int]bool) int {
+	best, bestRank := -1, 0
+	var bestDistance time.Duration
+	for i, t := range transactions {
+		if t.Pending || taken[i] {
+			continue
+		}
+
+		distance := t.Date.Sub(p.Date)
+		if distance < 0 {
+			distance = -distance
+		}
+		if distance > pendingMatchDays*24*time.Hour {
+			continue
+		}
+
+		rank := pendingMatchRank(p, t)
+		if rank == 0 {
+			continue
+		}
+		if rank > bestRank || (rank == bestRank && distance < bestDistance) {
+			best, bestRank, bestDistance = i, rank, distance
+		}
+	}
+
+	return best
+}
+
+// pendingMatchRank scores how likely t is the booked counterpart of p, 0 means it isn't
+func pendingMatchRank(p PendingTransaction, t Transaction) int {
+	sameName := strings.EqualFold(strings.TrimSpace(p.Name), strings.TrimSpace(t.Name))
+	switch {
+	case p.ID != "" && p.ID == t.ID:
+		return 4
+	case sameName && p.AmountMili == t.AmountMili:
+		return 3
+	case sameName && amountsClose(p.AmountMili, t.AmountMili):
+		return 2
+	case p.AmountMili == t.AmountMili:
+		return 1
+	}
+	return 0
+}
+
+// amountsClose reports whether both amounts have the same sign and differ by at most pendingAmountTolerance
+func amountsClose(pending, booked int64) bool {
+	if (pending < 0) != (booked < 0) {
+		return false
+	}
+	return math.Abs(float64(booked-pending)) <= math.Abs(float64(pending))*pendingAmountTolerance
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api"
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReconcilePending(t *testing.T) {
+	testJob := job{
+		GCAccountID:      "aaa",
+		YNABAccountID:    "bbb",
+		YNABBudgetID:     "ccc",
+		LookbackDays:     defaultLookbackDays,
+		ImportIDStrategy: importIDAmountDate,
+	}
+
+	nowTS := time.Now().UTC().Truncate(time.Hour)
+	pendingDate := nowTS.AddDate(0, 0, -3).Truncate(24 * time.Hour)
+	bookedDate := nowTS.AddDate(0, 0, -1).Truncate(24 * time.Hour)
+	pendingTransaction := Transaction{Date: pendingDate, AmountMili: -20000, Name: "Restaurant", Pending: true}
+	pendingImportID := toImportIDWithOccurrence(pendingTransaction, 1)
+	categoryID := "category"
+
+	newSyncService := func(t *testing.T, goCardlessMock *mockgoCardlesser, ynabMock *mockynaber) (SynchronizationServicer, StateStorer) {
+		monitorMock := NewMockMonitoringServicer(t)
+		mockTxn := &newrelic.Transaction{}
+		monitorMock.On("StartTransaction", "synchronizeAll").Return(mockTxn)
+		monitorMock.On("StartTransaction", "synchronization").Return(mockTxn)
+		monitorMock.On("AddAttribute", mockTxn, mock.Anything, mock.Anything).Return()
+		monitorMock.On("NewContext", mock.Anything, mockTxn).Return(context.Background())
+
+		stateStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+		state := JobState{LastSyncedAt: nowTS.AddDate(0, 0, -1)}
+		state.MarkPending(pendingImportID, PendingTransaction{YNABID: "ynab-pending", Date: pendingDate, AmountMili: -20000, Name: "Restaurant"})
+		assert.NoError(t, stateStore.SaveJobState(testJob.key(), state))
+
+		goCardlessMock.EXPECT().LogIn(mock.Anything).Return(nil)
+		return NewSyncService(goCardlessMock, ynabMock, monitorMock, stateStore, nil, []job{testJob}, 1), stateStore
+	}
+
+	existing := &transaction.Transaction{
+		ID:         "ynab-pending",
+		AccountID:  "bbb",
+		Date:       api.Date{Time: pendingDate},
+		Amount:     -20000,
+		Cleared:    transaction.ClearingStatusUncleared,
+		CategoryID: &categoryID,
+		ImportID:   &pendingImportID,
+	}
+
+	t.Run("pending imported as uncleared", func(t *testing.T) {
+		ynabTransactions := toYNABTransaction(testJob, JobState{}, []Transaction{pendingTransaction})
+		assert.Equal(t, transaction.ClearingStatusUncleared, ynabTransactions[0].Cleared)
+		assert.Equal(t, pendingImportID, *ynabTransactions[0].ImportID)
+		assert.Equal(t, "PENDING:-20000:"+pendingDate.Format("2006-01-02")+":1", pendingImportID)
+	})
+
+	t.Run("booked counterpart updates in place", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService, stateStore := newSyncService(t, goCardlessMock, ynabMock)
+
+		booked := Transaction{ID: "booked1", Date: bookedDate, AmountMili: -23000, Name: "Restaurant"}
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", mock.Anything, nowTS).Return([]Transaction{booked}, nil)
+		ynabMock.EXPECT().GetTransactionsByAccount("ccc", "bbb", mock.Anything).Return([]*transaction.Transaction{existing}, nil)
+		ynabMock.EXPECT().UpdateTransactions("ccc", mock.MatchedBy(func(p []transaction.PayloadTransaction) bool {
+			return len(p) == 1 &&
+				p[0].ID == "ynab-pending" &&
+				p[0].Amount == -23000 &&
+				p[0].Date.Equal(bookedDate) &&
+				p[0].Cleared == transaction.ClearingStatusCleared &&
+				*p[0].CategoryID == categoryID
+		})).Return(&transaction.OperationSummary{}, nil)
+
+		_, err := syncService.SynchronizeTransactions(context.Background(), []job{testJob})
+		assert.NoError(t, err)
+
+		state, err := stateStore.JobState(testJob.key())
+		assert.NoError(t, err)
+		assert.False(t, state.IsPending(pendingImportID))
+		assert.True(t, state.IsUploaded("booked1"))
+		assert.Equal(t, "ynab-pending", state.Uploaded["booked1"].YNABID)
+	})
+
+	t.Run("vanished pending is deleted", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService, stateStore := newSyncService(t, goCardlessMock, ynabMock)
+
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", mock.Anything, nowTS).Return(nil, nil)
+		ynabMock.EXPECT().GetTransactionsByAccount("ccc", "bbb", mock.Anything).Return([]*transaction.Transaction{existing}, nil)
+		ynabMock.EXPECT().DeleteTransaction("ccc", "ynab-pending").Return(existing, nil)
+
+		_, err := syncService.SynchronizeTransactions(context.Background(), []job{testJob})
+		assert.NoError(t, err)
+
+		state, err := stateStore.JobState(testJob.key())
+		assert.NoError(t, err)
+		assert.False(t, state.IsPending(pendingImportID))
+	})
+
+	t.Run("still pending is left alone", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService, stateStore := newSyncService(t, goCardlessMock, ynabMock)
+
+		goCardlessMock.EXPECT().ListTransactions(mock.Anything, "aaa", mock.Anything, nowTS).Return([]Transaction{pendingTransaction}, nil)
+
+		_, err := syncService.SynchronizeTransactions(context.Background(), []job{testJob})
+		assert.NoError(t, err)
+
+		state, err := stateStore.JobState(testJob.key())
+		assert.NoError(t, err)
+		assert.True(t, state.IsPending(pendingImportID))
+	})
+}
+
+func TestMatchBooked(t *testing.T) {
+	date := time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)
+	pending := PendingTransaction{ID: "", Date: date, AmountMili: -10000, Name: "Shop"}
+
+	transactions := []Transaction{
+		{ID: "pending", Date: date, AmountMili: -10000, Name: "Shop", Pending: true},
+		{ID: "far", Date: date.AddDate(0, 0, 10), AmountMili: -10000, Name: "Shop"},
+		{ID: "other-amount", Date: date.AddDate(0, 0, 1), AmountMili: -10000, Name: "Other"},
+		{ID: "close-amount", Date: date.AddDate(0, 0, 2), AmountMili: -11000, Name: "shop"},
+		{ID: "income", Date: date, AmountMili: 10000, Name: "Shop"},
+	}
+
+	assert.Equal(t, 3, matchBooked(pending, transactions, map[int]bool{}))
+	assert.Equal(t, 2, matchBooked(pending, transactions, map[int]bool{3: true}))
+	assert.Equal(t, -1, matchBooked(pending, transactions, map[int]bool{2: true, 3: true}))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JobResult is the outcome of synchronizing a single job
+type JobResult struct {
+	// Job is the name of the job
+	Job      string
+	Fetched  int
+	Uploaded int
+	// Skipped counts the fetched transactions left out because they couldn't be converted to the budget's currency
+	Skipped  int
+	Duration time.Duration
+	// Err is why the job failed, nil when it succeeded
+	Err error
+}
+
+// SyncReport collects the results of synchronizing several jobs
+type SyncReport struct {
+	Results []JobResult
+}
+
+// Failed returns the results of the jobs that failed
+func (r SyncReport) Failed() []JobResult {
+	var failed []JobResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Skipped returns the number of transactions all jobs skipped for their currency
+func (r SyncReport) Skipped() int {
+	skipped := 0
+	for _, result := range r.Results {
+		skipped += result.Skipped
+	}
+	return skipped
+}
+
+// Err returns a *SyncError when any job failed, nil otherwise
+func (r SyncReport) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return &SyncError{Failed: failed, Total: len(r.Results)}
+}
+
+// SyncError is returned when jobs of a synchronization failed, the other jobs were still synchronized
+type SyncError struct {
+	Failed []JobResult
+	Total  int
+}
+
+func (e *SyncError) Error() string {
+	messages := make([]string, 0, len(e.Failed))
+	for _, result := range e.Failed {
+		messages = append(messages, fmt.Sprintf("%s: %s", result.Job, result.Err))
+	}
+	return fmt.Sprintf("%d of %d jobs failed: %s", len(e.Failed), e.Total, strings.Join(messages, "; "))
+}
+
+// Unwrap returns the errors of the failed jobs, so errors.Is and errors.As look into them
+func (e *SyncError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, result := range e.Failed {
+		errs = append(errs, result.Err)
+	}
+	return errs
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// rules rewrite the transactions of every job before they're uploaded, they're loaded from RULES_FILE
+type rules struct {
+	// Payees rename the payee of the transactions they match, the first matching rule wins
+	Payees []payeeRule `json:"payees"`
+	// Categories assign a YNAB category to the transactions they match, the first matching rule wins
+	Categories []categoryRule `json:"categories"`
+}
+
+// payeeRule renames the payee of the transactions it matches. With a regex matcher, ${1} or ${name} in Payee
+// are replaced by the groups the regex captured.
+type payeeRule struct {
+	// Name describes the rule in the output of the rules test command
+	Name  string    `json:"name"`
+	Match ruleMatch `json:"match"`
+	Payee string    `json:"payee"`
+}
+
+// categoryRule assigns a category of the job's budget to the transactions it matches
+type categoryRule struct {
+	// Name describes the rule in the output of the rules test command
+	Name  string    `json:"name"`
+	Match ruleMatch `json:"match"`
+	// Category is the name of the YNAB category, or "Group: Category" when categories of several groups share it
+	Category string `json:"category"`
+	// Approve approves the transactions the rule matches, so they don't wait for a review in YNAB
+	Approve bool `json:"approve"`
+}
+
+// ruleMatch matches transactions, every matcher that is set has to match
+type ruleMatch struct {
+	// Contains matches the payee or memo containing it, ignoring case
+	Contains string `json:"contains"`
+	// Regex matches the payee or memo it matches
+	Regex string `json:"regex"`
+	// IBAN matches the account of the other party, the creditor of money going out or the debtor of money coming in
+	IBAN string `json:"iban"`
+	// MCC matches the merchant category code of card transactions
+	MCC string `json:"mcc"`
+	// Payee matches the whole payee, ignoring case. Category rules see the payee payee rules renamed it to.
+	Payee string `json:"payee"`
+	// Memo matches the memo containing it, ignoring case
+	Memo string `json:"memo"`
+	// MinAmount and MaxAmount bound the amount without its sign, in the currency of the budget, e.g. "12.50"
+	MinAmount string `json:"min_amount"`
+	MaxAmount string `json:"max_amount"`
+	// Direction matches money coming "in" to or going "out" of the account
+	Direction string `json:"direction"`
+
+	regex     *regexp.Regexp
+	minAmount *int64
+	maxAmount *int64
+}
+
+const (
+	// directionIn matches transactions with a positive amount
+	directionIn = "in"
+	// directionOut matches transactions with a negative amount
+	directionOut = "out"
+)
+
+// parseRules parses and validates rules in JSON
+func parseRules(data []byte) (rules, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var r rules
+	if err := decoder.Decode(&r); err != nil {
+		return rules{}, fmt.Errorf("failed to parse rules: %w", err)
+	}
+
+	for i := range r.Payees {
+		rule := &r.Payees[i]
+		if err := rule.Match.compile(); err != nil {
+			return rules{}, fmt.Errorf("payee rule %d: %w", i+1, err)
+		}
+		if strings.TrimSpace(rule.Payee) == "" {
+			return rules{}, fmt.Errorf("payee rule %d: payee is required", i+1)
+		}
+	}
+
+	for i := range r.Categories {
+		rule := &r.Categories[i]
+		if err := rule.Match.compile(); err != nil {
+			return rules{}, fmt.Errorf("category rule %d: %w", i+1, err)
+		}
+		if strings.TrimSpace(rule.Category) == "" {
+			return rules{}, fmt.Errorf("category rule %d: category is required", i+1)
+		}
+	}
+
+	return r, nil
+}
+
+// compile validates the matchers, compiles the regex and parses the amounts
+func (m *ruleMatch) compile() error {
+	if m.Contains == "" && m.Regex == "" && m.IBAN == "" && m.MCC == "" && m.Payee == "" && m.Memo == "" &&
+		m.MinAmount == "" && m.MaxAmount == "" && m.Direction == "" {
+		return fmt.Errorf("match needs at least one of contains, regex, iban, mcc, payee, memo, min_amount, max_amount or direction")
+	}
+
+	if m.Direction != "" && m.Direction != directionIn && m.Direction != directionOut {
+		return fmt.Errorf("invalid direction %q, must be %s or %s", m.Direction, directionIn, directionOut)
+	}
+
+	for _, bound := range []struct {
+		name  string
+		value string
+		to    **int64
+	}{{"min_amount", m.MinAmount, &m.minAmount}, {"max_amount", m.MaxAmount, &m.maxAmount}} {
+		if bound.value == "" {
+			continue
+		}
+		amount, err := parseDecimalMilliunits(bound.value, milliunitDigits, "milliunits")
+		if err != nil || amount < 0 {
+			return fmt.Errorf("invalid %s %q, must be an amount like 12.50 without a sign", bound.name, bound.value)
+		}
+		*bound.to = &amount
+	}
+	if m.minAmount != nil && m.maxAmount != nil && *m.minAmount > *m.maxAmount {
+		return fmt.Errorf("min_amount %s is larger than max_amount %s", m.MinAmount, m.MaxAmount)
+	}
+
+	if m.Regex != "" {
+		regex, err := regexp.Compile(m.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex %q: %w", m.Regex, err)
+		}
+		m.regex = regex
+	}
+	return nil
+}
+
+// matches reports whether t matches every matcher that is set, along with the text the regex matched and the
+// indexes of its submatches
+func (m ruleMatch) matches(t Transaction) (bool, string, []int) {
+	if m.Contains != "" {
+		contains := strings.ToLower(m.Contains)
+		if !strings.Contains(strings.ToLower(t.Name), contains) && !strings.Contains(strings.ToLower(t.Memo), contains) {
+			return false, "", nil
+		}
+	}
+
+	if m.IBAN != "" && normalizeIBAN(m.IBAN) != normalizeIBAN(counterpartyIBAN(t)) {
+		return false, "", nil
+	}
+
+	if m.MCC != "" && m.MCC != t.MerchantCategoryCode {
+		return false, "", nil
+	}
+
+	if m.Payee != "" && !strings.EqualFold(m.Payee, t.Name) {
+		return false, "", nil
+	}
+
+	if m.Memo != "" && !strings.Contains(strings.ToLower(t.Memo), strings.ToLower(m.Memo)) {
+		return false, "", nil
+	}
+
+	amount := t.AmountMili
+	if amount < 0 {
+		amount = -amount
+	}
+	if (m.minAmount != nil && amount < *m.minAmount) || (m.maxAmount != nil && amount > *m.maxAmount) {
+		return false, "", nil
+	}
+
+	if (m.Direction == directionIn && t.AmountMili <= 0) || (m.Direction == directionOut && t.AmountMili >= 0) {
+		return false, "", nil
+	}
+
+	if m.regex == nil {
+		return true, "", nil
+	}
+	for _, text := range []string{t.Name, t.Memo} {
+		if submatches := m.regex.FindStringSubmatchIndex(text); submatches != nil {
+			return true, text, submatches
+		}
+	}
+	return false, "", nil
+}
+
+// matchPayee returns the index of the first payee rule matching t, -1 when none does, and the payee it renames t to
+func (r rules) matchPayee(t Transaction) (int, string) {
+	for i, rule := range r.Payees {
+		ok, text, submatches := rule.Match.matches(t)
+		if !ok {
+			continue
+		}
+
+		if rule.Match.regex == nil {
+			return i, rule.Payee
+		}
+		return i, strings.TrimSpace(string(rule.Match.regex.ExpandString(nil, rule.Payee, text, submatches)))
+	}
+	return -1, ""
+}
+
+// matchCategory returns the index of the first category rule matching t, -1 when none does
+func (r rules) matchCategory(t Transaction) int {
+	for i, rule := range r.Categories {
+		if ok, _, _ := rule.Match.matches(t); ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// apply rewrites t according to the rules, payee rules run first so category rules can match the new payee
+func (r rules) apply(t Transaction) Transaction {
+	if _, payee := r.matchPayee(t); payee != "" {
+		t.Name = payee
+	}
+	if i := r.matchCategory(t); i >= 0 {
+		t.Category = r.Categories[i].Category
+		t.Approved = r.Categories[i].Approve
+	}
+	return t
+}
+
+// payeeRuleLabel names the payee rule at index i, by its name or its position in the file
+func (r rules) payeeRuleLabel(i int) string {
+	return ruleLabel(r.Payees[i].Name, "payee", i)
+}
+
+// categoryRuleLabel names the category rule at index i, by its name or its position in the file
+func (r rules) categoryRuleLabel(i int) string {
+	return ruleLabel(r.Categories[i].Name, "category", i)
+}
+
+// ruleLabel returns name, or the kind and position of the rule when it has none
+func ruleLabel(name, kind string, i int) string {
+	if name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s rule %d", kind, i+1)
+}
+
+// counterpartyIBAN returns the IBAN of the other party of a transaction
+func counterpartyIBAN(t Transaction) string {
+	if t.AmountMili < 0 {
+		return t.CreditorIBAN
+	}
+	return t.DebtorIBAN
+}
+
+// normalizeIBAN removes the spaces IBANs are often printed with and uppercases it
+func normalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+}
+
+// rulesFile holds the rules of a file, reloading them when the file changes. A nil rulesFile has no rules.
+type rulesFile struct {
+	path string
+
+	mu      sync.Mutex
+	rules   rules
+	modTime time.Time
+	size    int64
+}
+
+// newRulesFile loads the rules of the file at path, no rules are loaded when path is empty
+func newRulesFile(path string) (*rulesFile, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f := &rulesFile{path: path}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// current returns the rules of the file, reloading them first when the file changed since they were loaded.
+// Rules that fail to reload are logged and the previous ones are kept, so a typo doesn't stop synchronization.
+func (f *rulesFile) current(ctx context.Context) rules {
+	if f == nil {
+		return rules{}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		slog.Default().ErrorContext(ctx, "failed to check rules file, keeping the loaded rules", "path", f.path, "error", err)
+		return f.rules
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.rules
+	}
+
+	err = f.reload()
+	// A file that fails to load is only read again once it changes
+	f.modTime, f.size = info.ModTime(), info.Size()
+	if err != nil {
+		slog.Default().ErrorContext(ctx, "failed to reload rules file, keeping the loaded rules", "path", f.path, "error", err)
+		return f.rules
+	}
+	slog.Default().InfoContext(ctx, "reloaded rules file", "path", f.path, "payee_rules", len(f.rules.Payees), "category_rules", len(f.rules.Categories))
+	return f.rules
+}
+
+// reload reads and parses the file, it must be called with mu held or before f is shared
+func (f *rulesFile) reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read rules file")
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read rules file")
+	}
+
+	r, err := parseRules(data)
+	if err != nil {
+		return errors.Wrapf(err, "invalid rules file %s", f.path)
+	}
+
+	f.rules = r
+	f.modTime, f.size = info.ModTime(), info.Size()
+	return nil
+}
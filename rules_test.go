@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		r, err := parseRules([]byte(`{"payees": [{"name": "Lidl", "match": {"contains": "lidl"}, "payee": "Lidl"}]}`))
+		assert.NoError(t, err)
+		assert.Len(t, r.Payees, 1)
+	})
+
+	invalid := map[string]string{
+		`{"payees": [{"match": {}, "payee": "Lidl"}]}`:                                                  "payee rule 1: match needs at least one of contains, regex, iban, mcc, payee, memo, min_amount, max_amount or direction",
+		`{"payees": [{"match": {"contains": "lidl"}}]}`:                                                 "payee rule 1: payee is required",
+		`{"payees": [{"match": {"regex": "(lidl"}, "payee": "Lidl"}]}`:                                  "payee rule 1: invalid regex \"(lidl\": error parsing regexp: missing closing ): `(lidl`",
+		`{"payees": [{"match": {"contain": "lidl"}, "payee": "Lidl"}]}`:                                 "failed to parse rules: json: unknown field \"contain\"",
+		`{"payees": [{"match": {"contains": "lidl"}, "payee": "Lidl"}],}`:                               "failed to parse rules: invalid character '}' looking for beginning of object key string",
+		`{"categories": [{"match": {"payee": "Lidl"}}]}`:                                                "category rule 1: category is required",
+		`{"categories": [{"match": {"direction": "up"}, "category": "Groceries"}]}`:                     "category rule 1: invalid direction \"up\", must be in or out",
+		`{"categories": [{"match": {"min_amount": "-5"}, "category": "Groceries"}]}`:                    "category rule 1: invalid min_amount \"-5\", must be an amount like 12.50 without a sign",
+		`{"categories": [{"match": {"max_amount": "1.2345"}, "category": "Groceries"}]}`:                "category rule 1: invalid max_amount \"1.2345\", must be an amount like 12.50 without a sign",
+		`{"categories": [{"match": {"min_amount": "10", "max_amount": "5"}, "category": "Groceries"}]}`: "category rule 1: min_amount 10 is larger than max_amount 5",
+	}
+	for source, wantErr := range invalid {
+		_, err := parseRules([]byte(source))
+		assert.EqualError(t, err, wantErr, source)
+	}
+}
+
+func TestRulesMatchPayee(t *testing.T) {
+	r, err := parseRules([]byte(`{"payees": [
+		{"name": "card", "match": {"regex": "^CARD \\d+ (?P<merchant>.+?) SP Z O O"}, "payee": "${merchant}"},
+		{"name": "landlord", "match": {"iban": "PL61 1090 1014 0000 0712 1981 2874"}, "payee": "Landlord"},
+		{"name": "groceries", "match": {"mcc": "5411", "contains": "biedronka"}, "payee": "Biedronka"},
+		{"name": "fallback", "match": {"contains": "biedronka"}, "payee": "Biedronka (not groceries)"}
+	]}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		transaction Transaction
+		wantRule    int
+		wantPayee   string
+	}{
+		{
+			name:        "regex with a named group",
+			transaction: Transaction{Name: "CARD 1234 LIDL SP Z O O WARSZAWA 12/03", AmountMili: -1000},
+			wantRule:    0,
+			wantPayee:   "LIDL",
+		},
+		{
+			name:        "regex on the memo",
+			transaction: Transaction{Name: "Unknown", Memo: "CARD 1234 ZABKA SP Z O O KRAKOW", AmountMili: -1000},
+			wantRule:    0,
+			wantPayee:   "ZABKA",
+		},
+		{
+			name:        "IBAN of the creditor",
+			transaction: Transaction{Name: "Transfer", AmountMili: -2000000, CreditorIBAN: "PL61109010140000071219812874"},
+			wantRule:    1,
+			wantPayee:   "Landlord",
+		},
+		{
+			name:        "IBAN of the debtor only matches money coming in",
+			transaction: Transaction{Name: "Transfer", AmountMili: -2000000, DebtorIBAN: "PL61109010140000071219812874"},
+			wantRule:    -1,
+		},
+		{
+			name:        "all matchers of a rule",
+			transaction: Transaction{Name: "BIEDRONKA 123", AmountMili: -1000, MerchantCategoryCode: "5411"},
+			wantRule:    2,
+			wantPayee:   "Biedronka",
+		},
+		{
+			name:        "first matching rule",
+			transaction: Transaction{Name: "Biedronka 123", AmountMili: -1000, MerchantCategoryCode: "5999"},
+			wantRule:    3,
+			wantPayee:   "Biedronka (not groceries)",
+		},
+		{
+			name:        "no rule",
+			transaction: Transaction{Name: "Shop", AmountMili: -1000},
+			wantRule:    -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, payee := r.matchPayee(tt.transaction)
+			assert.Equal(t, tt.wantRule, rule)
+			assert.Equal(t, tt.wantPayee, payee)
+
+			want := tt.transaction.Name
+			if tt.wantPayee != "" {
+				want = tt.wantPayee
+			}
+			assert.Equal(t, want, r.apply(tt.transaction).Name)
+		})
+	}
+}
+
+func TestRulesMatchCategory(t *testing.T) {
+	r, err := parseRules([]byte(`{
+		"payees": [{"match": {"contains": "netflix.com"}, "payee": "Netflix"}],
+		"categories": [
+			{"name": "streaming", "match": {"payee": "netflix"}, "category": "Subscriptions", "approve": true},
+			{"name": "rent", "match": {"memo": "rent", "direction": "out", "min_amount": "1500"}, "category": "Bills: Rent"},
+			{"name": "salary", "match": {"direction": "in", "min_amount": "1000.00"}, "category": "Inflow: Ready to Assign", "approve": true},
+			{"name": "coffee", "match": {"mcc": "5814", "max_amount": "20"}, "category": "Coffee"}
+		]
+	}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		transaction  Transaction
+		wantRule     int
+		wantCategory string
+		wantApproved bool
+	}{
+		{
+			name:         "payee renamed by a payee rule",
+			transaction:  Transaction{Name: "NETFLIX.COM 866-579-7172", AmountMili: -43000},
+			wantRule:     0,
+			wantCategory: "Subscriptions",
+			wantApproved: true,
+		},
+		{
+			name:         "memo, direction and minimum amount",
+			transaction:  Transaction{Name: "Landlord", Memo: "Rent for May", AmountMili: -2000000},
+			wantRule:     1,
+			wantCategory: "Bills: Rent",
+		},
+		{
+			name:        "below the minimum amount",
+			transaction: Transaction{Name: "Landlord", Memo: "Rent for parking", AmountMili: -200000},
+			wantRule:    -1,
+		},
+		{
+			name:        "other direction",
+			transaction: Transaction{Name: "Landlord", Memo: "Rent refund", AmountMili: 200000},
+			wantRule:    -1,
+		},
+		{
+			name:         "money coming in",
+			transaction:  Transaction{Name: "Employer", Memo: "Salary", AmountMili: 5000000},
+			wantRule:     2,
+			wantCategory: "Inflow: Ready to Assign",
+			wantApproved: true,
+		},
+		{
+			name:         "maximum amount is inclusive",
+			transaction:  Transaction{Name: "Cafe", AmountMili: -20000, MerchantCategoryCode: "5814"},
+			wantRule:     3,
+			wantCategory: "Coffee",
+		},
+		{
+			name:        "above the maximum amount",
+			transaction: Transaction{Name: "Cafe", AmountMili: -20010, MerchantCategoryCode: "5814"},
+			wantRule:    -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied := r.apply(tt.transaction)
+			assert.Equal(t, tt.wantRule, r.matchCategory(applied))
+			assert.Equal(t, tt.wantCategory, applied.Category)
+			assert.Equal(t, tt.wantApproved, applied.Approved)
+		})
+	}
+}
+
+func TestRulesFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "rules.json")
+	write := func(source string, modTime time.Time) {
+		require.NoError(t, os.WriteFile(path, []byte(source), 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	shop := Transaction{Name: "SHOP 123"}
+	start := time.Now().Add(-time.Hour)
+
+	t.Run("no file", func(t *testing.T) {
+		f, err := newRulesFile("")
+		assert.NoError(t, err)
+		assert.Nil(t, f)
+		assert.Equal(t, "SHOP 123", f.current(ctx).apply(shop).Name)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		write(`{"payees": [{"match": {}, "payee": "Shop"}]}`, start)
+		_, err := newRulesFile(path)
+		assert.ErrorContains(t, err, "invalid rules file")
+	})
+
+	t.Run("reloads changes", func(t *testing.T) {
+		write(`{"payees": [{"match": {"contains": "shop"}, "payee": "Shop"}]}`, start)
+		f, err := newRulesFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "Shop", f.current(ctx).apply(shop).Name)
+
+		write(`{"payees": [{"match": {"contains": "shop"}, "payee": "Corner Shop"}]}`, start.Add(time.Minute))
+		assert.Equal(t, "Corner Shop", f.current(ctx).apply(shop).Name)
+
+		// A broken file keeps the rules loaded before
+		write(`{"payees": [`, start.Add(2*time.Minute))
+		assert.Equal(t, "Corner Shop", f.current(ctx).apply(shop).Name)
+
+		write(`{"payees": []}`, start.Add(3*time.Minute))
+		assert.Equal(t, "SHOP 123", f.current(ctx).apply(shop).Name)
+	})
+}
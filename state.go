@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+// stateRetention is how long uploaded transactions are remembered after their date.
+// GoCardless never returns more than 90 days of history, so anything older can't be re-fetched.
+const stateRetention = 120 * 24 * time.Hour
+
+// JobState holds everything remembered about a single job between runs
+type JobState struct {
+	// LastSyncedAt is the end of the window of the last successful synchronization
+	LastSyncedAt time.Time `json:"last_synced_at"`
+	// BackfilledTo is the first day an unfinished backfill still has to fetch
+	BackfilledTo time.Time `json:"backfilled_to,omitempty"`
+	// Uploaded maps upload keys, usually GoCardless transaction IDs, to what was sent to YNAB for them
+	Uploaded map[string]UploadedTransaction `json:"uploaded,omitempty"`
+	// Pending maps import IDs of pending transactions sent to YNAB as uncleared, until they are booked
+	Pending map[string]PendingTransaction `json:"pending,omitempty"`
+}
+
+// UploadedTransaction records a GoCardless transaction that was already sent to YNAB
+type UploadedTransaction struct {
+	ImportID   string    `json:"import_id"`
+	YNABID     string    `json:"ynab_id,omitempty"`
+	Date       time.Time `json:"date"`
+	AmountMili int64     `json:"amount_mili"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// PendingTransaction records a pending GoCardless transaction that was sent to YNAB as uncleared
+type PendingTransaction struct {
+	ID         string    `json:"id,omitempty"`
+	YNABID     string    `json:"ynab_id,omitempty"`
+	Date       time.Time `json:"date"`
+	AmountMili int64     `json:"amount_mili"`
+	Name       string    `json:"name"`
+}
+
+// uploadKey identifies a transaction in the job state, transactions without a bank ID are identified by their import ID
+func uploadKey(t Transaction, importID string) string {
+	if t.ID != "" {
+		return t.ID
+	}
+	return "import:" + importID
+}
+
+// IsUploaded reports whether the transaction with the given upload key was already sent to YNAB
+func (s JobState) IsUploaded(key string) bool {
+	if key == "" {
+		return false
+	}
+	_, ok := s.Uploaded[key]
+	return ok
+}
+
+// IsPending reports whether a pending transaction with the given import ID was sent to YNAB and isn't booked yet
+func (s JobState) IsPending(importID string) bool {
+	_, ok := s.Pending[importID]
+	return ok
+}
+
+// MarkPending records that a pending transaction was sent to YNAB with the given import ID
+func (s *JobState) MarkPending(importID string, pending PendingTransaction) {
+	if s.Pending == nil {
+		s.Pending = make(map[string]PendingTransaction)
+	}
+	s.Pending[importID] = pending
+}
+
+// MarkUploaded records that the transaction with the given upload key was sent to YNAB
+func (s *JobState) MarkUploaded(key string, uploaded UploadedTransaction) {
+	if key == "" {
+		return
+	}
+	if s.Uploaded == nil {
+		s.Uploaded = make(map[string]UploadedTransaction)
+	}
+	s.Uploaded[key] = uploaded
+}
+
+// prune forgets uploaded transactions that are too old to ever be returned by GoCardless again
+func (s *JobState) prune(now time.Time) {
+	for id, uploaded := range s.Uploaded {
+		if now.Sub(uploaded.Date) > stateRetention {
+			delete(s.Uploaded, id)
+		}
+	}
+	for importID, pending := range s.Pending {
+		if now.Sub(pending.Date) > stateRetention {
+			delete(s.Pending, importID)
+		}
+	}
+}
+
+type stateFile struct {
+	Jobs map[string]JobState `json:"jobs"`
+	// RateLimits maps GoCardless accounts, or their data endpoints other than transactions, to their last known daily quota
+	RateLimits map[string]gocardless.RateLimit `json:"rate_limits,omitempty"`
+}
+
+// FileStateStore implements the StateStorer interface using a JSON file on disk
+type FileStateStore struct {
+	path  string
+	mu    sync.Mutex
+	state stateFile
+}
+
+// NewFileStateStore creates a StateStorer backed by the JSON file at path, loading it if it exists
+func NewFileStateStore(path string) (*FileStateStore, error) {
+	store := &FileStateStore{
+		path:  path,
+		state: stateFile{Jobs: make(map[string]JobState)},
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read state file: %s", path)
+	}
+
+	if err := json.Unmarshal(content, &store.state); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse state file: %s", path)
+	}
+	if store.state.Jobs == nil {
+		store.state.Jobs = make(map[string]JobState)
+	}
+
+	return store, nil
+}
+
+// JobState returns the stored state for the job with the given key, or an empty state if there is none
+func (f *FileStateStore) JobState(key string) (JobState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	state := f.state.Jobs[key]
+	uploaded := make(map[string]UploadedTransaction, len(state.Uploaded))
+	for id, u := range state.Uploaded {
+		uploaded[id] = u
+	}
+	state.Uploaded = uploaded
+	pending := make(map[string]PendingTransaction, len(state.Pending))
+	for importID, p := range state.Pending {
+		pending[importID] = p
+	}
+	state.Pending = pending
+
+	return state, nil
+}
+
+// SaveJobState stores the state for the job with the given key and writes it to disk
+func (f *FileStateStore) SaveJobState(key string, state JobState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	state.prune(time.Now())
+	f.state.Jobs[key] = state
+
+	return f.write()
+}
+
+// RateLimit returns the last known GoCardless quota with the given key, or an empty one if there is none
+func (f *FileStateStore) RateLimit(key string) (gocardless.RateLimit, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.state.RateLimits[key], nil
+}
+
+// SaveRateLimit stores the GoCardless quota with the given key and writes it to disk
+func (f *FileStateStore) SaveRateLimit(key string, limit gocardless.RateLimit) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.state.RateLimits == nil {
+		f.state.RateLimits = make(map[string]gocardless.RateLimit)
+	}
+	f.state.RateLimits[key] = limit
+
+	return f.write()
+}
+
+// write atomically replaces the state file with the current state
+func (f *FileStateStore) write() error {
+	content, err := json.MarshalIndent(f.state, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal state")
+	}
+
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return errors.Wrapf(err, "failed to create state directory: %s", dir)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary state file")
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "failed to write temporary state file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to close temporary state file")
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return errors.Wrapf(err, "failed to replace state file: %s", f.path)
+	}
+
+	return nil
+}
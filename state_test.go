@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// Setup
+	store, err := NewFileStateStore(path)
+	assert.NoError(t, err)
+
+	state, err := store.JobState("aaa:bbb")
+	assert.NoError(t, err)
+	assert.True(t, state.LastSyncedAt.IsZero())
+	assert.False(t, state.IsUploaded("tx1"))
+
+	state.LastSyncedAt = now
+	state.MarkUploaded("tx1", UploadedTransaction{ImportID: "YNAB:100:2023-01-01:1", YNABID: "ynab1", Date: now})
+	state.MarkUploaded("tx-old", UploadedTransaction{ImportID: "YNAB:100:2020-01-01:1", Date: now.Add(-2 * stateRetention)})
+
+	// Test
+	assert.NoError(t, store.SaveJobState("aaa:bbb", state))
+	reloaded, err := NewFileStateStore(path)
+	assert.NoError(t, err)
+	state, err = reloaded.JobState("aaa:bbb")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, now, state.LastSyncedAt)
+	assert.True(t, state.IsUploaded("tx1"))
+	assert.Equal(t, "ynab1", state.Uploaded["tx1"].YNABID)
+	assert.False(t, state.IsUploaded("tx-old"))
+}
+
+func TestSyncWindow(t *testing.T) {
+	now := time.Date(2023, 1, 21, 10, 30, 0, 0, time.UTC)
+	to := time.Date(2023, 1, 21, 10, 0, 0, 0, time.UTC)
+
+	t.Run("without cursor", func(t *testing.T) {
+		from, gotTo, complete := syncWindow(now, time.Time{}, defaultLookbackDays)
+		assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), from)
+		assert.Equal(t, to, gotTo)
+		assert.True(t, complete)
+	})
+
+	t.Run("with recent cursor", func(t *testing.T) {
+		from, gotTo, complete := syncWindow(now, time.Date(2023, 1, 20, 18, 0, 0, 0, time.UTC), defaultLookbackDays)
+		assert.Equal(t, time.Date(2023, 1, 13, 0, 0, 0, 0, time.UTC), from)
+		assert.Equal(t, to, gotTo)
+		assert.True(t, complete)
+	})
+
+	t.Run("after an outage longer than the lookback", func(t *testing.T) {
+		// The window reaches back to the cursor instead of skipping the days in between
+		from, _, complete := syncWindow(now, time.Date(2022, 12, 1, 18, 0, 0, 0, time.UTC), defaultLookbackDays)
+		assert.Equal(t, time.Date(2022, 11, 24, 0, 0, 0, 0, time.UTC), from)
+		assert.True(t, complete)
+	})
+
+	t.Run("after an outage longer than the available history", func(t *testing.T) {
+		from, _, complete := syncWindow(now, time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC), defaultLookbackDays)
+		assert.Equal(t, time.Date(2022, 10, 23, 0, 0, 0, 0, time.UTC), from)
+		assert.False(t, complete)
+	})
+}
+
+func TestMarkUploaded(t *testing.T) {
+	date := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	transactions := []Transaction{
+		{ID: "c1", Date: date, AmountMili: -4500, Name: "Coffee"},
+		{ID: "c2", Date: date, AmountMili: -3000, Name: "Bakery"},
+	}
+	created, createdID := "ynab-c1", "YNAB:-4500:2023-01-01:1"
+	summary := &transaction.OperationSummary{
+		Transactions:       []*transaction.Transaction{{ID: created, ImportID: &createdID}},
+		DuplicateImportIDs: []string{"YNAB:-3000:2023-01-01:1"},
+	}
+
+	t.Run("leaves out duplicates of amount and date import IDs", func(t *testing.T) {
+		testJob := job{ImportIDStrategy: importIDAmountDate}
+		state := JobState{}
+		recorded := markUploaded(context.Background(), testJob, &state, transactions, toYNABTransaction(testJob, state, transactions), summary)
+		assert.Equal(t, 1, recorded)
+		assert.Equal(t, created, state.Uploaded["c1"].YNABID)
+		assert.False(t, state.IsUploaded("c2"))
+	})
+
+	t.Run("records duplicates of bank ID import IDs", func(t *testing.T) {
+		// Only the same transaction can hold the import ID, e.g. uploaded before the state was lost
+		testJob := job{ImportIDStrategy: importIDTransactionID}
+		state := JobState{}
+		recorded := markUploaded(context.Background(), testJob, &state, transactions[1:], toYNABTransaction(testJob, state, transactions[1:]), &transaction.OperationSummary{DuplicateImportIDs: []string{"GC:c2"}})
+		assert.Equal(t, 1, recorded)
+		assert.True(t, state.IsUploaded("c2"))
+	})
+}
@@ -2,8 +2,24 @@ package main
 
 import (
 	"context"
+	"io"
 	"log/slog"
+	"os"
+	"slices"
+	"sync"
 	"time"
+
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/pkg/errors"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+const (
+	// cursorOverlapDays is how far before the last cursor the window starts, since banks book transactions late
+	cursorOverlapDays = 7
+	// backfillChunkDelay is the pause between consecutive backfill requests for the same account
+	backfillChunkDelay = 2 * time.Second
 )
 
 // SyncService implements the SynchronizationServicer interface
@@ -11,38 +27,212 @@ type SyncService struct {
 	gcService      GoCardlessServicer
 	ynabService    YNABServicer
 	monitorService MonitoringServicer
-	jobs           []job
+	stateStore     StateStorer
+	// rules rewrite fetched transactions, nil when RULES_FILE isn't set
+	rules *rulesFile
+	jobs  []job
+	// concurrency is how many jobs SynchronizeTransactions runs in parallel
+	concurrency int
+	// output is where dry runs print their diff, a whole diff at a time under outputMu since jobs run in parallel
+	outputMu sync.Mutex
+	output   io.Writer
+
+	// budgetCurrencies caches the currency of every budget, guarded by currencyMu
+	currencyMu       sync.Mutex
+	budgetCurrencies map[string]string
+
+	// accountIBANs caches the IBANs of the GoCardless accounts of jobs, guarded by ibanMu
+	ibanMu       sync.Mutex
+	accountIBANs map[string]string
+	// transferMu is held while jobs with linked accounts link and upload transfers
+	transferMu sync.Mutex
 }
 
 // NewSyncService creates a new SynchronizationServicer
-func NewSyncService(gcService GoCardlessServicer, ynabService YNABServicer, monitorService MonitoringServicer, jobs []job) SynchronizationServicer {
+func NewSyncService(gcService GoCardlessServicer, ynabService YNABServicer, monitorService MonitoringServicer, stateStore StateStorer, rules *rulesFile, jobs []job, concurrency int) SynchronizationServicer {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
 	return &SyncService{
 		gcService:      gcService,
 		ynabService:    ynabService,
 		monitorService: monitorService,
+		stateStore:     stateStore,
+		rules:          rules,
 		jobs:           jobs,
+		concurrency:    concurrency,
+		output:         os.Stdout,
 	}
 }
 
-// SynchronizeTransactions synchronizes all transactions for all jobs
-func (s *SyncService) SynchronizeTransactions(ctx context.Context) error {
-	for _, j := range s.jobs {
-		if err := s.SynchronizeTransaction(ctx, j); err != nil {
-			return err
+// SynchronizeTransactions synchronizes jobs, e.g. all configured ones, a failing job doesn't stop the others.
+// Jobs run in parallel on up to concurrency workers sharing one GoCardless session; jobs of the same GoCardless
+// account run one after another and are skipped once the account hits a rate limit.
+// The returned error is a *SyncError listing the failed jobs.
+func (s *SyncService) SynchronizeTransactions(ctx context.Context, jobs []job) (SyncReport, error) {
+	txn := s.monitorService.StartTransaction("synchronizeAll")
+	defer txn.End()
+
+	l := slog.Default()
+	report := SyncReport{Results: make([]JobResult, len(jobs))}
+	s.monitorService.AddAttribute(txn, "jobsCount", len(jobs))
+	s.monitorService.AddAttribute(txn, "concurrency", s.concurrency)
+
+	ctx = s.monitorService.NewContext(ctx, txn)
+	if err := s.gcService.LogIn(ctx); err != nil {
+		s.monitorService.RecordError(txn, err)
+		l.ErrorContext(ctx, "failed to log in", "error", err)
+		for i, j := range jobs {
+			report.Results[i] = JobResult{Job: j.Name, Err: errors.Wrap(err, "failed to log in")}
 		}
+		return report, report.Err()
 	}
-	return nil
+
+	limiter := newAccountLimiter()
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(s.concurrency, len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				report.Results[i] = s.synchronizeLimited(ctx, jobs[i], limiter)
+			}
+		}()
+	}
+	for i := range jobs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	err := report.Err()
+	s.monitorService.AddAttribute(txn, "failedJobsCount", len(report.Failed()))
+	if err != nil {
+		s.monitorService.RecordError(txn, err)
+	}
+
+	return report, err
+}
+
+// synchronizeLimited synchronizes a single job once no other job of its GoCardless account runs,
+// unless a rate limit stops it
+func (s *SyncService) synchronizeLimited(ctx context.Context, j job, limiter *accountLimiter) JobResult {
+	unlock := limiter.lock(j.GCAccountID)
+	defer unlock()
+
+	if err := limiter.blocked(j.GCAccountID); err != nil {
+		slog.Default().WarnContext(ctx, "job skipped", "gocardless_account_id", j.GCAccountID, "ynab_account_id", j.YNABAccountID, "error", err)
+		return JobResult{Job: j.Name, Err: err}
+	}
+
+	result, err := s.synchronize(ctx, j, false)
+	limiter.record(j.GCAccountID, err)
+	return result
 }
 
 // SynchronizeTransaction synchronizes transactions for a single job
-func (s *SyncService) SynchronizeTransaction(ctx context.Context, j job) error {
+func (s *SyncService) SynchronizeTransaction(ctx context.Context, j job) (JobResult, error) {
+	return s.synchronize(ctx, j, true)
+}
+
+// synchronize synchronizes transactions for a single job, logging in to GoCardless first when logIn is set
+func (s *SyncService) synchronize(ctx context.Context, j job, logIn bool) (JobResult, error) {
 	txn := s.monitorService.StartTransaction("synchronization")
 	defer txn.End()
 
 	funcStartedAt := time.Now()
 	l := slog.Default().With("gocardless_account_id", j.GCAccountID, "ynab_account_id", j.YNABAccountID, "ynab_budget_id", j.YNABBudgetID)
+	result := JobResult{Job: j.Name}
+	fail := func(msg string, err error) (JobResult, error) {
+		s.monitorService.RecordError(txn, err)
+		l.ErrorContext(ctx, msg, "error", err)
+		result.Duration = time.Since(funcStartedAt)
+		result.Err = err
+		return result, err
+	}
+
+	state, err := s.stateStore.JobState(j.key())
+	if err != nil {
+		return fail("failed to load job state", err)
+	}
+
+	from, to, complete := syncWindow(time.Now(), state.LastSyncedAt, j.LookbackDays)
+	if !complete {
+		// GoCardless only gives maxHistoricalDays of history, the transactions in between have to be entered by hand
+		l.WarnContext(ctx, "transactions between the last synchronization and the oldest available day can't be fetched", "last_synced_at", state.LastSyncedAt.Format("2006-01-02"), "from", from.Format("2006-01-02"))
+	}
+	s.monitorService.AddAttribute(txn, "from", from.Format("2006-01-02"))
+	s.monitorService.AddAttribute(txn, "to", to.Format("2006-01-02"))
+	s.monitorService.AddAttribute(txn, "gocardlessAccountId", j.GCAccountID)
+	s.monitorService.AddAttribute(txn, "ynabAccountId", j.YNABAccountID)
+	s.monitorService.AddAttribute(txn, "ynabBudgetId", j.YNABBudgetID)
+
+	ctx = s.monitorService.NewContext(ctx, txn)
+	if logIn {
+		if err := s.gcService.LogIn(ctx); err != nil {
+			return fail("failed to log in", err)
+		}
+	}
+
+	if j.DryRun {
+		if err := s.dryRun(ctx, j, state, from, to, &result); err != nil {
+			return fail("failed to dry run", err)
+		}
+
+		result.Duration = time.Since(funcStartedAt)
+		l.InfoContext(ctx, "dry run finished", "duration", result.Duration, "fetched", result.Fetched, "skipped", result.Skipped)
+		return result, nil
+	}
+
+	err = s.syncRange(ctx, j, &state, from, to, &result)
+	s.monitorService.AddAttribute(txn, "transactionsCount", result.Fetched)
+	s.monitorService.AddAttribute(txn, "newTransactionsCount", result.Uploaded)
+	s.monitorService.AddAttribute(txn, "skippedTransactionsCount", result.Skipped)
+	if err != nil {
+		return fail("failed to synchronize transactions", err)
+	}
+
+	state.LastSyncedAt = to
+	if err := s.stateStore.SaveJobState(j.key(), state); err != nil {
+		return fail("failed to save job state", err)
+	}
+
+	result.Duration = time.Since(funcStartedAt)
+	l.InfoContext(ctx, "finished", "duration", result.Duration, "fetched", result.Fetched, "uploaded", result.Uploaded, "skipped", result.Skipped)
+	return result, nil
+}
+
+// Backfill uploads the last days of history for a single job, fetching it in chunks of chunkDays.
+// Progress is stored after every chunk, so a backfill stopped by a rate limit resumes where it left off.
+func (s *SyncService) Backfill(ctx context.Context, j job, days int, chunkDays int) error {
+	txn := s.monitorService.StartTransaction("backfill")
+	defer txn.End()
+
+	funcStartedAt := time.Now()
+	l := slog.Default().With("gocardless_account_id", j.GCAccountID, "ynab_account_id", j.YNABAccountID, "ynab_budget_id", j.YNABBudgetID)
+
+	if days < 1 || days > maxHistoricalDays {
+		return errors.Errorf("backfill days must be between 1 and %d, got %d", maxHistoricalDays, days)
+	}
+	if chunkDays < 1 {
+		return errors.Errorf("backfill chunk days must be positive, got %d", chunkDays)
+	}
+
+	state, err := s.stateStore.JobState(j.key())
+	if err != nil {
+		s.monitorService.RecordError(txn, err)
+		l.ErrorContext(ctx, "failed to load job state", "error", err)
+		return err
+	}
+
 	to := time.Now().UTC().Truncate(time.Hour)
-	from := to.AddDate(0, 0, -20).Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -days).Truncate(24 * time.Hour)
+	if state.BackfilledTo.After(from) {
+		l.InfoContext(ctx, "resuming backfill", "backfilled_to", state.BackfilledTo.Format("2006-01-02"))
+		from = state.BackfilledTo
+	}
 	s.monitorService.AddAttribute(txn, "from", from.Format("2006-01-02"))
 	s.monitorService.AddAttribute(txn, "to", to.Format("2006-01-02"))
 	s.monitorService.AddAttribute(txn, "gocardlessAccountId", j.GCAccountID)
@@ -56,20 +246,293 @@ func (s *SyncService) SynchronizeTransaction(ctx context.Context, j job) error {
 		return err
 	}
 
-	transactions, err := s.gcService.ListTransactions(ctx, j.GCAccountID, from, to)
-	if err != nil {
+	var total JobResult
+	for chunkFrom := from; !chunkFrom.After(to); {
+		chunkTo := chunkFrom.AddDate(0, 0, chunkDays-1)
+		if chunkTo.After(to) {
+			chunkTo = to
+		}
+
+		if err := s.syncRange(ctx, j, &state, chunkFrom, chunkTo, &total); err != nil {
+			var rateLimitErr *gocardless.RateLimitError
+			if errors.As(err, &rateLimitErr) {
+				l.WarnContext(ctx, "backfill stopped by rate limit, run it again to resume", "backfilled_to", state.BackfilledTo.Format("2006-01-02"), "reset_in", rateLimitErr.ResetIn)
+			}
+			// The state from before the chunk is already stored, the failed chunk may have changed it for YNAB
+			// updates that never happened, so it's fetched and synchronized again on the next run
+			s.monitorService.RecordError(txn, err)
+			l.ErrorContext(ctx, "failed to backfill transactions", "error", err, "from", chunkFrom.Format("2006-01-02"), "to", chunkTo.Format("2006-01-02"))
+			return err
+		}
+
+		chunkFrom = chunkTo.AddDate(0, 0, 1).Truncate(24 * time.Hour)
+		state.BackfilledTo = chunkFrom
+		if err := s.stateStore.SaveJobState(j.key(), state); err != nil {
+			s.monitorService.RecordError(txn, err)
+			l.ErrorContext(ctx, "failed to save job state", "error", err)
+			return err
+		}
+
+		if !chunkFrom.After(to) {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backfillChunkDelay):
+			}
+		}
+	}
+
+	// The whole history is uploaded, so the regular synchronization can continue from here
+	state.BackfilledTo = time.Time{}
+	if to.After(state.LastSyncedAt) {
+		state.LastSyncedAt = to
+	}
+	if err := s.stateStore.SaveJobState(j.key(), state); err != nil {
 		s.monitorService.RecordError(txn, err)
-		l.ErrorContext(ctx, "failed to list transactions", "error", err)
+		l.ErrorContext(ctx, "failed to save job state", "error", err)
 		return err
 	}
 
-	s.monitorService.AddAttribute(txn, "transactionsCount", len(transactions))
-	if err := uploadToYNAB(ctx, s.ynabService, j.YNABAccountID, j.YNABBudgetID, transactions); err != nil {
-		s.monitorService.RecordError(txn, err)
-		l.ErrorContext(ctx, "failed to upload transactions", "error", err)
+	s.monitorService.AddAttribute(txn, "transactionsCount", total.Fetched)
+	s.monitorService.AddAttribute(txn, "newTransactionsCount", total.Uploaded)
+	s.monitorService.AddAttribute(txn, "skippedTransactionsCount", total.Skipped)
+	l.InfoContext(ctx, "backfill finished", "duration", time.Since(funcStartedAt), "fetched", total.Fetched, "uploaded", total.Uploaded, "skipped", total.Skipped)
+	return nil
+}
+
+// listTransactions lists the transactions of a job between from and to like ListJobTransactions, rewritten by the
+// rules and with the categories rules assigned resolved in the budget. The number of transactions skipped because
+// they couldn't be converted to the budget's currency is returned with the others.
+func (s *SyncService) listTransactions(ctx context.Context, j job, from, to time.Time) ([]Transaction, int, error) {
+	transactions, skipped, err := s.ListJobTransactions(ctx, j, from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r := s.rules.current(ctx)
+	for i, t := range transactions {
+		transactions[i] = r.apply(t)
+	}
+
+	if err := s.resolveCategories(ctx, j, transactions); err != nil {
+		return nil, skipped, err
+	}
+	return transactions, skipped, nil
+}
+
+// ListJobTransactions lists the transactions of a job between from and to the way a synchronization gives them to
+// the rules: dated according to the job's date strategy, with the memo of its template and converted to the currency
+// of its budget. Transactions that can't be converted are skipped with a warning, their number is returned with the
+// others.
+func (s *SyncService) ListJobTransactions(ctx context.Context, j job, from, to time.Time) ([]Transaction, int, error) {
+	transactions, err := s.gcService.ListTransactions(ctx, j.GCAccountID, from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The budget's currency is only needed to check transactions whose currency is known
+	budgetCurrency := ""
+	if slices.ContainsFunc(transactions, func(t Transaction) bool { return t.Currency != "" }) {
+		budgetCurrency, err = s.budgetCurrency(j.YNABBudgetID)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	converted := make([]Transaction, 0, len(transactions))
+	skipped := 0
+	for _, t := range transactions {
+		t.Date = t.dateFor(j.DateStrategy)
+		t = withMemoTemplate(ctx, j, t)
+		t, err := toBudgetCurrency(t, budgetCurrency)
+		if err != nil {
+			slog.Default().WarnContext(ctx, "skipped transaction in another currency", "gocardless_account_id", j.GCAccountID, "ynab_budget_id", j.YNABBudgetID, "id", t.ID, "error", err)
+			skipped++
+			continue
+		}
+		converted = append(converted, t)
+	}
+
+	return converted, skipped, nil
+}
+
+// budgetCurrency returns the ISO 4217 code of the currency of a budget, fetched once per budget. It's empty when
+// YNAB doesn't give the budget's currency format.
+func (s *SyncService) budgetCurrency(budgetID string) (string, error) {
+	s.currencyMu.Lock()
+	defer s.currencyMu.Unlock()
+
+	if currency, ok := s.budgetCurrencies[budgetID]; ok {
+		return currency, nil
+	}
+
+	settings, err := s.ynabService.GetBudgetSettings(budgetID)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get budget settings")
+	}
+
+	currency := ""
+	if settings != nil && settings.CurrencyFormat != nil {
+		currency = settings.CurrencyFormat.ISOCode
+	}
+	if s.budgetCurrencies == nil {
+		s.budgetCurrencies = make(map[string]string)
+	}
+	s.budgetCurrencies[budgetID] = currency
+	return currency, nil
+}
+
+// syncRange fetches transactions of a job between from and to and uploads the ones that weren't uploaded yet,
+// recording them in state. The numbers of fetched, uploaded and skipped transactions are added to result.
+func (s *SyncService) syncRange(ctx context.Context, j job, state *JobState, from, to time.Time, result *JobResult) error {
+	transactions, skipped, err := s.listTransactions(ctx, j, from, to)
+	if err != nil {
+		return errors.Wrap(err, "failed to list transactions")
+	}
+	result.Fetched += len(transactions)
+	result.Skipped += skipped
+
+	// Map all fetched transactions first so import IDs don't depend on what was already uploaded
+	payloadTransactions := toYNABTransaction(j, *state, transactions)
+	newTransactions, newPayloadTransactions := notUploaded(*state, transactions, payloadTransactions)
+
+	booked, err := s.reconcilePending(ctx, j, state, from, pendingImportIDs(payloadTransactions, transactions), newTransactions, newPayloadTransactions)
+	if err != nil {
+		return errors.Wrap(err, "failed to reconcile pending transactions")
+	}
+	newTransactions, newPayloadTransactions = withoutIndexes(newTransactions, newPayloadTransactions, booked)
+
+	if len(newPayloadTransactions) > 0 && len(s.linkedJobs(j)) > 0 {
+		// Both sides of a transfer are handled one after another, so parallel jobs can't both create it
+		s.transferMu.Lock()
+		defer s.transferMu.Unlock()
+
+		linked, err := s.linkTransfers(ctx, j, state, newTransactions, newPayloadTransactions)
+		if err != nil {
+			return errors.Wrap(err, "failed to link transfers")
+		}
+		newTransactions, newPayloadTransactions = withoutIndexes(newTransactions, newPayloadTransactions, linked)
+	}
+
+	if len(newPayloadTransactions) > 0 {
+		entered, err := s.matchManual(ctx, j, state, newTransactions, newPayloadTransactions)
+		if err != nil {
+			return errors.Wrap(err, "failed to match transactions entered in YNAB")
+		}
+		newTransactions, newPayloadTransactions = withoutIndexes(newTransactions, newPayloadTransactions, entered)
+	}
+	if len(newPayloadTransactions) == 0 {
+		return nil
+	}
+
+	summary, err := uploadToYNAB(ctx, s.ynabService, j.YNABBudgetID, newPayloadTransactions)
+	if err != nil {
 		return err
 	}
+	result.Uploaded += markUploaded(ctx, j, state, newTransactions, newPayloadTransactions, summary)
 
-	l.InfoContext(ctx, "finished", "duration", time.Since(funcStartedAt))
 	return nil
 }
+
+// syncWindow returns the date range to fetch, starting a few days before the last cursor, or lookbackDays back
+// without one. After an outage the window reaches back to the cursor, but never further than the maxHistoricalDays
+// GoCardless gives access to, complete is false when that leaves a gap after the cursor.
+func syncWindow(now, lastSyncedAt time.Time, lookbackDays int) (from, to time.Time, complete bool) {
+	to = now.UTC().Truncate(time.Hour)
+	if lastSyncedAt.IsZero() {
+		return to.AddDate(0, 0, -lookbackDays).Truncate(24 * time.Hour), to, true
+	}
+
+	from = lastSyncedAt.UTC().AddDate(0, 0, -cursorOverlapDays).Truncate(24 * time.Hour)
+	oldest := to.AddDate(0, 0, -maxHistoricalDays).Truncate(24 * time.Hour)
+	if from.Before(oldest) {
+		return oldest, to, !lastSyncedAt.Before(oldest)
+	}
+
+	return from, to, true
+}
+
+// notUploaded returns the transactions, and their payloads, that weren't sent to YNAB yet.
+// payloadTransactions must be aligned with transactions.
+func notUploaded(state JobState, transactions []Transaction, payloadTransactions []transaction.PayloadTransaction) ([]Transaction, []transaction.PayloadTransaction) {
+	var newTransactions []Transaction
+	var newPayloadTransactions []transaction.PayloadTransaction
+	for i, t := range transactions {
+		importID := *payloadTransactions[i].ImportID
+		if state.IsUploaded(uploadKey(t, importID)) || (t.Pending && state.IsPending(importID)) {
+			continue
+		}
+		newTransactions = append(newTransactions, t)
+		newPayloadTransactions = append(newPayloadTransactions, payloadTransactions[i])
+	}
+
+	return newTransactions, newPayloadTransactions
+}
+
+// withoutIndexes returns the transactions, and their payloads, whose indexes aren't in skip
+func withoutIndexes(transactions []Transaction, payloadTransactions []transaction.PayloadTransaction, skip map[int]bool) ([]Transaction, []transaction.PayloadTransaction) {
+	if len(skip) == 0 {
+		return transactions, payloadTransactions
+	}
+
+	var keptTransactions []Transaction
+	var keptPayloadTransactions []transaction.PayloadTransaction
+	for i, t := range transactions {
+		if skip[i] {
+			continue
+		}
+		keptTransactions = append(keptTransactions, t)
+		keptPayloadTransactions = append(keptPayloadTransactions, payloadTransactions[i])
+	}
+
+	return keptTransactions, keptPayloadTransactions
+}
+
+// markUploaded records the uploaded transactions in the job state, including the YNAB IDs assigned to them, and returns
+// how many were recorded. Transactions YNAB rejected as duplicates of another transaction are left out, with a warning.
+func markUploaded(ctx context.Context, j job, state *JobState, transactions []Transaction, payloadTransactions []transaction.PayloadTransaction, result *transaction.OperationSummary) int {
+	ynabIDs := make(map[string]string, len(result.Transactions))
+	for _, t := range result.Transactions {
+		if t != nil && t.ImportID != nil {
+			ynabIDs[*t.ImportID] = t.ID
+		}
+	}
+	duplicates := make(map[string]bool, len(result.DuplicateImportIDs))
+	for _, importID := range result.DuplicateImportIDs {
+		duplicates[importID] = true
+	}
+
+	recorded := 0
+	now := time.Now().UTC()
+	for i, t := range transactions {
+		importID := *payloadTransactions[i].ImportID
+		// An import ID built from the bank's ID can only be a duplicate of the same transaction, e.g. after the state
+		// was lost. One built from the amount and date may belong to another transaction YNAB already holds.
+		if duplicates[importID] && (j.ImportIDStrategy != importIDTransactionID || t.ID == "") {
+			slog.Default().WarnContext(ctx, "YNAB rejected transaction as a duplicate", "gocardless_account_id", j.GCAccountID, "ynab_budget_id", j.YNABBudgetID, "id", t.ID, "import_id", importID, "date", t.Date.Format("2006-01-02"), "amount", t.AmountMili, "payee", t.Name)
+			continue
+		}
+		recorded++
+
+		if t.Pending {
+			state.MarkPending(importID, PendingTransaction{
+				ID:         t.ID,
+				YNABID:     ynabIDs[importID],
+				Date:       t.Date,
+				AmountMili: t.AmountMili,
+				Name:       t.Name,
+			})
+			continue
+		}
+
+		state.MarkUploaded(uploadKey(t, importID), UploadedTransaction{
+			ImportID:   importID,
+			YNABID:     ynabIDs[importID],
+			Date:       t.Date,
+			AmountMili: t.AmountMili,
+			UploadedAt: now,
+		})
+	}
+
+	return recorded
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api"
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/pkg/errors"
+)
+
+// transferMatchDays is how many days the two sides of a transfer between linked accounts may be booked apart
+const transferMatchDays = 4
+
+// linkedJobs returns the other jobs of the budget of j, their accounts are the ones transfers are detected between
+func (s *SyncService) linkedJobs(j job) []job {
+	var linked []job
+	for _, other := range s.jobs {
+		if other.YNABBudgetID == j.YNABBudgetID && other.YNABAccountID != j.YNABAccountID {
+			linked = append(linked, other)
+		}
+	}
+	return linked
+}
+
+// accountIBAN returns the normalized IBAN of the GoCardless account of a job, fetched once per account. It's empty
+// when GoCardless doesn't know it or fails to return it, then no transfers to the account are created.
+func (s *SyncService) accountIBAN(ctx context.Context, j job) string {
+	s.ibanMu.Lock()
+	defer s.ibanMu.Unlock()
+
+	if iban, ok := s.accountIBANs[j.GCAccountID]; ok {
+		return iban
+	}
+
+	account, err := s.gcService.GetAccount(ctx, j.GCAccountID)
+	if err != nil {
+		// It's asked for again in the next synchronization
+		slog.Default().WarnContext(ctx, "failed to get IBAN of linked account", "gocardless_account_id", j.GCAccountID, "error", err)
+		return ""
+	}
+
+	if s.accountIBANs == nil {
+		s.accountIBANs = make(map[string]string)
+	}
+	s.accountIBANs[j.GCAccountID] = normalizeIBAN(account.IBAN)
+	return s.accountIBANs[j.GCAccountID]
+}
+
+// linkTransfers detects the booked transactions that move money between the account of j and the account of
+// another job of its budget. A transaction whose other side YNAB already created as a transfer is recorded in state
+// as that transaction instead of being uploaded again, and its index is returned. A transaction sent to the IBAN of
+// a linked account gets the transfer payee of that account, so YNAB creates the other side, unless that side was
+// already imported as an ordinary transaction. transactions must be aligned with payloadTransactions.
+func (s *SyncService) linkTransfers(ctx context.Context, j job, state *JobState, transactions []Transaction, payloadTransactions []transaction.PayloadTransaction) (map[int]bool, error) {
+	l := slog.Default().With("gocardless_account_id", j.GCAccountID, "ynab_account_id", j.YNABAccountID, "ynab_budget_id", j.YNABBudgetID)
+	linked := make(map[int]bool)
+
+	linkedJobs := s.linkedJobs(j)
+	var since time.Time
+	for _, t := range transactions {
+		if !t.Pending && (since.IsZero() || t.Date.Before(since)) {
+			since = t.Date
+		}
+	}
+	if len(linkedJobs) == 0 || since.IsZero() {
+		return linked, nil
+	}
+	since = since.AddDate(0, 0, -transferMatchDays)
+
+	// Transfers the other jobs created show up in the account of j without an import ID
+	own, err := s.accountTransactions(j.YNABBudgetID, j.YNABAccountID, since)
+	if err != nil {
+		return nil, err
+	}
+	claimed := make(map[string]bool)
+	for _, uploaded := range state.Uploaded {
+		claimed[uploaded.YNABID] = true
+	}
+	for _, pending := range state.Pending {
+		claimed[pending.YNABID] = true
+	}
+
+	var byIBAN map[string]job
+	linkedAccounts := make(map[string]bool)
+	for _, other := range linkedJobs {
+		linkedAccounts[other.YNABAccountID] = true
+	}
+
+	var transferPayeeIDs map[string]string
+	destinations := make(map[string][]*transaction.Transaction)
+	var updates []transaction.PayloadTransaction
+	now := time.Now().UTC()
+	for i, t := range transactions {
+		if t.Pending {
+			continue
+		}
+
+		// The counterparty IBAN names the linked account, IBANs are only fetched once a transaction has one
+		var destination *job
+		if iban := normalizeIBAN(counterpartyIBAN(t)); iban != "" {
+			if byIBAN == nil {
+				byIBAN = make(map[string]job)
+				for _, other := range linkedJobs {
+					if otherIBAN := s.accountIBAN(ctx, other); otherIBAN != "" {
+						byIBAN[otherIBAN] = other
+					}
+				}
+			}
+			if other, ok := byIBAN[iban]; ok {
+				destination = &other
+			}
+		}
+
+		accounts := linkedAccounts
+		if destination != nil {
+			accounts = map[string]bool{destination.YNABAccountID: true}
+		}
+		if counterpart := matchTransfer(t.AmountMili, t.Date, own, accounts, claimed, false); counterpart != nil {
+			claimed[counterpart.ID] = true
+			linked[i] = true
+			if counterpart.Cleared == transaction.ClearingStatusUncleared {
+				update := toUpdatePayload(counterpart)
+				update.Cleared = transaction.ClearingStatusCleared
+				updates = append(updates, update)
+			}
+
+			l.InfoContext(ctx, "linked transfer", "id", t.ID, "ynab_id", counterpart.ID, "transfer_account_id", *counterpart.TransferAccountID, "amount", t.AmountMili, "date", t.Date.Format("2006-01-02"))
+			state.MarkUploaded(uploadKey(t, *payloadTransactions[i].ImportID), UploadedTransaction{
+				ImportID:   *payloadTransactions[i].ImportID,
+				YNABID:     counterpart.ID,
+				Date:       t.Date,
+				AmountMili: t.AmountMili,
+				UploadedAt: now,
+			})
+			continue
+		}
+		if destination == nil {
+			continue
+		}
+
+		// The other side imported as an ordinary transaction would be duplicated by the transfer
+		other, ok := destinations[destination.YNABAccountID]
+		if !ok {
+			other, err = s.accountTransactions(j.YNABBudgetID, destination.YNABAccountID, since)
+			if err != nil {
+				return nil, err
+			}
+			destinations[destination.YNABAccountID] = other
+		}
+		if imported := matchTransfer(-t.AmountMili, t.Date, other, nil, nil, true); imported != nil {
+			l.InfoContext(ctx, "other side of transfer already imported, uploading it as an ordinary transaction", "id", t.ID, "ynab_id", imported.ID, "destination_ynab_account_id", destination.YNABAccountID)
+			continue
+		}
+
+		if transferPayeeIDs == nil {
+			transferPayeeIDs, err = s.ynabService.GetTransferPayeeIDs(j.YNABBudgetID)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to get transfer payees")
+			}
+		}
+		payeeID, ok := transferPayeeIDs[destination.YNABAccountID]
+		if !ok {
+			l.WarnContext(ctx, "linked account has no transfer payee", "destination_ynab_account_id", destination.YNABAccountID)
+			continue
+		}
+
+		// Transfers between budget accounts aren't categorized
+		payloadTransactions[i].PayeeID = &payeeID
+		payloadTransactions[i].CategoryID = nil
+		l.InfoContext(ctx, "uploading transfer", "id", t.ID, "destination_ynab_account_id", destination.YNABAccountID, "amount", t.AmountMili, "date", t.Date.Format("2006-01-02"))
+	}
+
+	if len(updates) > 0 {
+		if _, err := s.ynabService.UpdateTransactions(j.YNABBudgetID, updates); err != nil {
+			return nil, errors.Wrap(err, "failed to clear linked transfers")
+		}
+	}
+
+	return linked, nil
+}
+
+// accountTransactions lists the transactions of a YNAB account since a date
+func (s *SyncService) accountTransactions(budgetID, accountID string, since time.Time) ([]*transaction.Transaction, error) {
+	sinceDate := api.Date{Time: since}
+	transactions, err := s.ynabService.GetTransactionsByAccount(budgetID, accountID, &transaction.Filter{Since: &sinceDate})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list YNAB transactions")
+	}
+	return transactions, nil
+}
+
+// matchTransfer returns the YNAB transaction of amount closest to date, within transferMatchDays. With imported it
+// looks for a transaction imported without a transfer, otherwise for a transfer from one of accounts that wasn't
+// imported and isn't claimed.
+func matchTransfer(amount int64, date time.Time, candidates []*transaction.Transaction, accounts, claimed map[string]bool, imported bool) *transaction.Transaction {
+	var best *transaction.Transaction
+	var bestDistance time.Duration
+	for _, c := range candidates {
+		if c.Deleted || c.Amount != amount || claimed[c.ID] {
+			continue
+		}
+		if imported && (c.ImportID == nil || c.TransferAccountID != nil) {
+			continue
+		}
+		if !imported && (c.ImportID != nil || c.TransferAccountID == nil || !accounts[*c.TransferAccountID]) {
+			continue
+		}
+
+		distance := c.Date.Sub(date)
+		if distance < 0 {
+			distance = -distance
+		}
+		if distance > transferMatchDays*24*time.Hour {
+			continue
+		}
+		if best == nil || distance < bestDistance {
+			best, bestDistance = c, distance
+		}
+	}
+	return best
+}
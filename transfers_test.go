@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api"
+	"github.com/brunomvsouza/ynab.go/api/transaction"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"psmarcin.github.com/open-ynab-sync/internal/gocardless"
+)
+
+func TestSyncServiceLinkTransfers(t *testing.T) {
+	checking := job{GCAccountID: "gc-checking", YNABAccountID: "checking", YNABBudgetID: "budget"}
+	savings := job{GCAccountID: "gc-savings", YNABAccountID: "savings", YNABBudgetID: "budget"}
+	other := job{GCAccountID: "gc-other", YNABAccountID: "other", YNABBudgetID: "other-budget"}
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	savingsIBAN := "DE02120300000000202051"
+	transferAccount := "checking"
+
+	t.Run("uploads a transfer to the account of the counterparty IBAN", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService := &SyncService{gcService: goCardlessMock, ynabService: ynabMock, jobs: []job{checking, savings, other}}
+
+		transactions := []Transaction{
+			{ID: "t1", Date: day, AmountMili: -100000, Name: "Me", CreditorIBAN: "de02 1203 0000 0000 2020 51", Category: "Fun", CategoryID: "fun"},
+			{ID: "t2", Date: day, AmountMili: -5000, Name: "Shop"},
+		}
+		payloads := toYNABTransaction(checking, JobState{}, transactions)
+		goCardlessMock.EXPECT().GetAccount(mock.Anything, "gc-savings").Return(gocardless.Account{IBAN: savingsIBAN}, nil).Once()
+		ynabMock.EXPECT().GetTransactionsByAccount("budget", "checking", mock.Anything).Return(nil, nil).Once()
+		ynabMock.EXPECT().GetTransactionsByAccount("budget", "savings", mock.Anything).Return(nil, nil).Once()
+		ynabMock.EXPECT().GetTransferPayeeIDs("budget").Return(map[string]string{"savings": "transfer-savings"}, nil).Once()
+
+		linked, err := syncService.linkTransfers(context.Background(), checking, &JobState{}, transactions, payloads)
+		require.NoError(t, err)
+		assert.Empty(t, linked)
+		if assert.NotNil(t, payloads[0].PayeeID) {
+			assert.Equal(t, "transfer-savings", *payloads[0].PayeeID)
+		}
+		assert.Nil(t, payloads[0].CategoryID)
+		assert.Nil(t, payloads[1].PayeeID)
+	})
+
+	t.Run("links the transfer the other job created", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService := &SyncService{gcService: goCardlessMock, ynabService: ynabMock, jobs: []job{checking, savings}}
+
+		transactions := []Transaction{{ID: "s1", Date: day.AddDate(0, 0, 2), AmountMili: 100000, Name: "Me"}}
+		payloads := toYNABTransaction(savings, JobState{}, transactions)
+		counterpart := &transaction.Transaction{
+			ID:                "ynab-s1",
+			AccountID:         "savings",
+			Date:              api.Date{Time: day},
+			Amount:            100000,
+			Cleared:           transaction.ClearingStatusUncleared,
+			TransferAccountID: &transferAccount,
+		}
+		ynabMock.EXPECT().GetTransactionsByAccount("budget", "savings", mock.Anything).Return([]*transaction.Transaction{counterpart}, nil).Once()
+		ynabMock.EXPECT().UpdateTransactions("budget", mock.Anything).RunAndReturn(func(_ string, updates []transaction.PayloadTransaction) (*transaction.OperationSummary, error) {
+			if assert.Len(t, updates, 1) {
+				assert.Equal(t, "ynab-s1", updates[0].ID)
+				assert.Equal(t, transaction.ClearingStatusCleared, updates[0].Cleared)
+			}
+			return &transaction.OperationSummary{}, nil
+		}).Once()
+
+		state := &JobState{}
+		linked, err := syncService.linkTransfers(context.Background(), savings, state, transactions, payloads)
+		require.NoError(t, err)
+		assert.Equal(t, map[int]bool{0: true}, linked)
+		if assert.Contains(t, state.Uploaded, "s1") {
+			assert.Equal(t, "ynab-s1", state.Uploaded["s1"].YNABID)
+			assert.Equal(t, *payloads[0].ImportID, state.Uploaded["s1"].ImportID)
+		}
+	})
+
+	t.Run("uploads an ordinary transaction when the other side was imported", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService := &SyncService{gcService: goCardlessMock, ynabService: ynabMock, jobs: []job{checking, savings}}
+
+		transactions := []Transaction{{ID: "t1", Date: day, AmountMili: -100000, Name: "Me", CreditorIBAN: savingsIBAN}}
+		payloads := toYNABTransaction(checking, JobState{}, transactions)
+		importID := "YNAB:100000:2024-03-11:1"
+		goCardlessMock.EXPECT().GetAccount(mock.Anything, "gc-savings").Return(gocardless.Account{IBAN: savingsIBAN}, nil).Once()
+		ynabMock.EXPECT().GetTransactionsByAccount("budget", "checking", mock.Anything).Return(nil, nil).Once()
+		ynabMock.EXPECT().GetTransactionsByAccount("budget", "savings", mock.Anything).Return([]*transaction.Transaction{
+			{ID: "ynab-s1", AccountID: "savings", Date: api.Date{Time: day.AddDate(0, 0, 1)}, Amount: 100000, ImportID: &importID},
+		}, nil).Once()
+
+		linked, err := syncService.linkTransfers(context.Background(), checking, &JobState{}, transactions, payloads)
+		require.NoError(t, err)
+		assert.Empty(t, linked)
+		assert.Nil(t, payloads[0].PayeeID)
+	})
+
+	t.Run("uploads an ordinary transaction when the IBAN is unknown", func(t *testing.T) {
+		goCardlessMock := newMockgoCardlesser(t)
+		ynabMock := newMockynaber(t)
+		syncService := &SyncService{gcService: goCardlessMock, ynabService: ynabMock, jobs: []job{checking, savings}}
+
+		transactions := []Transaction{{ID: "t1", Date: day, AmountMili: -100000, Name: "Me", CreditorIBAN: savingsIBAN}}
+		payloads := toYNABTransaction(checking, JobState{}, transactions)
+		goCardlessMock.EXPECT().GetAccount(mock.Anything, "gc-savings").Return(gocardless.Account{}, errors.New("boom")).Once()
+		ynabMock.EXPECT().GetTransactionsByAccount("budget", "checking", mock.Anything).Return(nil, nil).Once()
+
+		linked, err := syncService.linkTransfers(context.Background(), checking, &JobState{}, transactions, payloads)
+		require.NoError(t, err)
+		assert.Empty(t, linked)
+		assert.Nil(t, payloads[0].PayeeID)
+	})
+
+	t.Run("doesn't look for transfers without linked jobs", func(t *testing.T) {
+		syncService := &SyncService{gcService: newMockgoCardlesser(t), ynabService: newMockynaber(t), jobs: []job{checking, other}}
+
+		transactions := []Transaction{{ID: "t1", Date: day, AmountMili: -100000, CreditorIBAN: savingsIBAN}}
+		linked, err := syncService.linkTransfers(context.Background(), checking, &JobState{}, transactions, toYNABTransaction(checking, JobState{}, transactions))
+		require.NoError(t, err)
+		assert.Empty(t, linked)
+	})
+}
+
+func TestMatchTransfer(t *testing.T) {
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	savings, other := "savings", "other"
+	importID := "YNAB:100000:2024-03-10:1"
+	candidates := []*transaction.Transaction{
+		{ID: "far", Date: api.Date{Time: day.AddDate(0, 0, -5)}, Amount: 100000, TransferAccountID: &savings},
+		{ID: "near", Date: api.Date{Time: day.AddDate(0, 0, 1)}, Amount: 100000, TransferAccountID: &savings},
+		{ID: "nearer", Date: api.Date{Time: day}, Amount: 100000, TransferAccountID: &savings},
+		{ID: "other", Date: api.Date{Time: day}, Amount: 100000, TransferAccountID: &other},
+		{ID: "imported", Date: api.Date{Time: day}, Amount: 100000, ImportID: &importID},
+		{ID: "deleted", Date: api.Date{Time: day}, Amount: 50000, TransferAccountID: &savings, Deleted: true},
+	}
+
+	tests := []struct {
+		name     string
+		amount   int64
+		accounts map[string]bool
+		claimed  map[string]bool
+		imported bool
+		want     string
+	}{
+		{name: "closest transfer", amount: 100000, accounts: map[string]bool{savings: true}, want: "nearer"},
+		{name: "skips claimed", amount: 100000, accounts: map[string]bool{savings: true}, claimed: map[string]bool{"nearer": true}, want: "near"},
+		{name: "only linked accounts", amount: 100000, accounts: map[string]bool{other: true}, want: "other"},
+		{name: "imported", amount: 100000, imported: true, want: "imported"},
+		{name: "other amount", amount: 20000, accounts: map[string]bool{savings: true}},
+		{name: "skips deleted", amount: 50000, accounts: map[string]bool{savings: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchTransfer(tt.amount, day, candidates, tt.accounts, tt.claimed, tt.imported)
+			if tt.want == "" {
+				assert.Nil(t, got)
+				return
+			}
+			if assert.NotNil(t, got) {
+				assert.Equal(t, tt.want, got.ID)
+			}
+		})
+	}
+}
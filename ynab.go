@@ -2,25 +2,46 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
 
 	"github.com/brunomvsouza/ynab.go/api"
+	"github.com/brunomvsouza/ynab.go/api/account"
+	"github.com/brunomvsouza/ynab.go/api/budget"
+	"github.com/brunomvsouza/ynab.go/api/category"
 	"github.com/brunomvsouza/ynab.go/api/transaction"
 	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/pkg/errors"
 )
 
+const (
+	// maxImportIDLength is the longest import ID YNAB accepts
+	maxImportIDLength = 36
+	// transactionImportIDPrefix marks import IDs derived from GoCardless transaction IDs
+	transactionImportIDPrefix = "GC:"
+	// pendingTransactionImportIDPrefix marks import IDs derived from IDs of pending GoCardless transactions
+	pendingTransactionImportIDPrefix = "GCP:"
+)
+
 type ynaber interface {
 	CreateTransactions(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error)
+	UpdateTransactions(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error)
+	DeleteTransaction(budgetID, transactionID string) (*transaction.Transaction, error)
+	GetTransactionsByAccount(budgetID, accountID string, f *transaction.Filter) ([]*transaction.Transaction, error)
+	GetBudgets() ([]*budget.Summary, error)
+	GetBudgetSettings(budgetID string) (*budget.Settings, error)
+	GetAccounts(budgetID string) ([]*account.Account, error)
+	GetCategories(budgetID string) ([]*category.GroupWithCategories, error)
+	GetTransferPayeeIDs(budgetID string) (map[string]string, error)
 }
 
-func uploadToYNAB(ctx context.Context, ynabc ynaber, ynabAccountID, ynabBudgetID string, transactions []Transaction) error {
+func uploadToYNAB(ctx context.Context, ynabc ynaber, ynabBudgetID string, payloadTransactions []transaction.PayloadTransaction) (*transaction.OperationSummary, error) {
 	txn := newrelic.FromContext(ctx)
 	seg := txn.StartSegment("uploadToYNAB")
 	defer seg.End()
 	l := slog.Default()
-	payloadTransactions := toYNABTransaction(ynabAccountID, transactions)
 	seg.AddAttribute("payloadTransactionsCount", len(payloadTransactions))
 	for _, payloadTransaction := range payloadTransactions {
 		l.InfoContext(ctx, "uploading transaction", "date", payloadTransaction.Date, "payee", *payloadTransaction.PayeeName, "memo", *payloadTransaction.Memo, "amount", payloadTransaction.Amount)
@@ -28,41 +49,61 @@ func uploadToYNAB(ctx context.Context, ynabc ynaber, ynabAccountID, ynabBudgetID
 
 	result, err := ynabc.CreateTransactions(ynabBudgetID, payloadTransactions)
 	if err != nil {
-		return errors.Wrapf(err, "failed to upload transactions")
+		return nil, errors.Wrapf(err, "failed to upload transactions")
 	}
 
 	seg.AddAttribute("resultTransactionsCount", len(result.Transactions))
 
-	l.InfoContext(ctx, "successfully uploaded transactions", "count", len(result.Transactions))
-	return nil
+	l.InfoContext(ctx, "successfully uploaded transactions", "count", len(result.Transactions), "duplicates", len(result.DuplicateImportIDs))
+	return result, nil
 }
 
-func toYNABTransaction(ynabAccountID string, gcTransactions []Transaction) []transaction.PayloadTransaction {
+// toYNABTransaction maps GoCardless transactions to the YNAB transactions they are uploaded as. Import IDs built from
+// the amount and date are numbered in the order of gcTransactions, skipping the numbers state records for another bank
+// transaction, so a fetch with more transactions of the same day doesn't reuse the import ID of an uploaded one.
+func toYNABTransaction(j job, state JobState, gcTransactions []Transaction) []transaction.PayloadTransaction {
 	var ynabTransactions []transaction.PayloadTransaction
-	uniqueImportIDs := make(map[string]int64)
+	recorded := recordedImportIDs(state)
+	usedImportIDs := make(map[string]bool)
 	for _, gcTransaction := range gcTransactions {
 		d, err := api.DateFromString(gcTransaction.Date.Format("2006-01-02"))
 		if err != nil {
 			panic(err)
 		}
 
-		occurrence := uniqueImportIDs[toImportID(gcTransaction)]
-		occurrence = +1
-		uniqueImportIDs[toImportID(gcTransaction)] = occurrence
+		var importID string
+		if j.ImportIDStrategy == importIDTransactionID && gcTransaction.ID != "" {
+			importID = toTransactionImportID(gcTransaction)
+		} else {
+			importID = occurrenceImportID(gcTransaction, recorded, usedImportIDs)
+		}
 
-		importID := toImportIDWithOccurrence(gcTransaction, int(occurrence))
+		// Pending transactions stay uncleared until the bank books them
+		cleared := transaction.ClearingStatusCleared
+		if gcTransaction.Pending {
+			cleared = transaction.ClearingStatusUncleared
+		}
+
+		// YNAB rejects longer memos
+		memo := truncateRunes(maxMemoLength, gcTransaction.Memo)
+
+		// Transactions are uncategorized unless a category rule assigned them one
+		var categoryID *string
+		if gcTransaction.CategoryID != "" {
+			categoryID = &gcTransaction.CategoryID
+		}
 
 		ynabTransactions = append(ynabTransactions, transaction.PayloadTransaction{
 			ID:         gcTransaction.ID,
-			AccountID:  ynabAccountID,
+			AccountID:  j.YNABAccountID,
 			Date:       d,
 			Amount:     gcTransaction.AmountMili,
-			Cleared:    transaction.ClearingStatusCleared, //
-			Approved:   false,
+			Cleared:    cleared,
+			Approved:   gcTransaction.Approved,
 			PayeeID:    nil,
 			PayeeName:  &gcTransaction.Name,
-			CategoryID: nil,
-			Memo:       &gcTransaction.Memo,
+			CategoryID: categoryID,
+			Memo:       &memo,
 			ImportID:   &importID,
 		})
 	}
@@ -70,10 +111,84 @@ func toYNABTransaction(ynabAccountID string, gcTransactions []Transaction) []tra
 	return ynabTransactions
 }
 
+// recordedImportIDs maps the import IDs state records for transactions with a bank ID to that ID
+func recordedImportIDs(state JobState) map[string]string {
+	recorded := make(map[string]string, len(state.Uploaded)+len(state.Pending))
+	for key, uploaded := range state.Uploaded {
+		// Transactions without a bank ID are recorded under their import ID
+		if key != uploaded.ImportID {
+			recorded[uploaded.ImportID] = key
+		}
+	}
+	for importID, pending := range state.Pending {
+		if pending.ID != "" {
+			recorded[importID] = pending.ID
+		}
+	}
+	return recorded
+}
+
+// occurrenceImportID returns the amount and date import ID of t with the lowest occurrence that isn't used yet and
+// isn't recorded for another bank transaction
+func occurrenceImportID(t Transaction, recorded map[string]string, used map[string]bool) string {
+	for occurrence := 1; ; occurrence++ {
+		importID := toImportIDWithOccurrence(t, occurrence)
+		if used[importID] {
+			continue
+		}
+		if id, ok := recorded[importID]; ok && id != t.ID {
+			continue
+		}
+		used[importID] = true
+		return importID
+	}
+}
+
 func toImportIDWithOccurrence(transaction Transaction, occurrence int) string {
 	return fmt.Sprintf("%s:%d", toImportID(transaction), occurrence)
 }
 
+// toImportID builds the import ID without occurrence, pending transactions get their own prefix
+// so they never take an import ID a booked transaction needs later
 func toImportID(transaction Transaction) string {
-	return fmt.Sprintf("YNAB:%d:%s", transaction.AmountMili, transaction.Date.Format("2006-01-02"))
+	prefix := "YNAB"
+	if transaction.Pending {
+		prefix = "PENDING"
+	}
+	return fmt.Sprintf("%s:%d:%s", prefix, transaction.AmountMili, transaction.Date.Format("2006-01-02"))
+}
+
+// toTransactionImportID builds an import ID from the bank's transaction ID, hashing IDs that don't fit
+// in the 36 characters YNAB allows
+func toTransactionImportID(transaction Transaction) string {
+	prefix := transactionImportIDPrefix
+	if transaction.Pending {
+		prefix = pendingTransactionImportIDPrefix
+	}
+
+	importID := prefix + transaction.ID
+	if len(importID) <= maxImportIDLength {
+		return importID
+	}
+
+	hash := sha256.Sum256([]byte(transaction.ID))
+	return prefix + hex.EncodeToString(hash[:])[:maxImportIDLength-len(prefix)]
+}
+
+// toUpdatePayload builds a payload that keeps everything the user changed on an existing YNAB transaction
+func toUpdatePayload(t *transaction.Transaction) transaction.PayloadTransaction {
+	return transaction.PayloadTransaction{
+		ID:         t.ID,
+		AccountID:  t.AccountID,
+		Date:       t.Date,
+		Amount:     t.Amount,
+		Cleared:    t.Cleared,
+		Approved:   t.Approved,
+		PayeeID:    t.PayeeID,
+		PayeeName:  t.PayeeName,
+		CategoryID: t.CategoryID,
+		Memo:       t.Memo,
+		FlagColor:  t.FlagColor,
+		ImportID:   t.ImportID,
+	}
 }
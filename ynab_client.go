@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api"
+
+	"psmarcin.github.com/open-ynab-sync/internal/retry"
+)
+
+// ynabAPIURL is the base URL of the YNAB API
+const ynabAPIURL = "https://api.youneedabudget.com/v1"
+
+// ynabClient implements api.ClientReaderWriter, the transport of the ynab.go services, retrying transient failures
+type ynabClient struct {
+	// ctx is cancelled on shutdown, it stops requests along with their retries. ynab.go has no way to pass the
+	// context of a call.
+	ctx         context.Context
+	accessToken string
+	// baseURL is the base URL of the API, without a trailing slash
+	baseURL    string
+	httpClient *http.Client
+	retry      retry.Policy
+}
+
+func newYNABClient(ctx context.Context, accessToken, baseURL string, retryPolicy retry.Policy) *ynabClient {
+	return &ynabClient{
+		ctx:         ctx,
+		accessToken: accessToken,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		retry:       retryPolicy,
+	}
+}
+
+// GET sends a GET request to the YNAB API
+func (c *ynabClient) GET(url string, responseModel interface{}) error {
+	return c.do(http.MethodGet, url, responseModel, nil)
+}
+
+// POST sends a POST request to the YNAB API
+func (c *ynabClient) POST(url string, responseModel interface{}, requestBody []byte) error {
+	return c.do(http.MethodPost, url, responseModel, requestBody)
+}
+
+// PUT sends a PUT request to the YNAB API
+func (c *ynabClient) PUT(url string, responseModel interface{}, requestBody []byte) error {
+	return c.do(http.MethodPut, url, responseModel, requestBody)
+}
+
+// PATCH sends a PATCH request to the YNAB API
+func (c *ynabClient) PATCH(url string, responseModel interface{}, requestBody []byte) error {
+	return c.do(http.MethodPatch, url, responseModel, requestBody)
+}
+
+// DELETE sends a DELETE request to the YNAB API
+func (c *ynabClient) DELETE(url string, responseModel interface{}) error {
+	return c.do(http.MethodDelete, url, responseModel, nil)
+}
+
+// do sends a request to the YNAB API and decodes its response into responseModel. Retrying creates is safe,
+// since YNAB skips transactions with an import ID it already has.
+func (c *ynabClient) do(method, url string, responseModel interface{}, requestBody []byte) error {
+	response, err := c.retry.Do(c.ctx, c.httpClient, func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(c.ctx, method, c.baseURL+url, bytes.NewReader(requestBody))
+		if err != nil {
+			return nil, err
+		}
+
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", "Bearer "+c.accessToken)
+		if method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		return request, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode >= 400 {
+		parsed := struct {
+			Error *api.Error `json:"error"`
+		}{}
+		if err := json.Unmarshal(body, &parsed); err != nil || parsed.Error == nil {
+			// Responses that don't follow the API's error format still return an *api.Error, like ynab.go does
+			return &api.Error{
+				ID:     strconv.Itoa(response.StatusCode),
+				Name:   "unknown_api_error",
+				Detail: "Unknown API error",
+			}
+		}
+		return parsed.Error
+	}
+
+	return json.Unmarshal(body, &responseModel)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brunomvsouza/ynab.go/api"
+	"github.com/stretchr/testify/assert"
+
+	"psmarcin.github.com/open-ynab-sync/internal/retry"
+)
+
+func TestYNABClient(t *testing.T) {
+	newClient := func(handler http.HandlerFunc) *ynabClient {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		return newYNABClient(context.Background(), "token", server.URL, retry.Policy{MaxAttempts: 2})
+	}
+
+	t.Run("retries server errors", func(t *testing.T) {
+		calls := 0
+		c := newClient(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			if calls == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+		})
+
+		var response struct {
+			Data struct {
+				OK bool `json:"ok"`
+			} `json:"data"`
+		}
+		err := c.GET("/budgets", &response)
+		assert.NoError(t, err)
+		assert.True(t, response.Data.OK)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("returns API errors", func(t *testing.T) {
+		c := newClient(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"id":"404.2","name":"resource_not_found","detail":"Resource not found"}}`))
+		})
+
+		err := c.POST("/budgets/b/transactions", nil, []byte(`{}`))
+		var apiErr *api.Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "resource_not_found", apiErr.Name)
+	})
+
+	t.Run("returns unknown errors", func(t *testing.T) {
+		c := newClient(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+		c.retry = retry.Policy{MaxAttempts: 1, MaxBackoff: time.Second}
+
+		err := c.GET("/budgets", nil)
+		var apiErr *api.Error
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "502", apiErr.ID)
+	})
+
+	t.Run("stops retrying on shutdown", func(t *testing.T) {
+		calls := 0
+		c := newClient(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		c.ctx = ctx
+		c.retry = retry.Policy{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+
+		// The client is waiting an hour for its second attempt by then
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		err := c.GET("/budgets", nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls)
+	})
+}
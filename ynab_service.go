@@ -1,24 +1,110 @@
 package main
 
 import (
-	"github.com/brunomvsouza/ynab.go"
+	"context"
+	"fmt"
+
+	"github.com/brunomvsouza/ynab.go/api/account"
+	"github.com/brunomvsouza/ynab.go/api/budget"
+	"github.com/brunomvsouza/ynab.go/api/category"
 	"github.com/brunomvsouza/ynab.go/api/transaction"
+
+	"psmarcin.github.com/open-ynab-sync/internal/retry"
 )
 
 // YNABService implements the YNABServicer interface
 type YNABService struct {
-	client ynaber
+	transactions *transaction.Service
+	budgets      *budget.Service
+	accounts     *account.Service
+	categories   *category.Service
+	// client sends the requests ynab.go doesn't have a service for
+	client *ynabClient
 }
 
-// NewYNABService creates a new YNABServicer
-func NewYNABService(token string) YNABServicer {
-	client := ynab.NewClient(token)
+// NewYNABService creates a new YNABServicer talking to the API at baseURL and retrying failed requests according to
+// retryPolicy. Cancelling ctx stops its requests and their retries.
+func NewYNABService(ctx context.Context, token, baseURL string, retryPolicy retry.Policy) YNABServicer {
+	client := newYNABClient(ctx, token, baseURL, retryPolicy)
 	return &YNABService{
-		client: client.Transaction(),
+		transactions: transaction.NewService(client),
+		budgets:      budget.NewService(client),
+		accounts:     account.NewService(client),
+		categories:   category.NewService(client),
+		client:       client,
 	}
 }
 
 // CreateTransactions creates transactions in YNAB
 func (s *YNABService) CreateTransactions(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error) {
-	return s.client.CreateTransactions(budgetID, p)
+	return s.transactions.CreateTransactions(budgetID, p)
+}
+
+// UpdateTransactions updates existing transactions in YNAB
+func (s *YNABService) UpdateTransactions(budgetID string, p []transaction.PayloadTransaction) (*transaction.OperationSummary, error) {
+	return s.transactions.UpdateTransactions(budgetID, p)
+}
+
+// DeleteTransaction deletes a transaction in YNAB
+func (s *YNABService) DeleteTransaction(budgetID, transactionID string) (*transaction.Transaction, error) {
+	return s.transactions.DeleteTransaction(budgetID, transactionID)
+}
+
+// GetTransactionsByAccount lists transactions of an account in YNAB
+func (s *YNABService) GetTransactionsByAccount(budgetID, accountID string, f *transaction.Filter) ([]*transaction.Transaction, error) {
+	return s.transactions.GetTransactionsByAccount(budgetID, accountID, f)
+}
+
+// GetBudgets lists the budgets the token has access to
+func (s *YNABService) GetBudgets() ([]*budget.Summary, error) {
+	return s.budgets.GetBudgets()
+}
+
+// GetBudgetSettings gets the settings of a budget in YNAB, including its currency
+func (s *YNABService) GetBudgetSettings(budgetID string) (*budget.Settings, error) {
+	return s.budgets.GetBudgetSettings(budgetID)
+}
+
+// GetAccounts lists the accounts of a budget in YNAB
+func (s *YNABService) GetAccounts(budgetID string) ([]*account.Account, error) {
+	snapshot, err := s.accounts.GetAccounts(budgetID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Accounts, nil
+}
+
+// GetCategories lists the category groups of a budget in YNAB with their categories
+func (s *YNABService) GetCategories(budgetID string) ([]*category.GroupWithCategories, error) {
+	snapshot, err := s.categories.GetCategories(budgetID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.GroupWithCategories, nil
+}
+
+// GetTransferPayeeIDs maps the IDs of the accounts of a budget to the IDs of their transfer payees, the payees
+// transactions moving money to them are created with. ynab.go doesn't decode them, so accounts are requested directly.
+func (s *YNABService) GetTransferPayeeIDs(budgetID string) (map[string]string, error) {
+	var response struct {
+		Data struct {
+			Accounts []struct {
+				ID              string  `json:"id"`
+				TransferPayeeID *string `json:"transfer_payee_id"`
+				Deleted         bool    `json:"deleted"`
+			} `json:"accounts"`
+		} `json:"data"`
+	}
+	if err := s.client.GET(fmt.Sprintf("/budgets/%s/accounts", budgetID), &response); err != nil {
+		return nil, err
+	}
+
+	payeeIDs := make(map[string]string, len(response.Data.Accounts))
+	for _, account := range response.Data.Accounts {
+		if account.Deleted || account.TransferPayeeID == nil {
+			continue
+		}
+		payeeIDs[account.ID] = *account.TransferPayeeID
+	}
+	return payeeIDs, nil
 }
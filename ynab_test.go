@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -24,7 +25,7 @@ func TestToYNABTransaction(t *testing.T) {
 	ynabAccountID := "account123"
 
 	// Test
-	ynabTransactions := toYNABTransaction(ynabAccountID, gcTransactions)
+	ynabTransactions := toYNABTransaction(job{YNABAccountID: ynabAccountID, ImportIDStrategy: importIDAmountDate}, JobState{}, gcTransactions)
 
 	// Assert
 	assert.Len(t, ynabTransactions, 1)
@@ -45,6 +46,88 @@ func TestToYNABTransaction(t *testing.T) {
 	assert.Equal(t, expectedImportID, *tx.ImportID)
 }
 
+func TestToYNABTransactionCategory(t *testing.T) {
+	date := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	ynabTransactions := toYNABTransaction(job{ImportIDStrategy: importIDAmountDate}, JobState{}, []Transaction{
+		{ID: "tx1", Date: date, AmountMili: -4500, Name: "Coffee", Category: "Eating Out", CategoryID: "cat1", Approved: true},
+		{ID: "tx2", Date: date, AmountMili: -100, Name: "Bakery", Category: "Unknown"},
+	})
+
+	if assert.Len(t, ynabTransactions, 2) {
+		if assert.NotNil(t, ynabTransactions[0].CategoryID) {
+			assert.Equal(t, "cat1", *ynabTransactions[0].CategoryID)
+		}
+		assert.True(t, ynabTransactions[0].Approved)
+		// A category that wasn't resolved in the budget isn't sent
+		assert.Nil(t, ynabTransactions[1].CategoryID)
+		assert.False(t, ynabTransactions[1].Approved)
+	}
+}
+
+func TestToYNABTransactionMemoLength(t *testing.T) {
+	date := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	ynabTransactions := toYNABTransaction(job{ImportIDStrategy: importIDAmountDate}, JobState{}, []Transaction{
+		{ID: "tx1", Date: date, AmountMili: -4500, Name: "Coffee", Memo: strings.Repeat("ż", 250)},
+	})
+
+	if assert.Len(t, ynabTransactions, 1) {
+		assert.Equal(t, strings.Repeat("ż", maxMemoLength), *ynabTransactions[0].Memo)
+	}
+}
+
+func TestToYNABTransactionImportIDs(t *testing.T) {
+	date := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	gcTransactions := []Transaction{
+		{ID: "tx1", Date: date, AmountMili: -4500, Name: "Coffee"},
+		{ID: "tx2", Date: date, AmountMili: -4500, Name: "Coffee"},
+		{ID: "", Date: date, AmountMili: -4500, Name: "Coffee"},
+		{ID: "a-very-long-bank-transaction-identifier-0001", Date: date, AmountMili: -100, Name: "Bakery"},
+	}
+
+	importIDs := func(payloadTransactions []transaction.PayloadTransaction) []string {
+		var ids []string
+		for _, p := range payloadTransactions {
+			ids = append(ids, *p.ImportID)
+		}
+		return ids
+	}
+
+	t.Run("amount and date", func(t *testing.T) {
+		ynabTransactions := toYNABTransaction(job{ImportIDStrategy: importIDAmountDate}, JobState{}, gcTransactions)
+		assert.Equal(t, []string{
+			"YNAB:-4500:2023-01-01:1",
+			"YNAB:-4500:2023-01-01:2",
+			"YNAB:-4500:2023-01-01:3",
+			"YNAB:-100:2023-01-01:1",
+		}, importIDs(ynabTransactions))
+	})
+
+	t.Run("amount and date skips occurrences of uploaded transactions", func(t *testing.T) {
+		state := JobState{}
+		state.MarkUploaded("tx2", UploadedTransaction{ImportID: "YNAB:-4500:2023-01-01:1"})
+		state.MarkUploaded("YNAB:-4500:2023-01-01:3", UploadedTransaction{ImportID: "YNAB:-4500:2023-01-01:3"})
+
+		ynabTransactions := toYNABTransaction(job{ImportIDStrategy: importIDAmountDate}, state, gcTransactions)
+		assert.Equal(t, []string{
+			"YNAB:-4500:2023-01-01:2",
+			"YNAB:-4500:2023-01-01:1",
+			// Recorded without a bank ID, so it may be this transaction
+			"YNAB:-4500:2023-01-01:3",
+			"YNAB:-100:2023-01-01:1",
+		}, importIDs(ynabTransactions))
+	})
+
+	t.Run("transaction ID", func(t *testing.T) {
+		ynabTransactions := toYNABTransaction(job{ImportIDStrategy: importIDTransactionID}, JobState{}, gcTransactions)
+		ids := importIDs(ynabTransactions)
+		assert.Equal(t, "GC:tx1", ids[0])
+		assert.Equal(t, "GC:tx2", ids[1])
+		assert.Equal(t, "YNAB:-4500:2023-01-01:1", ids[2])
+		assert.Len(t, ids[3], maxImportIDLength)
+		assert.Equal(t, ids[3], toTransactionImportID(gcTransactions[3]))
+	})
+}
+
 func TestToImportID(t *testing.T) {
 	// Setup
 	date := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -101,12 +184,13 @@ func TestUploadToYNAB(t *testing.T) {
 	}
 
 	ynaberMock := newMockynaber(t)
-	ynabTransactions := toYNABTransaction(ynabAccountID, transactions)
+	ynabTransactions := toYNABTransaction(job{YNABAccountID: ynabAccountID}, JobState{}, transactions)
 	ynaberMock.EXPECT().CreateTransactions(ynabBudgetID, ynabTransactions).Return(&transaction.OperationSummary{Transactions: []*transaction.Transaction{{}}}, nil)
 
 	// Test
-	err := uploadToYNAB(ctx, ynaberMock, ynabAccountID, ynabBudgetID, transactions)
+	result, err := uploadToYNAB(ctx, ynaberMock, ynabBudgetID, ynabTransactions)
 
 	// Assert
 	assert.NoError(t, err)
+	assert.Len(t, result.Transactions, 1)
 }